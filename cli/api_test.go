@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	"github.com/spf13/viper"
+)
+
+// testAPI is a fake Spinup api for command tests.  Responses are keyed by method and path, like
+// "GET /api/v3/spaces/myspace/containers/web", and are either a value that's returned as json or a
+// testHandler.  Paths without a response return a 404.
+type testAPI struct {
+	mu        sync.Mutex
+	responses map[string]interface{}
+	requests  []*testRequest
+}
+
+// testHandler returns the status and the value to return as json for a request to the fake api
+type testHandler func(r *testRequest) (int, interface{})
+
+// testRequest is a request made to the fake api
+type testRequest struct {
+	Method string
+	Path   string
+	Body   []byte
+}
+
+// newTestAPI starts a fake api and points SpinupClient at it, the deployment journal is written to a
+// temporary directory.  Both are restored when the test finishes.
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	api := &testAPI{responses: map[string]interface{}{}}
+	ts := httptest.NewServer(api)
+
+	client, err := spinup.New(ts.URL, ts.Client(), "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldClient, oldJournal := SpinupClient, viper.GetString("deployment_journal")
+	SpinupClient = client
+	viper.Set("deployment_journal", filepath.Join(t.TempDir(), "deployments.json"))
+
+	t.Cleanup(func() {
+		ts.Close()
+		SpinupClient = oldClient
+		viper.Set("deployment_journal", oldJournal)
+	})
+
+	return api
+}
+
+// set sets the response for a method and path
+func (a *testAPI) set(key string, response interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.responses[key] = response
+}
+
+// requested returns the requests made with the method and path
+func (a *testAPI) requested(key string) []*testRequest {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := []*testRequest{}
+	for _, r := range a.requests {
+		if r.Method+" "+r.Path == key {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (a *testAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := &testRequest{Method: r.Method, Path: r.URL.Path, Body: body}
+
+	a.mu.Lock()
+	a.requests = append(a.requests, req)
+	response, ok := a.responses[r.Method+" "+r.URL.Path]
+	a.mu.Unlock()
+
+	status := http.StatusOK
+	switch {
+	case !ok:
+		status, response = http.StatusNotFound, map[string]string{"message": "not found"}
+	default:
+		if h, isHandler := response.(testHandler); isHandler {
+			status, response = h(req)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,627 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/journal"
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	applyFile   string
+	applyPrune  bool
+	applyDryRun bool
+	applyYes    bool
+)
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().StringVarP(&applyFile, "file", "f", "", "The manifest to apply ('-' for stdin)")
+	applyCmd.Flags().BoolVar(&applyPrune, "prune", false, "Delete resources that aren't in the manifest, for the kinds of resources it lists")
+	applyCmd.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print the planned changes without changing anything")
+	applyCmd.Flags().BoolVarP(&applyYes, "yes", "y", false, "Don't prompt for confirmation before deleting pruned resources")
+}
+
+// ApplyResult is the result of applying a change from a manifest
+type ApplyResult struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (a *ApplyResult) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"KIND", "kind"},
+		{"NAME", "name"},
+		{"ACTION", "action"},
+		{"STATUS", "status"},
+	}
+
+	if wide {
+		columns = append(columns, tableColumn{"ERROR", "error"})
+	}
+
+	return columns
+}
+
+// apply result statuses
+const (
+	applyDone      = "done"
+	applyFailed    = "failed"
+	applySkipped   = "skipped"
+	applyUnchanged = "unchanged"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply -f [manifest]",
+	Short: "Create, update and optionally delete the resources in a space to match a manifest",
+	Long: `Apply a space manifest.  Resources in the manifest are created or updated in dependency order,
+secrets before the containers that use them.  Fields that aren't in the manifest are left as they are.
+
+With --prune, resources of the kinds listed in the manifest that aren't in it are deleted, after asking
+for confirmation.  An empty list (eg. "databases: []") prunes every resource of that kind.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if applyFile == "" {
+			return errors.New("a manifest is required (--file)")
+		}
+
+		m, err := manifest.Load(applyFile)
+		if err != nil {
+			return err
+		}
+
+		if err := resolveSecretValues(m, applyFile); err != nil {
+			return err
+		}
+
+		space, err := manifestSpace(m)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("applying manifest %s to space %s", applyFile, space)
+
+		current, err := liveManifest(ctx, space, m)
+		if err != nil {
+			return err
+		}
+
+		changes := manifest.Plan(m, current, applyPrune)
+		if err := checkPlan(changes); err != nil {
+			return err
+		}
+
+		color, _ := colorEnabled("auto", os.Stderr)
+		writeDiff(os.Stderr, space, applyFile, changes, color)
+
+		if applyDryRun {
+			return formatOutput(changes)
+		}
+
+		if err := confirmPrune(space, changes); err != nil {
+			return err
+		}
+
+		results, err := applyChanges(ctx, space, changes)
+		if oerr := formatOutput(results); oerr != nil {
+			return oerr
+		}
+
+		return err
+	},
+}
+
+// checkPlan returns an error for changes that can't be applied, before anything is changed
+func checkPlan(changes []*manifest.Change) error {
+	var errs []string
+	for _, c := range changes {
+		switch d := c.Desired.(type) {
+		case *manifest.Secret:
+			if c.Action == manifest.ActionCreate && !d.Managed() {
+				errs = append(errs, fmt.Sprintf("secret %s doesn't exist and has no fromEnv or fromFile to create it from", c.Name))
+			}
+		case *manifest.ContainerService:
+			if c.Action == manifest.ActionCreate && d.SizeID == 0 {
+				errs = append(errs, fmt.Sprintf("container %s doesn't exist and has no sizeId to create it with", c.Name))
+			}
+		case *manifest.Database:
+			if c.Action == manifest.ActionCreate && d.SizeID == 0 {
+				errs = append(errs, fmt.Sprintf("database %s doesn't exist and has no sizeId to create it with", c.Name))
+			}
+		case *manifest.Storage:
+			for _, f := range c.Fields {
+				if f.Field == "sizeId" {
+					errs = append(errs, fmt.Sprintf("storage %s can't be resized", c.Name))
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to apply the manifest:\n  %s", strings.Join(errs, "\n  "))
+	}
+
+	return nil
+}
+
+// confirmPrune prompts the user to confirm deleting pruned resources, unless --yes was passed
+func confirmPrune(space string, changes []*manifest.Change) error {
+	deletes := 0
+	for _, c := range changes {
+		if c.Action == manifest.ActionDelete {
+			deletes++
+		}
+	}
+
+	if deletes == 0 || applyYes {
+		return nil
+	}
+
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 || applyFile == "-" {
+		return errors.New("refusing to delete pruned resources without confirmation, pass --yes to delete non-interactively")
+	}
+
+	fmt.Fprintf(os.Stderr, "Delete %d resources from %s? This cannot be undone. [y/N]: ", deletes, space)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+
+	return errors.New("apply cancelled")
+}
+
+// applyChanges applies the changes in order, printing progress to stderr.  It stops at the first failure
+// and the remaining changes are skipped.
+func applyChanges(ctx context.Context, space string, changes []*manifest.Change) ([]*ApplyResult, error) {
+	total := 0
+	for _, c := range changes {
+		if c.Action != manifest.ActionUnchanged {
+			total++
+		}
+	}
+
+	a := &applier{space: space, secretArns: map[string]string{}}
+
+	var failure error
+	results := make([]*ApplyResult, 0, len(changes))
+	step := 0
+	for _, c := range changes {
+		r := &ApplyResult{Action: string(c.Action), Kind: c.Kind, Name: c.Name}
+		results = append(results, r)
+
+		switch {
+		case c.Action == manifest.ActionUnchanged:
+			r.Status = applyUnchanged
+			continue
+		case failure != nil:
+			r.Status = applySkipped
+			continue
+		}
+
+		step++
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s %s %s... ", step, total, strings.TrimSuffix(string(c.Action), "e")+"ing", c.Kind, c.Name)
+
+		r.Status = applyDone
+		if err := a.apply(ctx, c); err != nil {
+			r.Status = applyFailed
+			r.Error = err.Error()
+			failure = fmt.Errorf("failed to %s %s %s: %s", c.Action, c.Kind, c.Name, err)
+		}
+		fmt.Fprintln(os.Stderr, r.Status)
+
+		// notes like new access keys are printed even if the change failed part way
+		for _, n := range a.notes {
+			fmt.Fprintf(os.Stderr, "  %s\n", n)
+		}
+		a.notes = nil
+	}
+
+	return results, failure
+}
+
+// applier applies changes to a space
+type applier struct {
+	space string
+
+	// secretArns caches the ARNs of secrets referenced by container definitions
+	secretArns map[string]string
+
+	// notes are printed after the current change is applied
+	notes []string
+}
+
+func (a *applier) params(name string) map[string]string {
+	return map[string]string{"space": a.space, "name": name}
+}
+
+func (a *applier) apply(ctx context.Context, c *manifest.Change) error {
+	switch c.Kind {
+	case manifest.KindSecret:
+		return a.applySecret(ctx, c)
+	case manifest.KindStorage:
+		return a.applyStorage(ctx, c)
+	case manifest.KindDatabase:
+		return a.applyDatabase(ctx, c)
+	case manifest.KindContainer:
+		return a.applyContainer(ctx, c)
+	}
+
+	return fmt.Errorf("unknown kind %s", c.Kind)
+}
+
+func (a *applier) applySecret(ctx context.Context, c *manifest.Change) error {
+	params := map[string]string{"space": a.space, "secretname": c.Name}
+
+	switch c.Action {
+	case manifest.ActionCreate:
+		d := c.Desired.(*manifest.Secret)
+		input, err := json.Marshal(spinup.SecretInput{Name: d.Name, Value: d.Value, Description: d.Description})
+		if err != nil {
+			return err
+		}
+
+		out := &spinup.CreateSecretOutput{}
+		if err := SpinupClient.PostResourceCtx(ctx, params, input, out); err != nil {
+			return err
+		}
+		a.secretArns[d.Name] = out.ARN
+
+		return nil
+	case manifest.ActionUpdate:
+		d := c.Desired.(*manifest.Secret)
+		input, err := json.Marshal(spinup.SecretUpdateInput{Value: d.Value, Description: d.Description})
+		if err != nil {
+			return err
+		}
+		return SpinupClient.PutResourceCtx(ctx, params, input, &spinup.Secret{})
+	case manifest.ActionDelete:
+		return SpinupClient.DeleteResourceCtx(ctx, params, &spinup.Secret{})
+	}
+
+	return nil
+}
+
+func (a *applier) applyStorage(ctx context.Context, c *manifest.Change) error {
+	params := a.params(c.Name)
+
+	switch c.Action {
+	case manifest.ActionCreate:
+		d := c.Desired.(*manifest.Storage)
+
+		in := spinup.S3StorageInput{Name: d.Name}
+		if d.SizeID != 0 {
+			size := spinup.FlexInt(d.SizeID)
+			in.Size = &size
+		}
+
+		input, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+
+		if err := SpinupClient.PostResourceCtx(ctx, params, input, &spinup.CreateS3StorageOutput{}); err != nil {
+			return err
+		}
+
+		return a.updateStorageUsers(ctx, d.Name, d.Users, nil)
+	case manifest.ActionUpdate:
+		d := c.Desired.(*manifest.Storage)
+		current := c.Current.(*manifest.Storage)
+
+		if d.Users == nil {
+			return nil
+		}
+		return a.updateStorageUsers(ctx, d.Name, d.Users, current.Users)
+	case manifest.ActionDelete:
+		info := &spinup.S3StorageInfo{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return err
+		}
+
+		if !info.Empty {
+			return errors.New("refusing to delete a bucket that is not empty")
+		}
+
+		return SpinupClient.DeleteResourceCtx(ctx, params, info)
+	}
+
+	return nil
+}
+
+// updateStorageUsers creates the desired users that don't exist and deletes the current users that aren't
+// desired.  The access keys of new users are noted since they can't be retrieved later.
+func (a *applier) updateStorageUsers(ctx context.Context, name string, desired, current []string) error {
+	want := map[string]bool{}
+	for _, u := range desired {
+		want[u] = true
+	}
+
+	have := map[string]bool{}
+	for _, u := range current {
+		have[u] = true
+	}
+
+	for _, u := range desired {
+		if have[u] {
+			continue
+		}
+
+		input, err := json.Marshal(spinup.S3StorageUserInput{Username: u})
+		if err != nil {
+			return err
+		}
+
+		out := &spinup.CreateS3StorageUserOutput{}
+		if err := SpinupClient.PostResourceCtx(ctx, a.params(name), input, out); err != nil {
+			return fmt.Errorf("failed to create user %s: %s", u, err)
+		}
+
+		if out.AccessKey != nil {
+			a.notes = append(a.notes, fmt.Sprintf("created user %s, access key id %s, secret access key %s (it won't be shown again)",
+				u, out.AccessKey.AccessKeyId, out.AccessKey.SecretAccessKey))
+		}
+	}
+
+	for _, u := range current {
+		if want[u] {
+			continue
+		}
+
+		params := map[string]string{"space": a.space, "name": name, "username": u}
+		if err := SpinupClient.DeleteResourceCtx(ctx, params, &spinup.S3StorageUser{}); err != nil {
+			return fmt.Errorf("failed to delete user %s: %s", u, err)
+		}
+	}
+
+	return nil
+}
+
+func (a *applier) applyDatabase(ctx context.Context, c *manifest.Change) error {
+	params := a.params(c.Name)
+
+	switch c.Action {
+	case manifest.ActionCreate, manifest.ActionUpdate:
+		d := c.Desired.(*manifest.Database)
+		size := spinup.FlexInt(d.SizeID)
+
+		if c.Action == manifest.ActionUpdate {
+			input, err := json.Marshal(spinup.DatabaseInput{Size: &size})
+			if err != nil {
+				return err
+			}
+			return SpinupClient.PutResourceCtx(ctx, params, input, &spinup.DatabaseInfo{})
+		}
+
+		input, err := json.Marshal(spinup.DatabaseInput{Name: d.Name, Size: &size})
+		if err != nil {
+			return err
+		}
+		return SpinupClient.PostResourceCtx(ctx, params, input, &spinup.CreateDatabaseOutput{})
+	case manifest.ActionDelete:
+		return SpinupClient.DeleteResourceCtx(ctx, params, &spinup.DatabaseInfo{})
+	}
+
+	return nil
+}
+
+func (a *applier) applyContainer(ctx context.Context, c *manifest.Change) error {
+	params := a.params(c.Name)
+
+	switch c.Action {
+	case manifest.ActionCreate:
+		d := c.Desired.(*manifest.ContainerService)
+
+		defs, err := a.containerDefinitions(ctx, d, nil)
+		if err != nil {
+			return err
+		}
+
+		desiredCount := int64(1)
+		if d.DesiredCount != nil {
+			desiredCount = *d.DesiredCount
+		}
+
+		size := spinup.FlexInt(d.SizeID)
+		input, err := json.Marshal(spinup.ContainerServiceInput{
+			Name: d.Name,
+			Size: &size,
+			Service: &spinup.ContainerServiceDefinitionInput{
+				CapacityProviderStrategy: capacityProviderStrategy(d.Capacity, nil),
+				ContainerDefinitions:     defs,
+				DesiredCount:             desiredCount,
+				PlatformVersion:          "LATEST",
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Debugf("posting input: %s", string(input))
+
+		return SpinupClient.PostResourceCtx(ctx, params, input, &spinup.CreateContainerServiceOutput{})
+	case manifest.ActionUpdate:
+		d := c.Desired.(*manifest.ContainerService)
+
+		// start from the current service so the fields that aren't in the manifest are kept
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return err
+		}
+		recordRevision(params, info, journal.SourceObserved)
+
+		defs, err := a.containerDefinitions(ctx, d, info.TaskDefinition.ContainerDefinitions)
+		if err != nil {
+			return err
+		}
+
+		desiredCount := info.DesiredCount
+		if d.DesiredCount != nil {
+			desiredCount = *d.DesiredCount
+		}
+
+		var size *spinup.FlexInt
+		if d.SizeID != 0 {
+			s := spinup.FlexInt(d.SizeID)
+			size = &s
+		} else if current, ok := c.Current.(*manifest.ContainerService); ok && current.SizeID != 0 {
+			s := spinup.FlexInt(current.SizeID)
+			size = &s
+		}
+
+		input, err := json.Marshal(spinup.ContainerServiceInput{
+			ForceRedeploy: true,
+			Size:          size,
+			Service: &spinup.ContainerServiceDefinitionInput{
+				CapacityProviderStrategy: capacityProviderStrategy(d.Capacity, info.CapacityProviderStrategy),
+				ContainerDefinitions:     defs,
+				DesiredCount:             desiredCount,
+				PlatformVersion:          "LATEST",
+				Volumes:                  info.TaskDefinition.Volumes,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Debugf("putting input: %s", string(input))
+
+		if err := SpinupClient.PutResourceCtx(ctx, params, input, &spinup.ContainerService{}); err != nil {
+			return err
+		}
+		recordDeployed(ctx, params, "spinup apply")
+
+		return nil
+	case manifest.ActionDelete:
+		return SpinupClient.DeleteResourceCtx(ctx, params, &spinup.ContainerService{})
+	}
+
+	return nil
+}
+
+// containerDefinitions overlays the container definitions in the manifest on the current definitions.
+// Definitions that aren't in the manifest are removed, fields that aren't in the manifest are kept.
+func (a *applier) containerDefinitions(ctx context.Context, d *manifest.ContainerService, current []*spinup.ContainerDefinition) ([]*spinup.ContainerDefinition, error) {
+	existing := map[string]*spinup.ContainerDefinition{}
+	for _, cd := range current {
+		existing[cd.Name] = cd
+	}
+
+	defs := make([]*spinup.ContainerDefinition, 0, len(d.Definitions))
+	for _, md := range d.Definitions {
+		cd, ok := existing[md.Name]
+		if !ok {
+			cd = &spinup.ContainerDefinition{Name: md.Name, Essential: true}
+		}
+
+		cd.Image = md.Image
+
+		if md.Command != nil {
+			cd.Command = md.Command
+		}
+
+		if md.Ports != nil {
+			cd.PortMappings = []*spinup.ContainerPortMapping{}
+			for _, p := range md.Ports {
+				port, proto, err := manifest.ParsePort(p)
+				if err != nil {
+					return nil, err
+				}
+
+				cd.PortMappings = append(cd.PortMappings, &spinup.ContainerPortMapping{
+					ContainerPort: port,
+					HostPort:      port,
+					Protocol:      proto,
+				})
+			}
+		}
+
+		if md.Env != nil {
+			cd.Environment = []*spinup.NameValue{}
+			for _, k := range sortedMapKeys(md.Env) {
+				cd.Environment = append(cd.Environment, &spinup.NameValue{Name: k, Value: md.Env[k]})
+			}
+		}
+
+		if md.Secrets != nil {
+			cd.Secrets = []*spinup.NameValueFrom{}
+			for _, k := range sortedMapKeys(md.Secrets) {
+				arn, err := a.secretArn(ctx, md.Secrets[k])
+				if err != nil {
+					return nil, fmt.Errorf("secret %s for %s in %s: %s", md.Secrets[k], k, md.Name, err)
+				}
+				cd.Secrets = append(cd.Secrets, &spinup.NameValueFrom{Name: k, ValueFrom: arn})
+			}
+		}
+
+		defs = append(defs, cd)
+	}
+
+	return defs, nil
+}
+
+// secretArn returns the ARN of a secret in the space
+func (a *applier) secretArn(ctx context.Context, name string) (string, error) {
+	if arn, ok := a.secretArns[name]; ok {
+		return arn, nil
+	}
+
+	secret := &spinup.Secret{}
+	if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"space": a.space, "secretname": name}, secret); err != nil {
+		return "", err
+	}
+	a.secretArns[name] = secret.ARN
+
+	return secret.ARN, nil
+}
+
+// capacityProviderStrategy returns the capacity provider strategy for the capacity in a manifest, or the
+// current strategy if the capacity isn't set
+func capacityProviderStrategy(capacity string, current []*spinup.ContainerCapacityProviderStrategyItem) []*spinup.ContainerCapacityProviderInput {
+	switch capacity {
+	case manifest.CapacitySpot:
+		return []*spinup.ContainerCapacityProviderInput{{Base: 1, CapacityProvider: spotCapacityProvider, Weight: 1}}
+	case manifest.CapacityOnDemand:
+		return []*spinup.ContainerCapacityProviderInput{{Base: 1, CapacityProvider: "FARGATE", Weight: 1}}
+	}
+
+	strategy := make([]*spinup.ContainerCapacityProviderInput, 0, len(current))
+	for _, cp := range current {
+		strategy = append(strategy, &spinup.ContainerCapacityProviderInput{
+			Base:             int64(cp.Base),
+			CapacityProvider: cp.CapacityProvider,
+			Weight:           int64(cp.Weight),
+		})
+	}
+	return strategy
+}
+
+// sortedMapKeys returns the keys of the map in order
+func sortedMapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
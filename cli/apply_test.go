@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+const (
+	testContainerPath = "/api/v3/spaces/myspace/containers/web"
+	testSecretArn     = "arn:aws:secretsmanager:us-east-1:123456789012:secret:db-password-AbCdEf"
+)
+
+// testContainerService returns a container service with a volume, a secret and a field that isn't in
+// manifests
+func testContainerService() *spinup.ContainerService {
+	info := &spinup.ContainerService{
+		DesiredCount:             2,
+		ServiceArn:               "arn:aws:ecs:us-east-1:123456789012:service/web",
+		CapacityProviderStrategy: []*spinup.ContainerCapacityProviderStrategyItem{{Base: 1, CapacityProvider: "FARGATE", Weight: 1}},
+	}
+	info.TaskDefinition.Revision = 3
+	info.TaskDefinition.TaskDefinitionArn = "arn:aws:ecs:us-east-1:123456789012:task-definition/web:3"
+	info.TaskDefinition.Volumes = []*spinup.ContainerVolume{
+		{Name: "data", EfsVolumeConfiguration: &spinup.ContainerEfsVolumeConfiguration{FileSystemId: "fs-123"}},
+	}
+	info.TaskDefinition.ContainerDefinitions = []*spinup.ContainerDefinition{
+		{
+			Name:        "app",
+			Image:       "nginx:1.25",
+			Essential:   true,
+			Memory:      512,
+			Environment: []*spinup.NameValue{{Name: "LOG_LEVEL", Value: "debug"}},
+			MountPoints: []*spinup.ContainerMountPoint{{ContainerPath: "/data", SourceVolume: "data"}},
+		},
+	}
+	return info
+}
+
+func TestCheckPlan(t *testing.T) {
+	tests := []struct {
+		change   *manifest.Change
+		expected string
+	}{
+		{
+			change:   &manifest.Change{Action: manifest.ActionCreate, Kind: manifest.KindSecret, Name: "api-key", Desired: &manifest.Secret{Name: "api-key"}},
+			expected: "secret api-key doesn't exist and has no fromEnv or fromFile",
+		},
+		{
+			change: &manifest.Change{Action: manifest.ActionCreate, Kind: manifest.KindSecret, Name: "api-key", Desired: &manifest.Secret{Name: "api-key", FromEnv: "API_KEY"}},
+		},
+		{
+			change: &manifest.Change{Action: manifest.ActionUpdate, Kind: manifest.KindSecret, Name: "api-key", Desired: &manifest.Secret{Name: "api-key"}},
+		},
+		{
+			change:   &manifest.Change{Action: manifest.ActionCreate, Kind: manifest.KindContainer, Name: "web", Desired: &manifest.ContainerService{Name: "web"}},
+			expected: "container web doesn't exist and has no sizeId",
+		},
+		{
+			change: &manifest.Change{Action: manifest.ActionUpdate, Kind: manifest.KindContainer, Name: "web", Desired: &manifest.ContainerService{Name: "web"}},
+		},
+		{
+			change:   &manifest.Change{Action: manifest.ActionCreate, Kind: manifest.KindDatabase, Name: "db", Desired: &manifest.Database{Name: "db"}},
+			expected: "database db doesn't exist and has no sizeId",
+		},
+		{
+			change:   &manifest.Change{Action: manifest.ActionUpdate, Kind: manifest.KindStorage, Name: "bucket", Desired: &manifest.Storage{Name: "bucket"}, Fields: []*manifest.FieldChange{{Field: "sizeId"}}},
+			expected: "storage bucket can't be resized",
+		},
+		{
+			change: &manifest.Change{Action: manifest.ActionUpdate, Kind: manifest.KindStorage, Name: "bucket", Desired: &manifest.Storage{Name: "bucket"}, Fields: []*manifest.FieldChange{{Field: "users"}}},
+		},
+	}
+
+	for _, test := range tests {
+		err := checkPlan([]*manifest.Change{test.change})
+		switch {
+		case test.expected == "" && err != nil:
+			t.Errorf("expected nil error for %s %s %s, got %s", test.change.Action, test.change.Kind, test.change.Name, err)
+		case test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)):
+			t.Errorf("expected error containing %q, got %v", test.expected, err)
+		}
+	}
+
+	// every problem is reported at once
+	err := checkPlan([]*manifest.Change{tests[0].change, tests[3].change})
+	if err == nil || strings.Count(err.Error(), "\n") != 2 {
+		t.Errorf("expected two problems, got %v", err)
+	}
+}
+
+func TestApplyContainerUpdate(t *testing.T) {
+	api := newTestAPI(t)
+	api.set("GET "+testContainerPath, testContainerService())
+	api.set("PUT "+testContainerPath, map[string]string{})
+	api.set("GET /api/v3/spaces/myspace/secrets/db-password", &spinup.Secret{Name: "db-password", ARN: testSecretArn})
+
+	count := int64(3)
+	change := &manifest.Change{
+		Action: manifest.ActionUpdate,
+		Kind:   manifest.KindContainer,
+		Name:   "web",
+		Desired: &manifest.ContainerService{
+			Name:         "web",
+			DesiredCount: &count,
+			Definitions: []*manifest.Container{
+				{Name: "app", Image: "nginx:1.26", Secrets: map[string]string{"DB_PASSWORD": "db-password"}},
+			},
+		},
+		Current: &manifest.ContainerService{Name: "web", SizeID: 4},
+	}
+
+	results, err := applyChanges(context.Background(), "myspace", []*manifest.Change{change})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(results) != 1 || results[0].Status != applyDone {
+		t.Errorf("unexpected results %+v", results[0])
+	}
+
+	puts := api.requested("PUT " + testContainerPath)
+	if len(puts) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(puts))
+	}
+
+	input := spinup.ContainerServiceInput{}
+	if err := json.Unmarshal(puts[0].Body, &input); err != nil {
+		t.Fatal(err)
+	}
+
+	if !input.ForceRedeploy || input.Size == nil || *input.Size != 4 || input.Service.DesiredCount != 3 {
+		t.Errorf("unexpected input %s", puts[0].Body)
+	}
+
+	if !reflect.DeepEqual(input.Service.Volumes, testContainerService().TaskDefinition.Volumes) {
+		t.Errorf("expected the volumes to be kept, got %s", puts[0].Body)
+	}
+
+	if c := input.Service.CapacityProviderStrategy; len(c) != 1 || c[0].CapacityProvider != "FARGATE" {
+		t.Errorf("expected the capacity provider strategy to be kept, got %s", puts[0].Body)
+	}
+
+	// the manifest fields are overlaid on the current definition
+	cd := input.Service.ContainerDefinitions[0]
+	if cd.Image != "nginx:1.26" || cd.Memory != 512 || len(cd.MountPoints) != 1 || len(cd.Environment) != 1 {
+		t.Errorf("unexpected container definition %+v", cd)
+	}
+
+	if len(cd.Secrets) != 1 || cd.Secrets[0].Name != "DB_PASSWORD" || cd.Secrets[0].ValueFrom != testSecretArn {
+		t.Errorf("expected the secret to be resolved to its arn, got %+v", cd.Secrets)
+	}
+}
+
+func TestApplyContainerCreate(t *testing.T) {
+	api := newTestAPI(t)
+	api.set("POST /api/v3/spaces/myspace/containers", map[string]string{})
+
+	change := &manifest.Change{
+		Action: manifest.ActionCreate,
+		Kind:   manifest.KindContainer,
+		Name:   "web",
+		Desired: &manifest.ContainerService{
+			Name:        "web",
+			SizeID:      4,
+			Capacity:    manifest.CapacitySpot,
+			Definitions: []*manifest.Container{{Name: "app", Image: "nginx", Ports: []string{"8080"}}},
+		},
+	}
+
+	if _, err := applyChanges(context.Background(), "myspace", []*manifest.Change{change}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	posts := api.requested("POST /api/v3/spaces/myspace/containers")
+	if len(posts) != 1 {
+		t.Fatalf("expected 1 create, got %d", len(posts))
+	}
+
+	input := spinup.ContainerServiceInput{}
+	if err := json.Unmarshal(posts[0].Body, &input); err != nil {
+		t.Fatal(err)
+	}
+
+	if input.Name != "web" || input.Service.DesiredCount != 1 || input.Service.CapacityProviderStrategy[0].CapacityProvider != spotCapacityProvider {
+		t.Errorf("unexpected input %s", posts[0].Body)
+	}
+
+	cd := input.Service.ContainerDefinitions[0]
+	if !cd.Essential || cd.PortMappings[0].ContainerPort != 8080 || cd.PortMappings[0].Protocol != "tcp" {
+		t.Errorf("unexpected container definition %+v", cd)
+	}
+}
+
+func TestApplyChangesFailure(t *testing.T) {
+	api := newTestAPI(t)
+	api.set("POST /api/v3/spaces/myspace/secrets", testHandler(func(r *testRequest) (int, interface{}) {
+		return http.StatusBadRequest, map[string]string{"message": "invalid secret"}
+	}))
+
+	changes := []*manifest.Change{
+		{Action: manifest.ActionUnchanged, Kind: manifest.KindSecret, Name: "old", Desired: &manifest.Secret{Name: "old"}},
+		{Action: manifest.ActionCreate, Kind: manifest.KindSecret, Name: "api-key", Desired: &manifest.Secret{Name: "api-key", Value: "s3cret"}},
+		{Action: manifest.ActionDelete, Kind: manifest.KindDatabase, Name: "db", Current: &manifest.Database{Name: "db"}},
+	}
+
+	results, err := applyChanges(context.Background(), "myspace", changes)
+	if err == nil || !strings.Contains(err.Error(), "failed to create secret api-key") {
+		t.Errorf("expected the secret create to fail, got %v", err)
+	}
+
+	var statuses []string
+	for _, r := range results {
+		statuses = append(statuses, r.Status)
+	}
+
+	if expected := []string{applyUnchanged, applyFailed, applySkipped}; !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("expected statuses %v, got %v", expected, statuses)
+	}
+
+	if !strings.Contains(results[1].Error, "invalid secret") {
+		t.Errorf("expected the api error in the result, got %q", results[1].Error)
+	}
+
+	if deletes := api.requested("DELETE /api/v3/spaces/myspace/databases/db"); len(deletes) != 0 {
+		t.Error("expected the changes after the failure to be skipped")
+	}
+}
@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// defaultTokenExpiryWarning is how long before the token expires to start warning about it
+const defaultTokenExpiryWarning = 7 * 24 * time.Hour
+
+var (
+	authOffline bool
+
+	// tokenSource describes where the token was read from
+	tokenSource string
+
+	// tokenExpiryWarning is how long before the token expires to start warning, 0 disables the warning
+	tokenExpiryWarning time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authStatusCmd.Flags().BoolVar(&authOffline, "offline", false, "Only decode the token, don't verify it with Spinup")
+}
+
+// tokenClaims are the claims in the payload of a Spinup API token
+type tokenClaims map[string]interface{}
+
+// parseToken decodes the payload of a JWT without verifying the signature, that's up to the server
+func parseToken(tokenString string) (tokenClaims, error) {
+	parts := strings.SplitN(tokenString, ".", 3)
+	if l := len(parts); l != 3 {
+		return nil, fmt.Errorf("invalid token, unexpected number of parts (%d)", l)
+	}
+
+	// JWTs are base64url encoded, but accept standard encoding as well
+	payload := strings.TrimRight(parts[1], "=")
+	rawPayload, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		if rawPayload, err = base64.RawStdEncoding.DecodeString(payload); err != nil {
+			return nil, fmt.Errorf("invalid token, unable to decode payload: %s", err)
+		}
+	}
+
+	log.Debugf("got payload: %s", string(rawPayload))
+
+	var claims tokenClaims
+	if err := json.Unmarshal(rawPayload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token, unable to unmarshal payload: %s", err)
+	}
+
+	return claims, nil
+}
+
+// time returns a numeric date claim like exp or nbf
+func (t tokenClaims) time(claim string) (time.Time, bool) {
+	v, ok := t[claim].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(v), 0), true
+}
+
+// string returns a string claim
+func (t tokenClaims) string(claim string) string {
+	s, _ := t[claim].(string)
+	return s
+}
+
+// strings returns a claim that's either a string, a space separated string or a list of strings
+func (t tokenClaims) strings(claims ...string) []string {
+	for _, c := range claims {
+		switch v := t[c].(type) {
+		case string:
+			return strings.Fields(v)
+		case []interface{}:
+			out := make([]string, 0, len(v))
+			for _, s := range v {
+				out = append(out, fmt.Sprint(s))
+			}
+			return out
+		}
+	}
+	return nil
+}
+
+// warnTokenExpiry warns when the token expires within the tokenExpiryWarning window
+func warnTokenExpiry(claims tokenClaims) {
+	// tokens from spinup login are refreshed before they expire
+	if tokenExpiryWarning <= 0 || tokenRefreshable {
+		return
+	}
+
+	exp, ok := claims.time("exp")
+	if !ok {
+		return
+	}
+
+	if remaining := time.Until(exp); remaining > 0 && remaining < tokenExpiryWarning {
+		log.Warnf("the spinup token for profile %s expires in %s (%s), get a new token and run `spinup configure --profile %s`",
+			activeProfile, formatLifetime(remaining), exp.Local().Format(time.RFC1123), activeProfile)
+	}
+}
+
+// formatLifetime formats a duration in days, hours and minutes
+func formatLifetime(d time.Duration) string {
+	d = d.Round(time.Minute)
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+
+	hours := d / time.Hour
+	d -= hours * time.Hour
+
+	minutes := d / time.Minute
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+	return fmt.Sprintf("%dm", minutes)
+}
+
+// AuthStatus is the output of auth status
+type AuthStatus struct {
+	Profile     string                 `json:"profile"`
+	URL         string                 `json:"url"`
+	Source      string                 `json:"source"`
+	Subject     string                 `json:"subject,omitempty"`
+	Issuer      string                 `json:"issuer,omitempty"`
+	Audience    []string               `json:"audience,omitempty"`
+	Scopes      []string               `json:"scopes,omitempty"`
+	IssuedAt    string                 `json:"issued_at,omitempty"`
+	NotBefore   string                 `json:"not_before,omitempty"`
+	ExpiresAt   string                 `json:"expires_at,omitempty"`
+	ExpiresIn   string                 `json:"expires_in,omitempty"`
+	Status      string                 `json:"status"`
+	Refreshable bool                   `json:"refreshable"`
+	Verified    *bool                  `json:"verified,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Claims      map[string]interface{} `json:"claims"`
+}
+
+func (a *AuthStatus) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"PROFILE", "profile"},
+		{"SUBJECT", "subject"},
+		{"STATUS", "status"},
+		{"EXPIRES IN", "expires_in"},
+		{"VERIFIED", "verified"},
+	}
+
+	if wide {
+		columns = append(columns,
+			tableColumn{"ISSUER", "issuer"},
+			tableColumn{"SCOPES", "scopes"},
+			tableColumn{"ISSUED AT", "issued_at"},
+			tableColumn{"EXPIRES AT", "expires_at"},
+			tableColumn{"SOURCE", "source"},
+		)
+	}
+
+	return columns
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Inspect the Spinup API token",
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the claims and lifetime of the token and verify it with Spinup",
+	Long: `Show the subject, issuer, scopes and lifetime of the token for the active profile, and verify that
+Spinup accepts it.  Exits non-zero if the token is invalid, expired or rejected by Spinup, so it can be
+used as a pre-flight check in pipelines.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if spinupToken == "" {
+			return fmt.Errorf("no token is configured for profile %s, run `spinup configure --profile %s`", activeProfile, activeProfile)
+		}
+
+		claims, err := parseToken(spinupToken)
+		if err != nil {
+			return err
+		}
+
+		out := &AuthStatus{
+			Profile:     activeProfile,
+			URL:         spinupURL,
+			Source:      tokenSource,
+			Subject:     claims.string("sub"),
+			Issuer:      claims.string("iss"),
+			Audience:    claims.strings("aud"),
+			Scopes:      claims.strings("scope", "scopes", "scp"),
+			Status:      "valid",
+			Refreshable: tokenRefreshable,
+			Claims:      claims,
+		}
+
+		if iat, ok := claims.time("iat"); ok {
+			out.IssuedAt = iat.Format(time.RFC3339)
+		}
+
+		if nbf, ok := claims.time("nbf"); ok {
+			out.NotBefore = nbf.Format(time.RFC3339)
+			if time.Now().Before(nbf) {
+				out.Status = "not yet valid"
+			}
+		}
+
+		exp, hasExp := claims.time("exp")
+		if hasExp {
+			out.ExpiresAt = exp.Format(time.RFC3339)
+
+			remaining := time.Until(exp)
+			switch {
+			case remaining <= 0:
+				out.Status = "expired"
+				out.ExpiresIn = "-" + formatLifetime(-remaining)
+			case tokenExpiryWarning > 0 && remaining < tokenExpiryWarning && !tokenRefreshable:
+				out.Status = "expiring"
+				out.ExpiresIn = formatLifetime(remaining)
+			default:
+				out.ExpiresIn = formatLifetime(remaining)
+			}
+		}
+
+		// an expired token will be rejected, don't bother asking
+		var verifyErr error
+		if !authOffline && out.Status != "expired" && out.Status != "not yet valid" {
+			verifyErr = verifyToken(cmd, out)
+		}
+
+		if err := formatOutput(out); err != nil {
+			return err
+		}
+
+		switch {
+		case verifyErr != nil:
+			return verifyErr
+		case out.Status == "expired":
+			return fmt.Errorf("token expired at %s", exp.Local().Format(time.RFC1123))
+		case out.Status == "not yet valid":
+			return fmt.Errorf("token is not valid until %s", out.NotBefore)
+		}
+
+		return nil
+	},
+}
+
+// verifyToken makes a lightweight authenticated request to check that Spinup accepts the token
+func verifyToken(cmd *cobra.Command, out *AuthStatus) error {
+	s, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	verified := false
+	out.Verified = &verified
+
+	log.Infof("verifying token with %s", spinupURL)
+
+	if err := s.GetResourceCtx(cmd.Context(), nil, &spinup.Spaces{}); err != nil {
+		msg, _ := errorExit(err)
+		out.Error = msg
+
+		var apiErr *spinup.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			out.Status = "rejected"
+		} else {
+			out.Status = "unknown"
+		}
+
+		return err
+	}
+
+	verified = true
+	return nil
+}
@@ -1,51 +1,88 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 
+	"github.com/YaleSpinup/spinup-cli/pkg/credentials"
+	"github.com/YaleSpinup/spinup-cli/pkg/login"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/term"
 
 	log "github.com/sirupsen/logrus"
 )
 
-var show bool
+var (
+	show              bool
+	credentialStore   string
+	credentialProcess string
+)
 
 func init() {
 	rootCmd.AddCommand(configureCmd)
 	configureCmd.Flags().BoolVar(&show, "show", false, "Display the current configurations")
+	configureCmd.Flags().StringVar(&credentialStore, "credential-store", storeAuto, "Where to store the token, one of: "+strings.Join(credentialStores, ", "))
+	configureCmd.Flags().StringVar(&credentialProcess, "credential-process", "", "A command that prints the token, instead of storing it")
 }
 
 var configureCmd = &cobra.Command{
 	Use:     "configure",
 	Aliases: []string{"config"},
 	Short:   "Configure Spinup CLI",
+	Long: `Configure the url, token and default spaces for Spinup.  Pass --profile to configure a named profile
+for another Spinup instance or token, the profile is created if it doesn't exist.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Debug("Configuring...")
 
 		if show {
-			for k, v := range viper.AllSettings() {
-				fmt.Printf("%s:\t%+v\n", k, v)
-			}
+			showConfig(cmd)
 			return nil
 		}
 
+		if credentialProcess != "" {
+			credentialStore = credentials.BackendProcess
+		} else if credentialStore == credentials.BackendProcess {
+			return errors.New("--credential-process is required with the process credential store")
+		}
+
+		if err := validCredentialStore(credentialStore); err != nil {
+			return err
+		}
+
 		var url, token, spaces string
 
+		fmt.Printf("Profile: %s\n", activeProfile)
+
 		fmt.Printf("URL [%s]: ", spinupURL)
 		fmt.Scanln(&url)
 		if url == "" {
 			url = spinupURL
 		}
-		viper.Set("url", url)
 
-		fmt.Printf("Token [%s]: ", spinupToken)
-		fmt.Scanln(&token)
-		if token == "" {
-			token = spinupToken
+		tokenRef := viper.GetString(profileKey(cmd, "token_ref"))
+
+		// the plaintext token from the config or --token, never a token resolved from token_ref
+		plaintext := spinupToken
+		if tokenRef != "" && tokenSource == tokenRef {
+			plaintext = ""
+		}
+
+		if credentialStore != credentials.BackendProcess {
+			current := maskToken(plaintext)
+			if current == "" && tokenRef != "" {
+				current = "stored in " + tokenRef
+			}
+
+			t, err := readToken(fmt.Sprintf("Token [%s]: ", current))
+			if err != nil {
+				return err
+			}
+			token = t
 		}
-		viper.Set("token", token)
 
 		fmt.Printf("Spaces [%s]: ", strings.Join(spinupSpaces, ","))
 		fmt.Scanln(&spaces)
@@ -53,19 +90,121 @@ var configureCmd = &cobra.Command{
 			spaces = strings.Join(spinupSpaces, ",")
 		}
 
-		spaceNames := strings.Split(spaces, ",")
+		spaceNames := []string{}
 		if spaces != "" {
-			viper.Set("spaces", spaceNames)
-		} else {
-			viper.Set("spaces", []string{})
+			spaceNames = strings.Split(spaces, ",")
 		}
 
-		log.Debugf("setting url %s, token %s, and spaces %+v", url, token, spaces)
+		// keep the existing token when none is entered.  A plaintext token is moved into the credential
+		// store, a stored token is left as is since storing it again would drop the refresh token saved by
+		// spinup login.
+		if token == "" {
+			token = plaintext
+		}
 
-		if err := viper.WriteConfig(); err != nil {
-			return err
+		switch {
+		case credentialStore == credentials.BackendProcess:
+			tokenRef = (&credentials.Ref{Backend: credentials.BackendProcess, Key: credentialProcess}).String()
+		case credentialStore == storePlaintext:
+			if token == "" && tokenRef != "" {
+				raw, err := resolveToken(tokenRef)
+				if err != nil {
+					return err
+				}
+
+				// a token from spinup login is stored with its refresh token
+				t, err := login.DecodeToken(raw)
+				if err != nil {
+					return err
+				}
+				token = t.AccessToken
+			}
+			tokenRef = ""
+		case token != "":
+			ref, err := storeToken(credentialStore, activeProfile, token)
+			if err != nil {
+				return fmt.Errorf("failed to store the token: %s", err)
+			}
+			tokenRef = ref
+			fmt.Fprintf(os.Stderr, "stored the token in %s\n", tokenRef)
 		}
 
-		return nil
+		log.Debugf("setting url %s, spaces %+v and token ref %s for profile %s", url, spaceNames, tokenRef, activeProfile)
+
+		return updateConfig(func(cfg map[string]interface{}) error {
+			settings := cfg
+			if activeProfile != defaultProfile {
+				profiles := configProfiles(cfg)
+
+				p, ok := profiles[activeProfile].(map[string]interface{})
+				if !ok {
+					p = map[string]interface{}{}
+					profiles[activeProfile] = p
+				}
+				settings = p
+			}
+
+			settings["url"] = url
+			settings["spaces"] = spaceNames
+
+			if credentialStore == storePlaintext {
+				settings["token"] = token
+				delete(settings, "token_ref")
+			} else {
+				delete(settings, "token")
+				if tokenRef != "" {
+					settings["token_ref"] = tokenRef
+				}
+			}
+
+			return nil
+		})
 	},
-}
\ No newline at end of file
+}
+
+// readToken prompts for the token without echoing it to the terminal
+func readToken(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		var token string
+		fmt.Print(prompt)
+		fmt.Scanln(&token)
+		return token, nil
+	}
+
+	fmt.Print(prompt)
+	b, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+// showConfig prints the settings for the active profile, with the token masked
+func showConfig(cmd *cobra.Command) {
+	fmt.Printf("profile:\t%s\n", activeProfile)
+	fmt.Printf("url:\t%s\n", spinupURL)
+	if spinupToken != "" {
+		fmt.Printf("token:\t%s\n", maskToken(spinupToken))
+	}
+	if ref := viper.GetString(profileKey(cmd, "token_ref")); ref != "" {
+		fmt.Printf("token_ref:\t%s\n", ref)
+	}
+	fmt.Printf("spaces:\t%+v\n", spinupSpaces)
+
+	keys := []string{}
+	for k := range viper.AllSettings() {
+		switch k {
+		case "url", "token", "token_ref", "spaces", "profiles", "current_profile":
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Printf("%s:\t%+v\n", k, viper.Get(profileKey(cmd, k)))
+	}
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/credentials"
+	"github.com/mitchellh/go-homedir"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+const (
+	// credentialPassphraseEnv holds the passphrase for the encrypted credential file on hosts without a terminal
+	credentialPassphraseEnv = "SPINUP_CREDENTIAL_PASSPHRASE"
+
+	// storeAuto tries the keyring and falls back to the encrypted file
+	storeAuto = "auto"
+
+	// storePlaintext keeps the token in the config file
+	storePlaintext = "plaintext"
+)
+
+var credentialStores = []string{storeAuto, credentials.BackendKeyring, credentials.BackendFile, credentials.BackendProcess, storePlaintext}
+
+// fileStore is reused so the passphrase is only asked for once
+var fileStore *credentials.FileStore
+
+// credentialFilePath returns the path of the encrypted credential file
+func credentialFilePath() (string, error) {
+	if f := viper.GetString("credential_file"); f != "" {
+		return homedir.Expand(f)
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".spinup-credentials.age"), nil
+}
+
+// credentialPassphrase reads the passphrase for the credential file from the environment or the terminal,
+// asking twice when the file is being created
+func credentialPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(credentialPassphraseEnv); p != "" {
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("a passphrase is required for the credential file, set %s", credentialPassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Credential file passphrase: ")
+	p, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		c, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+
+		if string(c) != string(p) {
+			return "", errors.New("passphrases don't match")
+		}
+	}
+
+	return string(p), nil
+}
+
+// newCredentialStore returns the store for a credential reference
+func newCredentialStore(ref *credentials.Ref) (credentials.Store, error) {
+	switch ref.Backend {
+	case credentials.BackendKeyring:
+		return credentials.NewKeyringStore(), nil
+	case credentials.BackendFile:
+		if fileStore == nil {
+			path, err := credentialFilePath()
+			if err != nil {
+				return nil, err
+			}
+			fileStore = &credentials.FileStore{Path: path, Passphrase: credentialPassphrase}
+		}
+		return fileStore, nil
+	case credentials.BackendProcess:
+		return &credentials.ProcessStore{Command: ref.Key}, nil
+	}
+
+	return nil, fmt.Errorf("unknown credential backend %s", ref.Backend)
+}
+
+// resolveToken returns the token for a credential reference like keyring:default
+func resolveToken(tokenRef string) (string, error) {
+	ref, err := credentials.ParseRef(tokenRef)
+	if err != nil {
+		return "", err
+	}
+
+	store, err := newCredentialStore(ref)
+	if err != nil {
+		return "", err
+	}
+
+	log.Debugf("reading token from %s", ref)
+
+	token, err := store.Get(ref.Key)
+	if err != nil {
+		if errors.Is(err, credentials.ErrNotFound) {
+			return "", fmt.Errorf("no token found for %s, run `spinup configure --profile %s`", ref, activeProfile)
+		}
+		return "", err
+	}
+
+	return token, nil
+}
+
+// storeToken saves the token for the profile in the backend and returns the reference to keep in the
+// config.  The auto backend uses the keyring if it's available, otherwise the encrypted file.
+func storeToken(backend, profile, token string) (string, error) {
+	if backend == storeAuto {
+		ref := &credentials.Ref{Backend: credentials.BackendKeyring, Key: profile}
+		err := credentials.NewKeyringStore().Set(ref.Key, token)
+		if err == nil {
+			return ref.String(), nil
+		}
+
+		log.Warnf("%s, storing the token in the encrypted credential file instead", err)
+		backend = credentials.BackendFile
+	}
+
+	ref := &credentials.Ref{Backend: backend, Key: profile}
+	store, err := newCredentialStore(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if err := store.Set(ref.Key, token); err != nil {
+		return "", err
+	}
+
+	return ref.String(), nil
+}
+
+// deleteToken removes a token from the store it references, tokens that are already gone and
+// tokens managed by a credential process are ignored
+func deleteToken(tokenRef string) error {
+	ref, err := credentials.ParseRef(tokenRef)
+	if err != nil {
+		return err
+	}
+
+	store, err := newCredentialStore(ref)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(ref.Key); err != nil && !errors.Is(err, credentials.ErrNotFound) && !errors.Is(err, credentials.ErrReadOnly) {
+		return err
+	}
+
+	return nil
+}
+
+// validCredentialStore returns an error if the store isn't supported
+func validCredentialStore(store string) error {
+	for _, s := range credentialStores {
+		if s == store {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown credential store %q, expected one of: %s", store, strings.Join(credentialStores, ", "))
+}
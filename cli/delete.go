@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteYes     bool
+	deleteWait    bool
+	deleteTimeout time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.PersistentFlags().BoolVarP(&deleteYes, "yes", "y", false, "Don't prompt for confirmation before deleting")
+	deleteCmd.PersistentFlags().BoolVarP(&deleteWait, "wait", "w", false, "Wait until the resource has been removed from the space")
+	deleteCmd.PersistentFlags().DurationVar(&deleteTimeout, "timeout", 10*time.Minute, "How long to wait for the resource to be removed")
+}
+
+var (
+	deleteParams   = map[string]string{}
+	deleteResource = &spinup.Resource{}
+)
+
+// deleteCmdPreRun parses the space/resource argument and sets the global deleteResource
+func deleteCmdPreRun(cmd *cobra.Command, args []string) error {
+	defer timeTrack(time.Now(), "deleteCmdPreRun()")
+
+	ctx := cmd.Context()
+
+	params, err := parseResourceParams(ctx, args)
+	if err != nil {
+		return err
+	}
+	deleteParams = params
+
+	// set the global deleteResource to the passed resource
+	if err := SpinupClient.GetResourceCtx(ctx, deleteParams, deleteResource); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// deleteSpaceLevelCmdPreRun parses the space/name argument for objects that aren't spinup resources
+// (like secrets and images), using the default space when only one is configured
+func deleteSpaceLevelCmdPreRun(cmd *cobra.Command, args []string) error {
+	defer timeTrack(time.Now(), "deleteSpaceLevelCmdPreRun()")
+
+	if len(args) == 0 {
+		return errors.New("space/name required")
+	}
+
+	space, name, err := parseSpaceAndName(args[0])
+	if err != nil {
+		return err
+	}
+
+	deleteParams["space"] = space
+	deleteParams["name"] = name
+
+	return nil
+}
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete [type] [space]/[resource]",
+	Short: "Delete a resource in a space",
+}
+
+// confirmDelete prompts the user to confirm the deletion, unless --yes was passed
+func confirmDelete(kind, space, name string) error {
+	if deleteYes {
+		return nil
+	}
+
+	if fi, err := os.Stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return errors.New("refusing to delete without confirmation, pass --yes to delete non-interactively")
+	}
+
+	fmt.Fprintf(os.Stderr, "Delete %s %s/%s? This cannot be undone. [y/N]: ", kind, space, name)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+
+	return errors.New("delete cancelled")
+}
+
+// deleteOutput prints the result of a delete
+func deleteOutput(kind, space, name, status string) error {
+	return formatOutput(struct {
+		Name   string `json:"name"`
+		Space  string `json:"space"`
+		Type   string `json:"type"`
+		Status string `json:"status"`
+	}{name, space, kind, status})
+}
+
+// deleteSpinupResource deletes a spinup resource using the type specific ResourceType and optionally
+// waits for it to be removed from the space
+func deleteSpinupResource(ctx context.Context, kind string, params map[string]string, r spinup.ResourceType) error {
+	if err := confirmDelete(kind, params["space"], params["name"]); err != nil {
+		return err
+	}
+
+	if err := SpinupClient.DeleteResourceCtx(ctx, params, r); err != nil {
+		return err
+	}
+
+	if !deleteWait {
+		return deleteOutput(kind, params["space"], params["name"], "deleting")
+	}
+
+	if err := waitForDeletion(ctx, params["space"], params["name"], deleteTimeout); err != nil {
+		return err
+	}
+
+	return deleteOutput(kind, params["space"], params["name"], "deleted")
+}
+
+// waitForDeletion polls the resources in a space until the named resource is gone
+func waitForDeletion(ctx context.Context, space, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	interval := 2 * time.Second
+	for {
+		resources, err := SpinupClient.ResourcesCtx(ctx, space)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %s waiting for %s/%s to be deleted", timeout, space, name)
+			}
+			return err
+		}
+
+		found := false
+		for _, r := range resources {
+			if r.Name == name && r.Status != "deleted" {
+				log.Infof("resource %s/%s is %s", space, name, r.Status)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("timed out after %s waiting for %s/%s to be deleted", timeout, space, name)
+			}
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		if interval < 15*time.Second {
+			interval *= 2
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	deleteCmd.AddCommand(deleteContainerCmd)
+}
+
+var deleteContainerCmd = &cobra.Command{
+	Use:     "container [space]/[resource]",
+	Short:   "Delete a container service",
+	PreRunE: deleteCmdPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("delete container: %+v", args)
+
+		return deleteSpinupResource(cmd.Context(), "container", deleteParams, &spinup.ContainerService{})
+	},
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	deleteCmd.AddCommand(deleteDatabaseCmd)
+}
+
+var deleteDatabaseCmd = &cobra.Command{
+	Use:     "database [space]/[resource]",
+	Short:   "Delete a database",
+	PreRunE: deleteCmdPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("delete database: %+v", args)
+
+		return deleteSpinupResource(cmd.Context(), "database", deleteParams, &spinup.DatabaseInfo{})
+	},
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	deleteCmd.AddCommand(deleteImageCmd)
+}
+
+var deleteImageCmd = &cobra.Command{
+	Use:     "image [space]/[id]",
+	Short:   "Delete a server image from a space",
+	PreRunE: deleteSpaceLevelCmdPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("delete image: %+v", args)
+
+		space, id := deleteParams["space"], deleteParams["name"]
+		if err := confirmDelete("image", space, id); err != nil {
+			return err
+		}
+
+		if err := SpinupClient.DeleteResourceCtx(cmd.Context(), deleteParams, &spinup.Image{}); err != nil {
+			return err
+		}
+
+		return deleteOutput("image", space, id, "deleted")
+	},
+}
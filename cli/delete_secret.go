@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	deleteCmd.AddCommand(deleteSecretCmd)
+}
+
+var deleteSecretCmd = &cobra.Command{
+	Use:     "secret [space]/[name]",
+	Short:   "Delete a secret from a space",
+	PreRunE: deleteSpaceLevelCmdPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("delete secret: %+v", args)
+
+		ctx := cmd.Context()
+		space, name := deleteParams["space"], deleteParams["name"]
+		params := map[string]string{"space": space, "secretname": name}
+
+		secret := &spinup.Secret{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, secret); err != nil {
+			return err
+		}
+
+		// deleting a secret that's still referenced will break the next deployment of those services
+		consumers, err := secretConsumers(ctx, space, secret.ARN)
+		if err != nil {
+			return err
+		}
+
+		if len(consumers) > 0 {
+			log.Warnf("secret %s is referenced by container services: %s", name, strings.Join(consumers, ", "))
+		}
+
+		if err := confirmDelete("secret", space, name); err != nil {
+			return err
+		}
+
+		if err := SpinupClient.DeleteResourceCtx(ctx, params, &spinup.Secret{}); err != nil {
+			return err
+		}
+
+		return deleteOutput("secret", space, name, "deleted")
+	},
+}
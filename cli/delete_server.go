@@ -0,0 +1,21 @@
+package cli
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	deleteCmd.AddCommand(deleteServerCmd)
+}
+
+var deleteServerCmd = &cobra.Command{
+	Use:     "server [space]/[resource]",
+	Short:   "Delete a server",
+	PreRunE: deleteCmdPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("delete server: %+v", args)
+
+		return deleteSpinupResource(cmd.Context(), "server", deleteParams, deleteResource)
+	},
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var deleteStorageForce bool
+
+func init() {
+	deleteCmd.AddCommand(deleteStorageCmd)
+	deleteStorageCmd.Flags().BoolVar(&deleteStorageForce, "force", false, "Delete the storage even if the bucket is not empty")
+}
+
+var deleteStorageCmd = &cobra.Command{
+	Use:     "storage [space]/[resource]",
+	Short:   "Delete a storage service",
+	PreRunE: deleteCmdPreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("delete storage: %+v", args)
+
+		ctx := cmd.Context()
+
+		switch deleteResource.Type.Flavor {
+		case "s3", "s3bucket":
+			info := &spinup.S3StorageInfo{}
+			if err := SpinupClient.GetResourceCtx(ctx, deleteParams, info); err != nil {
+				return err
+			}
+
+			if !info.Empty && !deleteStorageForce {
+				return errors.New("refusing to delete a bucket that is not empty, pass --force to delete it anyway")
+			}
+
+			return deleteSpinupResource(ctx, "storage", deleteParams, info)
+		case "efs":
+			return deleteSpinupResource(ctx, "storage", deleteParams, deleteResource)
+		default:
+			return fmt.Errorf("unknown flavor: %s", deleteResource.Type.Flavor)
+		}
+	},
+}
@@ -0,0 +1,343 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+)
+
+// crashLoopLimit is the number of tasks from a deployment that can stop before it's considered failed
+const crashLoopLimit = 3
+
+// deploymentPollInterval is how often a deployment is polled
+var deploymentPollInterval = 5 * time.Second
+
+// deploymentTracker follows the deployment of a container service after an update, until the desired
+// number of tasks from the new deployment are running and healthy
+type deploymentTracker struct {
+	params map[string]string
+
+	// newRevision is true when the update registers a new task definition revision
+	newRevision bool
+
+	// replaceTasks is true when the update replaces the tasks that were running before it
+	replaceTasks bool
+
+	// before is the task definition revision before the update, revision is the one being deployed
+	before   int64
+	revision int64
+
+	// oldTasks are the tasks that were running before the update
+	oldTasks map[string]bool
+
+	// seenEvents are the service events that have already been printed
+	seenEvents map[string]bool
+
+	// tasks are the tasks from the deployment, by id
+	tasks map[string]*deploymentTask
+
+	progress string
+}
+
+// deploymentTask is the last known state of a task from the deployment
+type deploymentTask struct {
+	ID            string
+	LastStatus    string
+	HealthStatus  string
+	StoppedReason string
+	Containers    []string
+}
+
+// newDeploymentTracker snapshots the container service before an update, so the tasks and events from
+// the deployment can be told apart from the ones before it
+func newDeploymentTracker(ctx context.Context, params map[string]string, newRevision, replaceTasks bool) (*deploymentTracker, error) {
+	info := &spinup.ContainerService{}
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
+	}
+
+	t := &deploymentTracker{
+		params:       params,
+		newRevision:  newRevision,
+		replaceTasks: replaceTasks,
+		before:       info.TaskDefinition.Revision,
+		oldTasks:     map[string]bool{},
+		seenEvents:   map[string]bool{},
+		tasks:        map[string]*deploymentTask{},
+	}
+
+	for _, task := range info.Tasks {
+		t.oldTasks[taskID(task)] = true
+	}
+
+	for _, e := range info.Events {
+		t.seenEvents[e.ID] = true
+	}
+
+	return t, nil
+}
+
+// wait polls the container service until the deployment completes, fails or the timeout passes.  New
+// service events and task failures are printed to stderr as they appear.
+func (t *deploymentTracker) wait(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	name := t.params["space"] + "/" + t.params["name"]
+	var last *spinup.ContainerService
+	for {
+		info, err := t.poll(ctx)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				break
+			}
+			return err
+		}
+
+		if info != nil {
+			last = info
+			if t.complete(info) {
+				fmt.Fprintf(os.Stderr, "deployment of %s revision %d complete, %d/%d tasks running\n", name, t.revision, info.RunningCount, info.DesiredCount)
+				return nil
+			}
+
+			if stopped := t.stoppedTasks(); len(stopped) >= crashLoopLimit {
+				return fmt.Errorf("deployment of %s revision %d failed, %d tasks stopped: %s", name, t.revision, len(stopped), t.summary(info))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(deploymentPollInterval):
+		}
+
+		if ctx.Err() == context.DeadlineExceeded {
+			break
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if last == nil {
+		return fmt.Errorf("timed out after %s waiting for the new task definition of %s to be deployed", timeout, name)
+	}
+
+	return fmt.Errorf("timed out after %s waiting for the deployment of %s revision %d: %s", timeout, name, t.revision, t.summary(last))
+}
+
+// poll gets the current state of the service and its tasks, it returns nil until the revision being
+// deployed is known
+func (t *deploymentTracker) poll(ctx context.Context) (*spinup.ContainerService, error) {
+	info := &spinup.ContainerService{}
+	if err := SpinupClient.GetResourceCtx(ctx, t.params, info); err != nil {
+		return nil, err
+	}
+
+	// events are returned newest first
+	for i := len(info.Events) - 1; i >= 0; i-- {
+		e := info.Events[i]
+		if !t.seenEvents[e.ID] {
+			t.seenEvents[e.ID] = true
+			fmt.Fprintf(os.Stderr, "  %s %s\n", e.CreatedAt, e.Message)
+		}
+	}
+
+	if t.revision == 0 {
+		if t.newRevision && info.TaskDefinition.Revision <= t.before {
+			log.Infof("waiting for a task definition newer than revision %d", t.before)
+			return nil, nil
+		}
+
+		t.revision = info.TaskDefinition.Revision
+		fmt.Fprintf(os.Stderr, "following deployment of revision %d\n", t.revision)
+	}
+
+	// poll the tasks that are listed and the ones from the deployment that may have stopped since
+	ids := []string{}
+	listed := map[string]bool{}
+	for _, task := range info.Tasks {
+		id := taskID(task)
+		listed[id] = true
+		if !t.replaceTasks || !t.oldTasks[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	for id, task := range t.tasks {
+		if !listed[id] && task.LastStatus != "STOPPED" {
+			ids = append(ids, id)
+		}
+	}
+
+	tasks := make([]*spinup.ContainerTask, len(ids))
+	if err := SpinupClient.Parallel(ctx, len(ids), func(ctx context.Context, i int) error {
+		task := &spinup.ContainerTask{}
+		params := map[string]string{"space": t.params["space"], "name": t.params["name"], "taskId": ids[i]}
+		if err := SpinupClient.GetResourceCtx(ctx, params, task); err != nil {
+			// stopped tasks are eventually forgotten
+			if spinup.IsNotFound(err) {
+				log.Debugf("task %s not found", ids[i])
+				return nil
+			}
+			return err
+		}
+		tasks[i] = task
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for i, out := range tasks {
+		if out == nil {
+			if dt, ok := t.tasks[ids[i]]; ok {
+				dt.LastStatus = "STOPPED"
+			}
+			continue
+		}
+
+		for _, task := range out.Tasks {
+			if taskRevision(task.TaskDefinitionArn) != t.revision {
+				continue
+			}
+
+			dt := &deploymentTask{
+				ID:            ids[i],
+				LastStatus:    task.LastStatus,
+				HealthStatus:  task.HealthStatus,
+				StoppedReason: task.StoppedReason,
+			}
+
+			for _, c := range task.Containers {
+				switch {
+				case c.ExitCode != "" && c.Reason != "":
+					dt.Containers = append(dt.Containers, fmt.Sprintf("%s exited with code %s: %s", c.Name, c.ExitCode, c.Reason))
+				case c.ExitCode != "":
+					dt.Containers = append(dt.Containers, fmt.Sprintf("%s exited with code %s", c.Name, c.ExitCode))
+				case c.Reason != "":
+					dt.Containers = append(dt.Containers, fmt.Sprintf("%s: %s", c.Name, c.Reason))
+				}
+			}
+
+			if prev, ok := t.tasks[dt.ID]; !ok || prev.LastStatus != dt.LastStatus || prev.HealthStatus != dt.HealthStatus {
+				fmt.Fprintf(os.Stderr, "  task %s %s\n", dt.ID, dt.describe())
+			}
+			t.tasks[dt.ID] = dt
+		}
+	}
+
+	progress := fmt.Sprintf("revision %d: %d/%d running, %d pending, %d stopped", t.revision, info.RunningCount, info.DesiredCount, info.PendingCount, len(t.stoppedTasks()))
+	if progress != t.progress {
+		fmt.Fprintln(os.Stderr, progress)
+		t.progress = progress
+	}
+
+	return info, nil
+}
+
+// complete returns true when the desired number of tasks from the deployment are running and healthy, and
+// no other tasks are running
+func (t *deploymentTracker) complete(info *spinup.ContainerService) bool {
+	if info.TaskDefinition.Revision != t.revision || info.PendingCount > 0 || info.RunningCount != info.DesiredCount {
+		return false
+	}
+
+	healthCheck := false
+	for _, cd := range info.TaskDefinition.ContainerDefinitions {
+		if cd.HealthCheck != nil {
+			healthCheck = true
+		}
+	}
+
+	running := 0
+	for _, task := range t.tasks {
+		if task.LastStatus != "RUNNING" {
+			continue
+		}
+
+		if healthCheck && task.HealthStatus != "HEALTHY" {
+			continue
+		}
+
+		running++
+	}
+
+	return int64(running) >= info.DesiredCount
+}
+
+// stoppedTasks returns the tasks started by the deployment that have stopped.  Tasks that were running
+// before the update are left out, they stop when the service is scaled down.
+func (t *deploymentTracker) stoppedTasks() []*deploymentTask {
+	stopped := []*deploymentTask{}
+	for id, task := range t.tasks {
+		if task.LastStatus == "STOPPED" && !t.oldTasks[id] {
+			stopped = append(stopped, task)
+		}
+	}
+	return stopped
+}
+
+// summary describes the state of the deployment for an error
+func (t *deploymentTracker) summary(info *spinup.ContainerService) string {
+	parts := []string{
+		fmt.Sprintf("%d/%d tasks running, %d pending", info.RunningCount, info.DesiredCount, info.PendingCount),
+	}
+
+	ids := make([]string, 0, len(t.tasks))
+	for id := range t.tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("task %s %s", id, t.tasks[id].describe()))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// describe returns the status of the task and why it stopped
+func (d *deploymentTask) describe() string {
+	s := strings.ToLower(d.LastStatus)
+	if d.HealthStatus != "" && d.HealthStatus != "UNKNOWN" {
+		s += " " + strings.ToLower(d.HealthStatus)
+	}
+
+	if d.StoppedReason != "" {
+		s += ": " + d.StoppedReason
+	}
+
+	if len(d.Containers) > 0 {
+		s += " (" + strings.Join(d.Containers, ", ") + ")"
+	}
+
+	return s
+}
+
+// taskID returns the id of a task listed in a container service, the part after the cluster
+func taskID(task string) string {
+	if _, id, ok := strings.Cut(task, "/"); ok {
+		return id
+	}
+	return task
+}
+
+// taskRevision returns the revision from a task definition arn, like arn:...:task-definition/family:5
+func taskRevision(arn string) int64 {
+	i := strings.LastIndex(arn, ":")
+	if i < 0 {
+		return 0
+	}
+
+	rev, err := strconv.ParseInt(arn[i+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return rev
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+// testTask is the state of a task at a step of a fake deployment
+type testTask struct {
+	id       string
+	revision int64
+	status   string
+	exitCode string
+}
+
+// testStep is the state of a container service when it's polled, tasks that aren't stopped are listed
+// in the service and tasks that aren't in the step at all return a 404
+type testStep struct {
+	revision int64
+	desired  int64
+	tasks    []testTask
+}
+
+// testDeployment serves a container service whose state advances to the next step each time it's
+// polled, the first step is the snapshot taken before the update
+type testDeployment struct {
+	mu    sync.Mutex
+	step  int
+	steps []testStep
+}
+
+func newTestDeployment(t *testing.T, steps ...testStep) *testDeployment {
+	t.Helper()
+
+	old := deploymentPollInterval
+	deploymentPollInterval = time.Millisecond
+	t.Cleanup(func() { deploymentPollInterval = old })
+
+	d := &testDeployment{step: -1, steps: steps}
+
+	api := newTestAPI(t)
+	api.set("GET "+testContainerPath, testHandler(d.service))
+
+	ids := map[string]bool{}
+	for _, s := range steps {
+		for _, task := range s.tasks {
+			if !ids[task.id] {
+				ids[task.id] = true
+				api.set("GET "+testContainerPath+"/tasks/"+task.id, testHandler(d.task))
+			}
+		}
+	}
+
+	return d
+}
+
+func (d *testDeployment) service(r *testRequest) (int, interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.step < len(d.steps)-1 {
+		d.step++
+	}
+	s := d.steps[d.step]
+
+	info := &spinup.ContainerService{DesiredCount: s.desired, Tasks: []string{}}
+	info.TaskDefinition.Revision = s.revision
+	for _, task := range s.tasks {
+		switch task.status {
+		case "RUNNING":
+			info.RunningCount++
+		case "PENDING":
+			info.PendingCount++
+		}
+
+		if task.status != "STOPPED" {
+			info.Tasks = append(info.Tasks, "spinup/"+task.id)
+		}
+	}
+
+	return http.StatusOK, info
+}
+
+func (d *testDeployment) task(r *testRequest) (int, interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := r.Path[strings.LastIndex(r.Path, "/")+1:]
+	for _, task := range d.steps[d.step].tasks {
+		if task.id != id {
+			continue
+		}
+
+		container := map[string]string{"Name": "app", "ExitCode": task.exitCode}
+		return http.StatusOK, map[string]interface{}{
+			"Tasks": []map[string]interface{}{
+				{
+					"LastStatus":        task.status,
+					"TaskDefinitionArn": fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task-definition/web:%d", task.revision),
+					"Containers":        []map[string]string{container},
+				},
+			},
+		}
+	}
+
+	return http.StatusNotFound, map[string]string{"message": "task not found"}
+}
+
+// waitDeployment tracks a deployment through the steps of a fake one
+func waitDeployment(t *testing.T, newRevision, replaceTasks bool, steps ...testStep) error {
+	t.Helper()
+
+	newTestDeployment(t, steps...)
+
+	params := map[string]string{"space": "myspace", "name": "web"}
+	tracker, err := newDeploymentTracker(context.Background(), params, newRevision, replaceTasks)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	return tracker.wait(context.Background(), 5*time.Second)
+}
+
+func TestDeploymentScaleUp(t *testing.T) {
+	err := waitDeployment(t, false, false,
+		testStep{revision: 3, desired: 2, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}}},
+		testStep{revision: 3, desired: 4, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"c", 3, "PENDING", ""}, {"d", 3, "PENDING", ""}}},
+		testStep{revision: 3, desired: 4, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"c", 3, "RUNNING", ""}, {"d", 3, "RUNNING", ""}}},
+	)
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+}
+
+func TestDeploymentScaleDown(t *testing.T) {
+	// the stopped tasks were running before the update, they aren't a crash loop
+	err := waitDeployment(t, false, false,
+		testStep{revision: 3, desired: 5, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"c", 3, "RUNNING", ""}, {"d", 3, "RUNNING", ""}, {"e", 3, "RUNNING", ""}}},
+		testStep{revision: 3, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"c", 3, "RUNNING", ""}, {"d", 3, "RUNNING", ""}, {"e", 3, "RUNNING", ""}}},
+		testStep{revision: 3, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"d", 3, "STOPPED", ""}, {"e", 3, "STOPPED", ""}}},
+		testStep{revision: 3, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "STOPPED", ""}}},
+	)
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+}
+
+func TestDeploymentRollout(t *testing.T) {
+	// one task from the new revision fails and is replaced, which is less than a crash loop
+	err := waitDeployment(t, true, true,
+		testStep{revision: 3, desired: 2, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}}},
+		testStep{revision: 3, desired: 2, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}}},
+		testStep{revision: 4, desired: 2, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"c", 4, "PENDING", ""}, {"d", 4, "PENDING", ""}}},
+		testStep{revision: 4, desired: 2, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 3, "RUNNING", ""}, {"c", 4, "RUNNING", ""}, {"d", 4, "STOPPED", "1"}, {"e", 4, "PENDING", ""}}},
+		testStep{revision: 4, desired: 2, tasks: []testTask{{"a", 3, "STOPPED", ""}, {"c", 4, "RUNNING", ""}, {"e", 4, "RUNNING", ""}}},
+	)
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+}
+
+func TestDeploymentCrashLoop(t *testing.T) {
+	err := waitDeployment(t, true, true,
+		testStep{revision: 3, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}}},
+		testStep{revision: 4, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 4, "PENDING", ""}}},
+		testStep{revision: 4, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"b", 4, "STOPPED", "1"}, {"c", 4, "PENDING", ""}}},
+		testStep{revision: 4, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"c", 4, "STOPPED", "1"}, {"d", 4, "PENDING", ""}}},
+		testStep{revision: 4, desired: 1, tasks: []testTask{{"a", 3, "RUNNING", ""}, {"d", 4, "STOPPED", "1"}, {"e", 4, "PENDING", ""}}},
+	)
+
+	if err == nil || !strings.Contains(err.Error(), "revision 4 failed, 3 tasks stopped") || !strings.Contains(err.Error(), "app exited with code 1") {
+		t.Errorf("expected a crash loop error, got %v", err)
+	}
+}
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	diffFile  string
+	diffPrune bool
+	diffColor string
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&diffFile, "file", "f", "", "The manifest to compare ('-' for stdin)")
+	diffCmd.Flags().BoolVar(&diffPrune, "prune", false, "Include resources that aren't in the manifest, for the kinds of resources it lists")
+	diffCmd.Flags().StringVar(&diffColor, "color", "auto", "Colorize the diff, one of: auto, always, never")
+}
+
+// ANSI colors for the diff
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBold   = "\033[1m"
+)
+
+// driftError is returned when the live state of a space doesn't match the manifest
+type driftError struct {
+	changes int
+}
+
+func (e *driftError) Error() string {
+	return fmt.Sprintf("drift detected, %d resources don't match the manifest", e.changes)
+}
+
+// DiffPlan is the machine readable plan from spinup diff
+type DiffPlan struct {
+	Space   string                  `json:"space"`
+	Drift   bool                    `json:"drift"`
+	Summary map[manifest.Action]int `json:"summary"`
+	Changes []*manifest.Change      `json:"changes"`
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff -f [manifest]",
+	Short: "Show the differences between a manifest and the live state of a space",
+	Long: `Compare a space manifest with the live state of the space and print a field level diff of the changes
+spinup apply would make.  Pass --output (-o) for a machine readable plan instead of the diff.
+
+Exits 0 when the space matches the manifest and 2 when it has drifted, so it can be run on a schedule.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if diffFile == "" {
+			return fmt.Errorf("a manifest is required (--file)")
+		}
+
+		color, err := colorEnabled(diffColor, os.Stdout)
+		if err != nil {
+			return err
+		}
+
+		m, err := manifest.Load(diffFile)
+		if err != nil {
+			return err
+		}
+
+		if err := resolveSecretValues(m, diffFile); err != nil {
+			return err
+		}
+
+		space, err := manifestSpace(m)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("comparing manifest %s with space %s", diffFile, space)
+
+		current, err := liveManifest(ctx, space, m)
+		if err != nil {
+			return err
+		}
+
+		changes := manifest.Plan(m, current, diffPrune)
+
+		plan := &DiffPlan{
+			Space:   space,
+			Drift:   manifest.HasChanges(changes),
+			Summary: planSummary(changes),
+			Changes: changes,
+		}
+
+		if cmd.Flags().Changed("output") {
+			if err := formatOutput(plan); err != nil {
+				return err
+			}
+		} else {
+			writeDiff(os.Stdout, space, diffFile, changes, color)
+		}
+
+		if plan.Drift {
+			return &driftError{changes: len(changes) - plan.Summary[manifest.ActionUnchanged]}
+		}
+
+		return nil
+	},
+}
+
+// colorEnabled returns true if output to the file should be colorized.  In auto mode that's when the
+// file is a terminal and NO_COLOR isn't set.
+func colorEnabled(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if _, ok := os.LookupEnv("NO_COLOR"); ok {
+			return false, nil
+		}
+		return term.IsTerminal(int(f.Fd())), nil
+	}
+
+	return false, fmt.Errorf("unsupported color mode %q, expected auto, always or never", mode)
+}
+
+// colorize wraps s in the ANSI color c when color is enabled
+func colorize(enabled bool, c, s string) string {
+	if !enabled {
+		return s
+	}
+	return c + s + colorReset
+}
+
+// planSummary counts the changes by action
+func planSummary(changes []*manifest.Change) map[manifest.Action]int {
+	summary := map[manifest.Action]int{
+		manifest.ActionCreate:    0,
+		manifest.ActionUpdate:    0,
+		manifest.ActionDelete:    0,
+		manifest.ActionUnchanged: 0,
+	}
+
+	for _, c := range changes {
+		summary[c.Action]++
+	}
+
+	return summary
+}
+
+// writeDiff writes a unified, field level diff of the changes.  Removed values are prefixed with -,
+// added values with + and created or deleted resources are shown in full.
+func writeDiff(w io.Writer, space, source string, changes []*manifest.Change, color bool) {
+	fmt.Fprintln(w, colorize(color, colorBold, "--- live: "+space))
+	fmt.Fprintln(w, colorize(color, colorBold, "+++ manifest: "+source))
+
+	for _, c := range changes {
+		writeChange(w, c, color)
+	}
+
+	summary := planSummary(changes)
+	fmt.Fprintf(w, "%d to create, %d to update, %d to delete, %d unchanged\n",
+		summary[manifest.ActionCreate], summary[manifest.ActionUpdate], summary[manifest.ActionDelete], summary[manifest.ActionUnchanged])
+}
+
+// writeChange writes the diff of a single change
+func writeChange(w io.Writer, c *manifest.Change, color bool) {
+	switch c.Action {
+	case manifest.ActionCreate:
+		fmt.Fprintln(w, colorize(color, colorGreen, fmt.Sprintf("+ %s %s", c.Kind, c.Name)))
+		for _, l := range resourceLines(c.Desired) {
+			fmt.Fprintln(w, colorize(color, colorGreen, "+     "+l))
+		}
+	case manifest.ActionDelete:
+		fmt.Fprintln(w, colorize(color, colorRed, fmt.Sprintf("- %s %s", c.Kind, c.Name)))
+		for _, l := range resourceLines(c.Current) {
+			fmt.Fprintln(w, colorize(color, colorRed, "-     "+l))
+		}
+	case manifest.ActionUpdate:
+		fmt.Fprintln(w, colorize(color, colorYellow, fmt.Sprintf("~ %s %s", c.Kind, c.Name)))
+		for _, f := range c.Fields {
+			if f.Sensitive {
+				fmt.Fprintln(w, colorize(color, colorYellow, fmt.Sprintf("~     %s: (sensitive value changed)", f.Field)))
+				continue
+			}
+
+			if f.Old != nil {
+				fmt.Fprintln(w, colorize(color, colorRed, fmt.Sprintf("-     %s: %s", f.Field, formatFieldValue(f.Old))))
+			}
+
+			if f.New != nil {
+				fmt.Fprintln(w, colorize(color, colorGreen, fmt.Sprintf("+     %s: %s", f.Field, formatFieldValue(f.New))))
+			}
+		}
+	}
+}
+
+// resourceLines returns a manifest resource as lines of YAML
+func resourceLines(r interface{}) []string {
+	buf := &bytes.Buffer{}
+	e := yaml.NewEncoder(buf)
+	e.SetIndent(2)
+	if err := e.Encode(r); err != nil {
+		return []string{fmt.Sprintf("%+v", r)}
+	}
+	e.Close()
+
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// formatFieldValue formats a value in a field change, strings are quoted and everything else is json
+func formatFieldValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(j)
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// DotEnvEntry is a key/value pair from a dotenv file, the comment lines directly above a key are
+// used as the description
+type DotEnvEntry struct {
+	Key         string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+var dotEnvKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.\-/]*$`)
+
+// parseDotEnv parses a dotenv file.  It supports comments, blank lines, an optional 'export ' prefix,
+// single quoted (literal) values, double quoted values with escapes and unquoted values with inline comments.
+func parseDotEnv(r io.Reader) ([]*DotEnvEntry, error) {
+	entries := []*DotEnvEntry{}
+	seen := map[string]bool{}
+
+	var comments []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			comments = nil
+			continue
+		case strings.HasPrefix(line, "#"):
+			comments = append(comments, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+
+		key := strings.TrimSpace(kv[0])
+		if !dotEnvKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNum, key)
+		}
+
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %s", lineNum, key)
+		}
+		seen[key] = true
+
+		raw := strings.TrimSpace(kv[1])
+
+		var value string
+		switch {
+		case strings.HasPrefix(raw, `"`):
+			// double quoted values may span multiple lines
+			for !closedDoubleQuote(raw) && scanner.Scan() {
+				lineNum++
+				raw = raw + "\n" + scanner.Text()
+			}
+
+			v, err := unquoteDouble(raw)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNum, err)
+			}
+			value = v
+		case strings.HasPrefix(raw, `'`):
+			end := strings.Index(raw[1:], `'`)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated single quoted value", lineNum)
+			}
+			value = raw[1 : end+1]
+		default:
+			if i := strings.Index(raw, " #"); i >= 0 {
+				raw = raw[:i]
+			}
+			value = strings.TrimSpace(raw)
+		}
+
+		entries = append(entries, &DotEnvEntry{
+			Key:         key,
+			Value:       value,
+			Description: strings.Join(comments, " "),
+		})
+		comments = nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// closedDoubleQuote returns true if the double quoted string has an unescaped closing quote
+func closedDoubleQuote(s string) bool {
+	escaped := false
+	for _, c := range s[1:] {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteDouble unquotes a double quoted dotenv value, ignoring anything after the closing quote
+func unquoteDouble(s string) (string, error) {
+	var b strings.Builder
+	escaped := false
+	for _, c := range s[1:] {
+		switch {
+		case escaped:
+			switch c {
+			case 'n':
+				b.WriteRune('\n')
+			case 'r':
+				b.WriteRune('\r')
+			case 't':
+				b.WriteRune('\t')
+			default:
+				b.WriteRune(c)
+			}
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			return b.String(), nil
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated double quoted value")
+}
+
+// writeDotEnv writes the entries as a dotenv file, with descriptions as comments
+func writeDotEnv(w io.Writer, entries []*DotEnvEntry) error {
+	for i, e := range entries {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+
+		if e.Description != "" {
+			if _, err := fmt.Fprintf(w, "# %s\n", strings.ReplaceAll(e.Description, "\n", " ")); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", e.Key, quoteDotEnvValue(e.Value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quoteDotEnvValue double quotes a value if it contains characters that wouldn't survive unquoted
+func quoteDotEnvValue(v string) string {
+	if v == "" || !strings.ContainsAny(v, " \t\r\n\"'#\\=$`") {
+		return v
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(v) + `"`
+}
@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+func TestParseDotEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []*DotEnvEntry
+	}{
+		{
+			name:     "unquoted",
+			input:    "KEY=value\nOTHER = spaced out \n",
+			expected: []*DotEnvEntry{{Key: "KEY", Value: "value"}, {Key: "OTHER", Value: "spaced out"}},
+		},
+		{
+			name:     "empty value",
+			input:    "KEY=\n",
+			expected: []*DotEnvEntry{{Key: "KEY"}},
+		},
+		{
+			name:     "export prefix",
+			input:    "export KEY=value\n",
+			expected: []*DotEnvEntry{{Key: "KEY", Value: "value"}},
+		},
+		{
+			name:     "inline comment",
+			input:    "KEY=value # a comment\nHASH=a#b\n",
+			expected: []*DotEnvEntry{{Key: "KEY", Value: "value"}, {Key: "HASH", Value: "a#b"}},
+		},
+		{
+			name:     "single quoted",
+			input:    `KEY='a "literal" \n # value' # a comment`,
+			expected: []*DotEnvEntry{{Key: "KEY", Value: `a "literal" \n # value`}},
+		},
+		{
+			name:     "double quoted escapes",
+			input:    `KEY="tab\there \"quoted\" back\\slash # not a comment" # a comment`,
+			expected: []*DotEnvEntry{{Key: "KEY", Value: "tab\there \"quoted\" back\\slash # not a comment"}},
+		},
+		{
+			name:     "double quoted multi-line",
+			input:    "CERT=\"-----BEGIN-----\nabc\n-----END-----\"\nNEXT=1\n",
+			expected: []*DotEnvEntry{{Key: "CERT", Value: "-----BEGIN-----\nabc\n-----END-----"}, {Key: "NEXT", Value: "1"}},
+		},
+		{
+			name:  "comments as descriptions",
+			input: "# ignored\n\n# the database\n# password\nDB_PASSWORD=secret\nAPI_KEY=key\n",
+			expected: []*DotEnvEntry{
+				{Key: "DB_PASSWORD", Value: "secret", Description: "the database password"},
+				{Key: "API_KEY", Value: "key"},
+			},
+		},
+		{
+			name:     "key characters",
+			input:    "app/db.password-1=x\n",
+			expected: []*DotEnvEntry{{Key: "app/db.password-1", Value: "x"}},
+		},
+	}
+
+	for _, test := range tests {
+		out, err := parseDotEnv(strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: expected nil error, got %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(out, test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.name, entriesString(test.expected), entriesString(out))
+		}
+	}
+}
+
+func TestParseDotEnvErrors(t *testing.T) {
+	tests := map[string]string{
+		"NOVALUE\n":                  "line 1: expected KEY=VALUE",
+		"KEY=1\n1KEY=2\n":            "line 2: invalid key",
+		"KEY=1\nKEY=2\n":             "line 2: duplicate key KEY",
+		"KEY='unterminated\n":        "line 1: unterminated single quoted value",
+		"KEY=\"unterminated\nmore\n": "line 2: unterminated double quoted value",
+	}
+
+	for input, expected := range tests {
+		_, err := parseDotEnv(strings.NewReader(input))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error containing %q for %q, got %v", expected, input, err)
+		}
+	}
+}
+
+func TestClosedDoubleQuote(t *testing.T) {
+	tests := map[string]bool{
+		`"value"`:        true,
+		`"value" # x`:    true,
+		`"value`:         false,
+		`"escaped \"`:    false,
+		`"escaped \\"`:   true,
+		`"multi` + "\n":  false,
+		`"a \" b " rest`: true,
+	}
+
+	for input, expected := range tests {
+		if out := closedDoubleQuote(input); out != expected {
+			t.Errorf("expected %t for %q, got %t", expected, input, out)
+		}
+	}
+}
+
+func TestUnquoteDouble(t *testing.T) {
+	tests := map[string]string{
+		`""`:                 "",
+		`"value" # comment`:  "value",
+		`"a\nb\r\tc"`:        "a\nb\r\tc",
+		`"\"q\" \\ \$ \x"`:   `"q" \ $ x`,
+		"\"line1\nline2\"":   "line1\nline2",
+		`"trailing"garbage"`: "trailing",
+	}
+
+	for input, expected := range tests {
+		out, err := unquoteDouble(input)
+		if err != nil {
+			t.Errorf("expected nil error for %q, got %s", input, err)
+			continue
+		}
+
+		if out != expected {
+			t.Errorf("expected %q for %q, got %q", expected, input, out)
+		}
+	}
+
+	if _, err := unquoteDouble(`"open \"`); err == nil {
+		t.Error("expected error for an unterminated value, got nil")
+	}
+}
+
+func TestQuoteDotEnvValue(t *testing.T) {
+	tests := map[string]string{
+		"":             "",
+		"plain":        "plain",
+		"with space":   `"with space"`,
+		"a#b":          `"a#b"`,
+		"multi\nline":  `"multi\nline"`,
+		`say "hi"`:     `"say \"hi\""`,
+		`back\slash`:   `"back\\slash"`,
+		"it's":         `"it's"`,
+		"k=v":          `"k=v"`,
+		"$HOME":        `"$HOME"`,
+		"tab\tand\rcr": `"tab\tand\rcr"`,
+	}
+
+	for input, expected := range tests {
+		if out := quoteDotEnvValue(input); out != expected {
+			t.Errorf("expected %s for %q, got %s", expected, input, out)
+		}
+	}
+}
+
+func TestWriteDotEnv(t *testing.T) {
+	entries := []*DotEnvEntry{
+		{Key: "API_KEY", Value: "key", Description: "the api\nkey"},
+		{Key: "EMPTY"},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeDotEnv(buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "# the api key\nAPI_KEY=key\n\nEMPTY=\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestDotEnvRoundTrip(t *testing.T) {
+	entries := []*DotEnvEntry{
+		{Key: "API_KEY", Value: "plain", Description: "the api key"},
+		{Key: "CERT", Value: "-----BEGIN-----\r\nabc\n-----END-----\n"},
+		{Key: "QUOTES", Value: `single ' and double " and \n literal`},
+		{Key: "SHELL", Value: "$HOME `pwd` # not a comment", Description: "shell characters"},
+		{Key: "SPACES", Value: "  padded\t"},
+		{Key: "EMPTY"},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeDotEnv(buf, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := parseDotEnv(buf)
+	if err != nil {
+		t.Fatalf("expected nil error parsing %q, got %s", buf.String(), err)
+	}
+
+	if !reflect.DeepEqual(out, entries) {
+		t.Errorf("expected %s, got %s", entriesString(entries), entriesString(out))
+	}
+}
+
+func TestPlanSecretImport(t *testing.T) {
+	api := newTestAPI(t)
+
+	secrets := map[string]*spinup.Secret{
+		"app/same":      {Name: "app/same", Description: "unchanged"},
+		"app/changed":   {Name: "app/changed"},
+		"app/described": {Name: "app/described", Description: "old"},
+		"app/unread":    {Name: "app/unread"},
+		"app/missing":   {Name: "app/missing"},
+		"other":         {Name: "other"},
+	}
+
+	names := spinup.Secrets{}
+	for name, s := range secrets {
+		names = append(names, spinup.SecretName(name))
+		api.set("GET /api/v3/spaces/myspace/secrets/"+name, s)
+		api.set("GET /api/v3/spaces/myspace/secrets/"+name+"/value", &spinup.SecretValue{Name: name, Value: "v1"})
+	}
+	api.set("GET /api/v3/spaces/myspace/secrets", names)
+	api.set("GET /api/v3/spaces/myspace/secrets/app/unread/value", testHandler(func(r *testRequest) (int, interface{}) {
+		return http.StatusForbidden, map[string]string{"message": "access denied"}
+	}))
+
+	oldPrefix, oldDelete := secretsPrefix, secretsDeleteMissing
+	secretsPrefix, secretsDeleteMissing = "app/", true
+	defer func() { secretsPrefix, secretsDeleteMissing = oldPrefix, oldDelete }()
+
+	entries := []*DotEnvEntry{
+		{Key: "same", Value: "v1"},
+		{Key: "changed", Value: "v2"},
+		{Key: "described", Value: "v1", Description: "new"},
+		{Key: "unread", Value: "v1"},
+		{Key: "new", Value: "v1"},
+	}
+
+	changes, err := planSecretImport(context.Background(), "myspace", entries)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	expected := []*SecretChange{
+		{Action: secretUnchanged, Name: "app/same", Key: "same"},
+		{Action: secretUpdate, Name: "app/changed", Key: "changed"},
+		{Action: secretUpdate, Name: "app/described", Key: "described"},
+		{Action: secretUpdate, Name: "app/unread", Key: "unread"},
+		{Action: secretCreate, Name: "app/new", Key: "new"},
+		{Action: secretRemove, Name: "app/missing"},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		var out []string
+		for _, c := range changes {
+			out = append(out, c.Action+" "+c.Name)
+		}
+		t.Errorf("unexpected changes %v", out)
+	}
+
+	// a new description is an update whatever the value, so it isn't read
+	if reads := api.requested("GET /api/v3/spaces/myspace/secrets/app/described/value"); len(reads) != 0 {
+		t.Errorf("expected the value of a secret with a new description not to be read, got %d reads", len(reads))
+	}
+}
+
+func TestSecretsExportImport(t *testing.T) {
+	api := newTestAPI(t)
+
+	values := map[string]string{
+		"app/API_KEY": "plain",
+		"app/CERT":    "-----BEGIN-----\nabc\n-----END-----\n",
+		"app/QUOTED":  `it's "quoted" # with a hash`,
+	}
+
+	names := spinup.Secrets{"other"}
+	for name, value := range values {
+		names = append(names, spinup.SecretName(name))
+		api.set("GET /api/v3/spaces/myspace/secrets/"+name, &spinup.Secret{Name: name, Description: "the " + name})
+		api.set("GET /api/v3/spaces/myspace/secrets/"+name+"/value", &spinup.SecretValue{Name: name, Value: value})
+	}
+	api.set("GET /api/v3/spaces/myspace/secrets", names)
+	api.set("GET /api/v3/spaces/myspace/secrets/other", &spinup.Secret{Name: "other"})
+
+	file := filepath.Join(t.TempDir(), ".env")
+
+	oldFile, oldPrefix, oldFormat, oldReveal := secretsFile, secretsPrefix, secretsFormat, secretsReveal
+	secretsFile, secretsPrefix, secretsFormat, secretsReveal = file, "app/", "dotenv", true
+	defer func() {
+		secretsFile, secretsPrefix, secretsFormat, secretsReveal = oldFile, oldPrefix, oldFormat, oldReveal
+	}()
+
+	secretsExportCmd.SetContext(context.Background())
+	if err := secretsExportCmd.RunE(secretsExportCmd, []string{"myspace"}); err != nil {
+		t.Fatalf("expected nil error exporting, got %s", err)
+	}
+
+	if reads := api.requested("GET /api/v3/spaces/myspace/secrets/other/value"); len(reads) != 0 {
+		t.Error("expected secrets without the prefix not to be read")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	entries, err := parseDotEnv(f)
+	if err != nil {
+		t.Fatalf("expected nil error parsing the export, got %s", err)
+	}
+
+	if len(entries) != len(values) {
+		t.Fatalf("expected %d entries, got %s", len(values), entriesString(entries))
+	}
+
+	// importing the export changes nothing
+	changes, err := planSecretImport(context.Background(), "myspace", entries)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	for _, c := range changes {
+		if c.Action != secretUnchanged {
+			t.Errorf("expected %s to be unchanged, got %s", c.Name, c.Action)
+		}
+	}
+}
+
+func entriesString(entries []*DotEnvEntry) string {
+	var out []string
+	for _, e := range entries {
+		out = append(out, e.Key+"="+strings.ReplaceAll(e.Value, "\n", `\n`)+" ("+e.Description+")")
+	}
+	return "[" + strings.Join(out, ", ") + "]"
+}
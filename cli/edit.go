@@ -0,0 +1,497 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/journal"
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	editCmd.AddCommand(editContainerCmd)
+}
+
+var editCmd = &cobra.Command{
+	Use:   "edit [type] [space]/[resource]",
+	Short: "Edit a resource in a space in an editor",
+}
+
+var editContainerCmd = &cobra.Command{
+	Use:   "container [space]/[resource]",
+	Short: "Edit the definition of a container service in an editor",
+	Long: `Edit the definition of a container service in an editor: the desired count, capacity providers,
+volumes and container definitions, including health checks, ports, mount points, command, entry point
+and ulimits.
+
+The editor is $VISUAL or $EDITOR (default vi, or notepad on Windows).  Secrets are referenced by the name
+of a secret in the space.  When the saved document isn't valid the editor is opened again with the errors
+as comments, save it unchanged or empty to cancel.  The changes are shown and the container service is
+redeployed with the new definition.  Fields of the container definitions that aren't in the document,
+like the log configuration, are kept.`,
+	Example: `  spinup edit container my-space/my-service
+  EDITOR="code --wait" spinup edit container my-service`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		params, err := parseResourceParams(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		resource := &spinup.Resource{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, resource); err != nil {
+			return err
+		}
+
+		if !isContainerService(resource) {
+			return fmt.Errorf("%s/%s is not a container service", params["space"], params["name"])
+		}
+
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return err
+		}
+		recordRevision(params, info, journal.SourceObserved)
+
+		secrets, err := spaceSecrets(ctx, map[string]string{"space": params["space"]})
+		if err != nil {
+			return err
+		}
+
+		current := serviceSpec(info, secrets)
+
+		doc := &bytes.Buffer{}
+		fmt.Fprintf(doc, "# Editing container service %s/%s, task definition %s:%d\n", params["space"], params["name"], info.TaskDefinition.Family, info.TaskDefinition.Revision)
+		fmt.Fprintln(doc, "# Secrets are referenced by name. Save the file empty to cancel the edit.")
+		if err := current.Encode(doc); err != nil {
+			return err
+		}
+
+		desired, path, err := editServiceSpec(doc.Bytes(), func(name string) bool {
+			return secretArnByName(secrets, name) != ""
+		})
+		if err != nil {
+			return err
+		}
+
+		if desired == nil {
+			fmt.Fprintln(os.Stderr, "Edit cancelled, no changes made")
+			return nil
+		}
+
+		fields, err := manifest.DiffServiceSpec(current, desired)
+		if err != nil {
+			return err
+		}
+
+		if len(fields) == 0 {
+			os.Remove(path)
+			fmt.Fprintln(os.Stderr, "Edit cancelled, no changes made")
+			return nil
+		}
+
+		color, err := colorEnabled("auto", os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		writeChange(os.Stderr, &manifest.Change{Action: manifest.ActionUpdate, Kind: manifest.KindContainer, Name: params["name"], Fields: fields}, color)
+
+		out, err := putServiceSpec(ctx, params, resource, info, desired, secrets)
+		if err != nil {
+			return fmt.Errorf("%s (your changes are saved in %s)", err, path)
+		}
+		os.Remove(path)
+		recordDeployed(ctx, params, cmd.CommandPath())
+
+		return formatOutput(out)
+	},
+}
+
+// editServiceSpec opens the document in the editor until it's saved as a valid service spec, adding the
+// errors as comments each time it isn't.  It returns the spec and the file with the edited document, or a
+// nil spec if the edit is cancelled.
+func editServiceSpec(doc []byte, secretExists func(string) bool) (*manifest.ServiceSpec, string, error) {
+	f, err := os.CreateTemp("", "spinup-edit-*.yaml")
+	if err != nil {
+		return nil, "", err
+	}
+	path := f.Name()
+	f.Close()
+
+	for {
+		if err := os.WriteFile(path, doc, 0600); err != nil {
+			return nil, "", err
+		}
+
+		if err := runEditor(path); err != nil {
+			os.Remove(path)
+			return nil, "", err
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+
+		// an invalid document saved as it was, with its errors, cancels the edit
+		if bytes.Equal(edited, doc) && bytes.Contains(doc, []byte("# error: ")) {
+			os.Remove(path)
+			return nil, "", errors.New("edit cancelled, the document is still invalid")
+		}
+
+		edited = manifest.StripErrorComments(edited)
+
+		spec, errs := manifest.ParseServiceSpec(edited, secretExists)
+		if len(errs) == 0 {
+			if spec == nil {
+				os.Remove(path)
+			}
+			return spec, path, nil
+		}
+
+		for _, e := range errs {
+			log.Debugf("invalid container service spec: %s", e)
+		}
+
+		fmt.Fprintf(os.Stderr, "The edited container service isn't valid (%d error(s)), reopening the editor\n", len(errs))
+		doc = manifest.AnnotateErrors(edited, errs)
+	}
+}
+
+// runEditor opens the file in the user's editor and waits for it to exit.  The editor command is run by
+// the shell so it can include arguments.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		if editor == "" {
+			editor = "notepad"
+		}
+		cmd = exec.Command("cmd", "/C", editor+` "`+path+`"`)
+	} else {
+		if editor == "" {
+			editor = "vi"
+		}
+		cmd = exec.Command("sh", "-c", editor+" "+shellJoin([]string{path}))
+	}
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	log.Debugf("running editor %s", cmd.String())
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %s", editor, err)
+	}
+
+	return nil
+}
+
+// serviceSpec returns the editable spec of a container service, with secrets referenced by name when
+// they're in the space
+func serviceSpec(info *spinup.ContainerService, secrets []*spinup.Secret) *manifest.ServiceSpec {
+	s := &manifest.ServiceSpec{DesiredCount: info.DesiredCount}
+
+	for _, cp := range info.CapacityProviderStrategy {
+		s.CapacityProviders = append(s.CapacityProviders, &manifest.CapacityProviderSpec{
+			Name:   cp.CapacityProvider,
+			Base:   int64(cp.Base),
+			Weight: int64(cp.Weight),
+		})
+	}
+
+	for _, v := range info.TaskDefinition.Volumes {
+		vs := &manifest.VolumeSpec{Name: v.Name}
+		if efs := v.EfsVolumeConfiguration; efs != nil {
+			vs.EFS = &manifest.EFSVolumeSpec{
+				FileSystemID:          efs.FileSystemId,
+				RootDirectory:         efs.RootDirectory,
+				TransitEncryption:     efs.TransitEncryption,
+				TransitEncryptionPort: efs.TransitEncryptionPort,
+				AccessPointID:         efs.AuthorizationConfig.AccessPointId,
+				IAM:                   efs.AuthorizationConfig.Iam,
+			}
+		}
+		s.Volumes = append(s.Volumes, vs)
+	}
+
+	for _, cd := range info.TaskDefinition.ContainerDefinitions {
+		c := &manifest.ContainerSpec{
+			Name:                   cd.Name,
+			Image:                  cd.Image,
+			Essential:              cd.Essential,
+			Command:                cd.Command,
+			EntryPoint:             cd.EntryPoint,
+			WorkingDirectory:       cd.WorkingDirectory,
+			User:                   cd.User,
+			CPU:                    cd.CPU,
+			Memory:                 cd.Memory,
+			MemoryReservation:      cd.MemoryReservation,
+			DockerLabels:           cd.DockerLabels,
+			ReadonlyRootFilesystem: cd.ReadonlyRootFilesystem,
+			StartTimeout:           cd.StartTimeout,
+			StopTimeout:            cd.StopTimeout,
+		}
+
+		for _, p := range cd.PortMappings {
+			c.Ports = append(c.Ports, &manifest.PortSpec{ContainerPort: p.ContainerPort, Protocol: p.Protocol})
+		}
+
+		if len(cd.Environment) > 0 {
+			c.Env = map[string]string{}
+			for _, e := range cd.Environment {
+				c.Env[e.Name] = e.Value
+			}
+		}
+
+		if len(cd.Secrets) > 0 {
+			c.Secrets = map[string]string{}
+			for _, sec := range cd.Secrets {
+				c.Secrets[sec.Name] = sec.ValueFrom
+				for _, secret := range secrets {
+					if secretReferencesArn(sec.ValueFrom, secret.ARN) {
+						c.Secrets[sec.Name] = secret.Name
+						break
+					}
+				}
+			}
+		}
+
+		if hc := cd.HealthCheck; hc != nil {
+			c.HealthCheck = &manifest.HealthCheckSpec{
+				Command:     hc.Command,
+				Interval:    hc.Interval,
+				Retries:     hc.Retries,
+				StartPeriod: hc.StartPeriod,
+				Timeout:     hc.Timeout,
+			}
+		}
+
+		for _, m := range cd.MountPoints {
+			c.MountPoints = append(c.MountPoints, &manifest.MountPointSpec{
+				SourceVolume:  m.SourceVolume,
+				ContainerPath: m.ContainerPath,
+				ReadOnly:      m.ReadOnly,
+			})
+		}
+
+		for _, u := range cd.Ulimits {
+			c.Ulimits = append(c.Ulimits, &manifest.UlimitSpec{Name: u.Name, SoftLimit: u.SoftLimit, HardLimit: u.HardLimit})
+		}
+
+		for _, d := range cd.DependsOn {
+			c.DependsOn = append(c.DependsOn, &manifest.DependencySpec{ContainerName: d.ContainerName, Condition: d.Condition})
+		}
+
+		s.Containers = append(s.Containers, c)
+	}
+
+	return s
+}
+
+// specContainerDefinitions overlays the containers in a spec on the current definitions, keeping the fields
+// that aren't in the spec.  Secret names are resolved to their ARNs, references to a key or version of the
+// same secret are kept.
+func specContainerDefinitions(s *manifest.ServiceSpec, current []*spinup.ContainerDefinition, secrets []*spinup.Secret) ([]*spinup.ContainerDefinition, error) {
+	existing := map[string]*spinup.ContainerDefinition{}
+	for _, cd := range current {
+		existing[cd.Name] = cd
+	}
+
+	defs := make([]*spinup.ContainerDefinition, 0, len(s.Containers))
+	for _, c := range s.Containers {
+		cd, ok := existing[c.Name]
+		if !ok {
+			cd = &spinup.ContainerDefinition{Name: c.Name}
+		}
+
+		currentSecrets := map[string]string{}
+		for _, sec := range cd.Secrets {
+			currentSecrets[sec.Name] = sec.ValueFrom
+		}
+
+		cd.Image = c.Image
+		cd.Essential = c.Essential
+		cd.Command = c.Command
+		cd.EntryPoint = c.EntryPoint
+		cd.WorkingDirectory = c.WorkingDirectory
+		cd.User = c.User
+		cd.CPU = c.CPU
+		cd.Memory = c.Memory
+		cd.MemoryReservation = c.MemoryReservation
+		cd.DockerLabels = c.DockerLabels
+		cd.ReadonlyRootFilesystem = c.ReadonlyRootFilesystem
+		cd.StartTimeout = c.StartTimeout
+		cd.StopTimeout = c.StopTimeout
+
+		cd.PortMappings = []*spinup.ContainerPortMapping{}
+		for _, p := range c.Ports {
+			proto := p.Protocol
+			if proto == "" {
+				proto = "tcp"
+			}
+			cd.PortMappings = append(cd.PortMappings, &spinup.ContainerPortMapping{ContainerPort: p.ContainerPort, HostPort: p.ContainerPort, Protocol: proto})
+		}
+
+		cd.Environment = []*spinup.NameValue{}
+		for _, k := range sortedMapKeys(c.Env) {
+			cd.Environment = append(cd.Environment, &spinup.NameValue{Name: k, Value: c.Env[k]})
+		}
+
+		cd.Secrets = []*spinup.NameValueFrom{}
+		for _, k := range sortedMapKeys(c.Secrets) {
+			valueFrom := c.Secrets[k]
+			if arn := secretArnByName(secrets, valueFrom); arn != "" {
+				valueFrom = arn
+				if secretReferencesArn(currentSecrets[k], arn) {
+					valueFrom = currentSecrets[k]
+				}
+			} else if !strings.HasPrefix(valueFrom, "arn:") {
+				return nil, fmt.Errorf("secret %s for %s in %s not found in the space", valueFrom, k, c.Name)
+			}
+			cd.Secrets = append(cd.Secrets, &spinup.NameValueFrom{Name: k, ValueFrom: valueFrom})
+		}
+
+		cd.HealthCheck = nil
+		if hc := c.HealthCheck; hc != nil {
+			cd.HealthCheck = &spinup.ContainerHealthCheck{
+				Command:     hc.Command,
+				Interval:    hc.Interval,
+				Retries:     hc.Retries,
+				StartPeriod: hc.StartPeriod,
+				Timeout:     hc.Timeout,
+			}
+		}
+
+		cd.MountPoints = []*spinup.ContainerMountPoint{}
+		for _, m := range c.MountPoints {
+			cd.MountPoints = append(cd.MountPoints, &spinup.ContainerMountPoint{
+				ContainerPath: m.ContainerPath,
+				ReadOnly:      m.ReadOnly,
+				SourceVolume:  m.SourceVolume,
+			})
+		}
+
+		cd.Ulimits = cd.Ulimits[:0:0]
+		for _, u := range c.Ulimits {
+			cd.Ulimits = append(cd.Ulimits, struct {
+				HardLimit int64
+				Name      string
+				SoftLimit int64
+			}{u.HardLimit, u.Name, u.SoftLimit})
+		}
+
+		cd.DependsOn = cd.DependsOn[:0:0]
+		for _, d := range c.DependsOn {
+			cd.DependsOn = append(cd.DependsOn, struct {
+				Condition     string
+				ContainerName string
+			}{d.Condition, d.ContainerName})
+		}
+
+		defs = append(defs, cd)
+	}
+
+	return defs, nil
+}
+
+// specVolumes returns the volumes in a spec, keeping the host configuration of current volumes
+func specVolumes(s *manifest.ServiceSpec, current []*spinup.ContainerVolume) []*spinup.ContainerVolume {
+	existing := map[string]*spinup.ContainerVolume{}
+	for _, v := range current {
+		existing[v.Name] = v
+	}
+
+	volumes := make([]*spinup.ContainerVolume, 0, len(s.Volumes))
+	for _, vs := range s.Volumes {
+		v := &spinup.ContainerVolume{Name: vs.Name}
+		if e, ok := existing[vs.Name]; ok {
+			v.Host = e.Host
+		}
+
+		if efs := vs.EFS; efs != nil {
+			v.EfsVolumeConfiguration = &spinup.ContainerEfsVolumeConfiguration{
+				FileSystemId:          efs.FileSystemID,
+				RootDirectory:         efs.RootDirectory,
+				TransitEncryption:     efs.TransitEncryption,
+				TransitEncryptionPort: efs.TransitEncryptionPort,
+			}
+			v.EfsVolumeConfiguration.AuthorizationConfig.AccessPointId = efs.AccessPointID
+			v.EfsVolumeConfiguration.AuthorizationConfig.Iam = efs.IAM
+		}
+
+		volumes = append(volumes, v)
+	}
+
+	return volumes
+}
+
+// putServiceSpec updates the container service with the spec, which registers a new task definition and
+// deploys it
+func putServiceSpec(ctx context.Context, params map[string]string, resource *spinup.Resource, info *spinup.ContainerService, s *manifest.ServiceSpec, secrets []*spinup.Secret) (interface{}, error) {
+	defs, err := specContainerDefinitions(s, info.TaskDefinition.ContainerDefinitions, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := make([]*spinup.ContainerCapacityProviderInput, 0, len(s.CapacityProviders))
+	for _, cp := range s.CapacityProviders {
+		strategy = append(strategy, &spinup.ContainerCapacityProviderInput{
+			Base:             cp.Base,
+			CapacityProvider: cp.Name,
+			Weight:           cp.Weight,
+		})
+	}
+
+	input, err := json.Marshal(spinup.ContainerServiceInput{
+		ForceRedeploy: true,
+		Size:          resource.SizeID,
+		Service: &spinup.ContainerServiceDefinitionInput{
+			CapacityProviderStrategy: strategy,
+			ContainerDefinitions:     defs,
+			DesiredCount:             s.DesiredCount,
+			PlatformVersion:          "LATEST",
+			Volumes:                  specVolumes(s, info.TaskDefinition.Volumes),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("putting input: %s", string(input))
+
+	updatedInfo := &spinup.ContainerService{}
+	if err := SpinupClient.PutResourceCtx(ctx, params, input, updatedInfo); err != nil {
+		return nil, err
+	}
+
+	return updatedContainer(ctx, params, resource, updatedInfo)
+}
+
+// secretArnByName returns the ARN of the secret with the name, or an empty string if it isn't in the list
+func secretArnByName(secrets []*spinup.Secret, name string) string {
+	for _, s := range secrets {
+		if s.Name == name {
+			return s.ARN
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+// exit codes returned by the cli, so scripts can distinguish between failures
+const (
+	exitCodeError        = 1
+	exitCodeDrift        = 2
+	exitCodeUnauthorized = 3
+	exitCodeForbidden    = 4
+	exitCodeNotFound     = 5
+	exitCodeAPIError     = 6
+	exitCodeInterrupted  = 130
+)
+
+// commandExitError is returned when a remote command exits non-zero, the cli exits with the same code
+type commandExitError struct {
+	code int
+}
+
+func (e *commandExitError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.code)
+}
+
+// tokenError is returned when the configured token is expired or can't be used
+type tokenError struct {
+	err error
+}
+
+func (e *tokenError) Error() string {
+	return e.err.Error()
+}
+
+func (e *tokenError) Unwrap() error {
+	return e.err
+}
+
+// errorExit maps an error returned from a command to a human readable message and an exit code.  The
+// message is empty when there's nothing to report, like a remote command that exited non-zero.
+func errorExit(err error) (string, int) {
+	if errors.Is(err, context.Canceled) {
+		return "interrupted, cancelled in-flight requests", exitCodeInterrupted
+	}
+
+	var exit *commandExitError
+	if errors.As(err, &exit) {
+		return "", exit.code
+	}
+
+	var drift *driftError
+	if errors.As(err, &drift) {
+		return drift.Error(), exitCodeDrift
+	}
+
+	var tokenErr *tokenError
+	if errors.As(err, &tokenErr) {
+		return fmt.Sprintf("%s, run `spinup configure` to set a new token", tokenErr), exitCodeUnauthorized
+	}
+
+	var apiErr *spinup.APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error(), exitCodeError
+	}
+
+	detail := apiErr.Message
+	if detail == "" {
+		detail = apiErr.Status
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Sprintf("token expired or invalid (%s), run `spinup configure` to set a new token", detail), exitCodeUnauthorized
+	case http.StatusForbidden:
+		return fmt.Sprintf("access denied (%s), check that you are a member of the space", detail), exitCodeForbidden
+	case http.StatusNotFound:
+		return fmt.Sprintf("not found (%s), check the space and resource names", detail), exitCodeNotFound
+	}
+
+	return fmt.Sprintf("spinup api error: %s", apiErr), exitCodeAPIError
+}
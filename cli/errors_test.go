@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+func TestErrorExit(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+		msg  string
+	}{
+		{errors.New("boom"), exitCodeError, "boom"},
+		{fmt.Errorf("failed: %w", context.Canceled), exitCodeInterrupted, "interrupted"},
+		{&commandExitError{code: 42}, 42, ""},
+		{fmt.Errorf("failed to create client: %w", &tokenError{errors.New("token is expired")}), exitCodeUnauthorized, "token is expired, run `spinup configure`"},
+		{&spinup.APIError{StatusCode: http.StatusUnauthorized, Message: "bad token"}, exitCodeUnauthorized, "run `spinup configure`"},
+		{&spinup.APIError{StatusCode: http.StatusForbidden}, exitCodeForbidden, "access denied"},
+		{&spinup.APIError{StatusCode: http.StatusNotFound}, exitCodeNotFound, "not found"},
+		{&spinup.APIError{StatusCode: http.StatusBadGateway}, exitCodeAPIError, "spinup api error"},
+	}
+
+	for _, test := range tests {
+		msg, code := errorExit(test.err)
+		if code != test.code {
+			t.Errorf("expected exit code %d for %s, got %d", test.code, test.err, code)
+		}
+
+		if test.msg == "" && msg != "" || !strings.Contains(msg, test.msg) {
+			t.Errorf("expected message containing %q for %s, got %q", test.msg, test.err, msg)
+		}
+	}
+}
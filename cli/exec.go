@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/session"
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	execTask        string
+	execContainer   string
+	execInteractive bool
+)
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.AddCommand(execContainerCmd)
+	execContainerCmd.Flags().StringVar(&execTask, "task", "", "The task to run the command in (default is the first running task)")
+	execContainerCmd.Flags().StringVar(&execContainer, "container", "", "The container to run the command in, required when the task has more than one")
+	execContainerCmd.Flags().BoolVarP(&execInteractive, "interactive", "i", false, "Attach the terminal to the command (default is true when stdin and stdout are terminals)")
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [type] [space]/[resource] -- [command]",
+	Short: "Run a command in a resource in a space",
+}
+
+var execContainerCmd = &cobra.Command{
+	Use:   "container [space]/[resource] -- [command]",
+	Short: "Run a command in a container of a container service task",
+	Long: `Run a command in a container of a running container service task, /bin/sh by default.
+
+When stdin and stdout are terminals the session is interactive: the terminal is attached to the command
+in raw mode and resizes are sent to it.  Otherwise the command runs once, its output is written to stdout
+and stderr and spinup exits with the exit code of the command.  Pass --interactive=false to run a one-shot
+command from a terminal.`,
+	Example: `  spinup exec container my-space/my-service
+  spinup exec container my-space/my-service --task 0f3c2a9b8e1d4c7f --container web -- /bin/bash
+  spinup exec container my-space/my-service --interactive=false -- ls -la /app`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		command := []string{"/bin/sh"}
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			if len(args) > dash {
+				command = args[dash:]
+			}
+			args = args[:dash]
+		}
+
+		if len(args) > 1 {
+			return errors.New("the command to run must come after --")
+		}
+
+		params, err := parseResourceParams(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		interactive := term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+		if cmd.Flags().Changed("interactive") {
+			interactive = execInteractive
+		}
+
+		task, container, err := execTarget(ctx, params, execTask, execContainer)
+		if err != nil {
+			return err
+		}
+
+		input, err := json.Marshal(&spinup.ContainerExecInput{
+			Container:   container,
+			Command:     shellJoin(command),
+			Interactive: interactive,
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Infof("starting exec session in %s/%s task %s container %s", params["space"], params["name"], task, container)
+
+		out := &spinup.ContainerExecSession{}
+		if err := SpinupClient.PostResourceCtx(ctx, map[string]string{"space": params["space"], "name": params["name"], "taskId": task}, input, out); err != nil {
+			return err
+		}
+
+		conn, err := session.Dial(ctx, &session.Session{ID: out.SessionId, StreamURL: out.StreamUrl, Token: out.TokenValue})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		var stdin io.Reader
+		if interactive {
+			stdin = os.Stdin
+
+			if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+				state, err := term.MakeRaw(fd)
+				if err != nil {
+					return fmt.Errorf("failed setting the terminal to raw mode: %s", err)
+				}
+				defer term.Restore(fd, state)
+			}
+
+			if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+				resize := func(width, height int) {
+					if err := conn.Resize(session.Size{Cols: width, Rows: height}); err != nil {
+						log.Debugf("failed resizing the terminal: %s", err)
+					}
+				}
+
+				if width, height, err := term.GetSize(fd); err == nil {
+					resize(width, height)
+				}
+
+				ctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+				watchTerminalSize(ctx, fd, resize)
+			}
+		}
+
+		code, err := conn.Run(ctx, stdin, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		if code != 0 {
+			return &commandExitError{code: code}
+		}
+
+		return nil
+	},
+}
+
+// execTarget returns the task and container to run a command in, checking that the task is running and
+// has the container.  The first running task is used when one isn't passed, and the only container of the
+// task when a container isn't passed.
+func execTarget(ctx context.Context, params map[string]string, task, container string) (string, string, error) {
+	name := params["space"] + "/" + params["name"]
+
+	if task == "" {
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return "", "", err
+		}
+
+		if len(info.Tasks) == 0 {
+			return "", "", fmt.Errorf("container service %s has no running tasks", name)
+		}
+
+		task = taskID(info.Tasks[0])
+		if len(info.Tasks) > 1 {
+			fmt.Fprintf(os.Stderr, "using task %s, 1 of %d running tasks (pass --task to choose another)\n", task, len(info.Tasks))
+		}
+	}
+
+	out := &spinup.ContainerTask{}
+	if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"space": params["space"], "name": params["name"], "taskId": task}, out); err != nil {
+		return "", "", err
+	}
+
+	if len(out.Tasks) == 0 {
+		return "", "", fmt.Errorf("task %s not found in container service %s", task, name)
+	}
+
+	t := out.Tasks[0]
+	if t.LastStatus != "RUNNING" {
+		return "", "", fmt.Errorf("task %s is %s, commands can only run in running tasks", task, strings.ToLower(t.LastStatus))
+	}
+
+	names := make([]string, 0, len(t.Containers))
+	for _, c := range t.Containers {
+		names = append(names, c.Name)
+	}
+
+	switch {
+	case container != "":
+		for _, n := range names {
+			if n == container {
+				return task, container, nil
+			}
+		}
+		return "", "", fmt.Errorf("container %s not found in task %s, expected one of %s", container, task, strings.Join(names, ", "))
+	case len(names) == 1:
+		return task, names[0], nil
+	case len(names) == 0:
+		return "", "", fmt.Errorf("task %s has no containers", task)
+	}
+
+	return "", "", fmt.Errorf("task %s has more than one container, pass --container with one of %s", task, strings.Join(names, ", "))
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellJoin joins the arguments of a command into a single command line, quoting the arguments that
+// need it
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if shellSafe.MatchString(a) {
+			quoted[i] = a
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'"'"'`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
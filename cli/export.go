@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportTargetSpace    string
+	exportSecretsFromEnv bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.AddCommand(exportSpaceCmd)
+	exportSpaceCmd.Flags().StringVar(&exportTargetSpace, "target-space", "", "The space to write in the manifest (default is the exported space)")
+	exportSpaceCmd.Flags().BoolVar(&exportSecretsFromEnv, "secrets-from-env", false, "Read each secret value from an environment variable named after the secret when the manifest is applied")
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export resources as manifests",
+}
+
+var exportSpaceCmd = &cobra.Command{
+	Use:   "space [space]",
+	Short: "Export the resources in a space as a manifest",
+	Long: `Export the secrets, container services, storage and databases in a space as a manifest that can be
+reviewed, kept in version control and applied to another space with spinup apply.
+
+Secret values are never exported.  Pass --secrets-from-env to have apply read each value from an
+environment variable named after the secret, eg. my-app/db-password is read from MY_APP_DB_PASSWORD.
+
+The manifest is written as YAML, or in the format passed with --output.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		space, err := singleSpace(args)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("exporting space %s", space)
+
+		resources, err := SpinupClient.ResourcesCtx(ctx, space)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range resources {
+			if r.Status == "deleted" || isContainerService(r) || isStorageBucket(r) || isDatabase(r) {
+				continue
+			}
+			log.Warnf("skipping %s, it can't be described in a manifest", r.Name)
+		}
+
+		m, err := liveManifest(ctx, space, nil)
+		if err != nil {
+			return err
+		}
+
+		if exportTargetSpace != "" {
+			m.Space = exportTargetSpace
+		}
+
+		if exportSecretsFromEnv {
+			for _, s := range m.Secrets {
+				s.FromEnv = secretEnvName(s.Name)
+			}
+		}
+
+		secrets := map[string]bool{}
+		for _, s := range m.Secrets {
+			secrets[s.Name] = true
+		}
+
+		for _, c := range m.Containers {
+			for _, d := range c.Definitions {
+				for env, name := range d.Secrets {
+					if !secrets[name] {
+						log.Warnf("container %s definition %s references %s for %s, which isn't a secret in the space", c.Name, d.Name, name, env)
+					}
+				}
+			}
+		}
+
+		if err := m.Validate(); err != nil {
+			log.Warnf("the exported manifest will need changes before it can be applied: %s", err)
+		}
+
+		if cmd.Flags().Changed("output") {
+			return formatOutput(m)
+		}
+
+		return m.Encode(os.Stdout)
+	},
+}
+
+var nonEnvChars = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// secretEnvName returns the environment variable name for a secret, like MY_APP_DB_PASSWORD for
+// my-app/db-password
+func secretEnvName(name string) string {
+	return strings.Trim(nonEnvChars.ReplaceAllString(strings.ToUpper(name), "_"), "_")
+}
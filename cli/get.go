@@ -2,11 +2,9 @@ package cli
 
 import (
 	"errors"
-	"strings"
 	"time"
 
 	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -25,35 +23,16 @@ var (
 func getCmdPreRun(cmd *cobra.Command, args []string) error {
 	defer timeTrack(time.Now(), "getCmdPreRun()")
 
-	if len(args) == 0 {
-		return errors.New("space/resource required")
-	}
-
-	parts := strings.Split(args[0], "/")
-	switch len(parts) {
-	case 2:
-		getParams["space"] = parts[0]
-		getParams["name"] = parts[1]
-	case 1:
-		log.Debug("space not found in input, finding resource in default spaces")
+	ctx := cmd.Context()
 
-		if len(spinupSpaces) == 0 {
-			return errors.New("space not passed and no default spaces found")
-		}
-
-		space, err := findResourceInSpaces(parts[0], spinupSpaces)
-		if err != nil {
-			return err
-		}
-
-		getParams["space"] = space
-		getParams["name"] = parts[0]
-	default:
-		return errors.New("space/resource required")
+	params, err := parseResourceParams(ctx, args)
+	if err != nil {
+		return err
 	}
+	getParams = params
 
 	// set the global getResource to the passed resource
-	if err := SpinupClient.GetResource(getParams, getResource); err != nil {
+	if err := SpinupClient.GetResourceCtx(ctx, getParams, getResource); err != nil {
 		return err
 	}
 
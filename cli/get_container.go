@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strings"
 
@@ -27,28 +27,30 @@ var getContainerCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("update container: %+v", args)
 
+		ctx := cmd.Context()
+
 		status := getResource.Status
 		if status != "created" && status != "creating" && status != "deleting" {
 			return ingStatus(getResource)
 		}
 
 		var err error
-		var out []byte
+		var out interface{}
 		switch {
 		case detailedGetCmd:
-			if out, err = containerDetails(getParams, getResource); err != nil {
+			if out, err = containerDetails(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		case containerEventsCmd:
-			if out, err = containerEvents(getParams, getResource); err != nil {
+			if out, err = containerEvents(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		case containerTaskCmd:
-			if out, err = containerTasks(getParams, getResource); err != nil {
+			if out, err = containerTasks(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		default:
-			if out, err = container(getParams, getResource); err != nil {
+			if out, err = container(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		}
@@ -57,29 +59,39 @@ var getContainerCmd = &cobra.Command{
 	},
 }
 
-func container(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.ContainerSize(resource.SizeID.String())
-	if err != nil {
-		return []byte{}, err
-	}
-
+func container(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
 	info := &spinup.ContainerService{}
-	if err = SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
-	return json.MarshalIndent(newResourceSummary(resource, size, info.Status), "", "  ")
+	return containerSummary(ctx, resource, info)
 }
 
-func containerDetails(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.ContainerSize(resource.SizeID.String())
+// containerSummary returns the resource summary for a container service
+func containerSummary(ctx context.Context, resource *spinup.Resource, info *spinup.ContainerService) (interface{}, error) {
+	size, err := SpinupClient.ContainerSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
+	return newResourceSummary(resource, size, info.Status), nil
+}
+
+func containerDetails(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
 	info := &spinup.ContainerService{}
-	if err = SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
+	}
+
+	return containerServiceDetails(ctx, params, resource, info)
+}
+
+// containerServiceDetails returns the detailed output for a container service
+func containerServiceDetails(ctx context.Context, params map[string]string, resource *spinup.Resource, info *spinup.ContainerService) (interface{}, error) {
+	size, err := SpinupClient.ContainerSizeCtx(ctx, resource.SizeID.String())
+	if err != nil {
+		return nil, err
 	}
 
 	log.Debugf("collected container info %+v", info)
@@ -94,9 +106,9 @@ func containerDetails(params map[string]string, resource *spinup.Resource) ([]by
 
 	log.Debugf("container service spot: %t", spot)
 
-	secrets, err := spaceSecrets(params)
+	secrets, err := spaceSecrets(ctx, params)
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	log.Debugf("collected space secrets %+v", secrets)
@@ -121,7 +133,7 @@ func containerDetails(params map[string]string, resource *spinup.Resource) ([]by
 
 		env, err := mapNameValueArray(cdef.Environment)
 		if err != nil {
-			return []byte{}, err
+			return nil, err
 		}
 
 		cSecrets := make(map[string]string)
@@ -129,13 +141,13 @@ func containerDetails(params map[string]string, resource *spinup.Resource) ([]by
 			// map the secrets for the container def
 			cSecrets, err = mapNameValueFromArray(cdef.Secrets)
 			if err != nil {
-				return []byte{}, err
+				return nil, err
 			}
 
 			// if the ARN matches the value of the container secret, override the value with the spinup secret name
 			for _, s := range secrets {
 				for k, v := range cSecrets {
-					if v == s.ARN {
+					if secretReferencesArn(v, s.ARN) {
 						cSecrets[k] = s.Name
 					}
 				}
@@ -212,18 +224,13 @@ func containerDetails(params map[string]string, resource *spinup.Resource) ([]by
 		},
 	}
 
-	j, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return j, nil
+	return output, nil
 }
 
-func containerEvents(params map[string]string, resource *spinup.Resource) ([]byte, error) {
+func containerEvents(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
 	info := &spinup.ContainerService{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
 	log.Debugf("%+v", info)
@@ -248,18 +255,13 @@ func containerEvents(params map[string]string, resource *spinup.Resource) ([]byt
 		Events []*Event `json:"events"`
 	}{events}
 
-	j, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return j, nil
+	return output, nil
 }
 
-func containerTasks(params map[string]string, resource *spinup.Resource) ([]byte, error) {
+func containerTasks(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
 	info := &spinup.ContainerService{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
 	log.Debugf("%+v", info)
@@ -294,13 +296,19 @@ func containerTasks(params map[string]string, resource *spinup.Resource) ([]byte
 		Version          int64        `json:"version"`
 	}
 
-	tasks := make([]*Task, 0, len(info.Tasks))
-	for _, t := range info.Tasks {
-		tid := strings.SplitN(t, "/", 2)
-		params["taskId"] = tid[1]
+	// each task is fetched concurrently into its own slot to preserve the ordering
+	taskLists := make([][]*Task, len(info.Tasks))
+	err := SpinupClient.Parallel(ctx, len(info.Tasks), func(ctx context.Context, i int) error {
+		tid := strings.SplitN(info.Tasks[i], "/", 2)
+		taskParams := map[string]string{
+			"space":  params["space"],
+			"name":   params["name"],
+			"taskId": tid[1],
+		}
+
 		taskOut := &spinup.ContainerTask{}
-		if err := SpinupClient.GetResource(params, taskOut); err != nil {
-			return []byte{}, err
+		if err := SpinupClient.GetResourceCtx(ctx, taskParams, taskOut); err != nil {
+			return err
 		}
 
 		for _, task := range taskOut.Tasks {
@@ -327,7 +335,7 @@ func containerTasks(params map[string]string, resource *spinup.Resource) ([]byte
 				})
 			}
 
-			tasks = append(tasks, &Task{
+			taskLists[i] = append(taskLists[i], &Task{
 				AvailabilityZone: task.AvailabilityZone,
 				CapacityProvider: task.CapacityProviderName,
 				CPU:              task.Cpu,
@@ -348,6 +356,16 @@ func containerTasks(params map[string]string, resource *spinup.Resource) ([]byte
 				Version:          task.Version,
 			})
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, 0, len(info.Tasks))
+	for _, l := range taskLists {
+		tasks = append(tasks, l...)
 	}
 
 	output := struct {
@@ -356,10 +374,5 @@ func containerTasks(params map[string]string, resource *spinup.Resource) ([]byte
 		tasks,
 	}
 
-	j, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return j, nil
+	return output, nil
 }
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -22,20 +22,22 @@ var getDatabaseCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("get database: %+v", args)
 
+		ctx := cmd.Context()
+
 		status := getResource.Status
 		if status != "created" && status != "creating" && status != "deleting" {
 			return ingStatus(getResource)
 		}
 
 		var err error
-		var out []byte
+		var out interface{}
 		switch {
 		case detailedGetCmd:
-			if out, err = databaseDetails(getParams, getResource); err != nil {
+			if out, err = databaseDetails(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		default:
-			if out, err = database(getParams, getResource); err != nil {
+			if out, err = database(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		}
@@ -44,17 +46,23 @@ var getDatabaseCmd = &cobra.Command{
 	},
 }
 
-func database(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.DatabaseSize(resource.SizeID.String())
+func database(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	size, err := SpinupClient.DatabaseSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	info := &spinup.DatabaseInfo{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
+	return newResourceSummary(resource, size, databaseStatus(resource, info)), nil
+}
+
+// databaseStatus returns the status of the database cluster or instance, a serverless cluster scaled
+// to zero is paused
+func databaseStatus(resource *spinup.Resource, info *spinup.DatabaseInfo) string {
 	status := resource.Status
 	if len(info.DBClusters) > 0 {
 		status = info.DBClusters[0].Status
@@ -65,18 +73,18 @@ func database(params map[string]string, resource *spinup.Resource) ([]byte, erro
 		status = info.DBInstances[0].DBInstanceStatus
 	}
 
-	return json.MarshalIndent(newResourceSummary(resource, size, status), "", "  ")
+	return status
 }
 
-func databaseDetails(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.DatabaseSize(resource.SizeID.String())
+func databaseDetails(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	size, err := SpinupClient.DatabaseSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	info := &spinup.DatabaseInfo{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
 	// I think we only ever have one cluster and instance (even in multi-az deployments)
@@ -234,10 +242,5 @@ func databaseDetails(params map[string]string, resource *spinup.Resource) ([]byt
 		details,
 	}
 
-	j, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return j, nil
+	return output, nil
 }
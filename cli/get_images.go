@@ -17,6 +17,8 @@ var getImagesCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("get images: %+v", args)
 
+		ctx := cmd.Context()
+
 		type ImageOutput struct {
 			*spinup.Image
 			OfferingName string `json:"offering_name"`
@@ -24,7 +26,7 @@ var getImagesCmd = &cobra.Command{
 		}
 
 		images := spinup.Images{}
-		if err := SpinupClient.GetResource(getParams, &images); err != nil {
+		if err := SpinupClient.GetResourceCtx(ctx, getParams, &images); err != nil {
 			return err
 		}
 
@@ -1,6 +1,9 @@
 package cli
 
 import (
+	"context"
+	"strings"
+
 	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,6 +20,8 @@ var getSecretsCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("get secrets: %+v", args)
 
+		ctx := cmd.Context()
+
 		type SecretOutput struct {
 			Name        string `json:"name"`
 			Description string `json:"description"`
@@ -24,7 +29,7 @@ var getSecretsCmd = &cobra.Command{
 		}
 
 		out := []*SecretOutput{}
-		secrets, err := spaceSecrets(getParams)
+		secrets, err := spaceSecrets(ctx, getParams)
 		if err != nil {
 			return err
 		}
@@ -40,27 +45,103 @@ var getSecretsCmd = &cobra.Command{
 	},
 }
 
-func spaceSecrets(params map[string]string) ([]*spinup.Secret, error) {
+// SecretDetails is the output for a single secret
+type SecretDetails struct {
+	ARN          string `json:"arn"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	Space        string `json:"space"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func newSecretDetails(space string, secret *spinup.Secret) *SecretDetails {
+	return &SecretDetails{
+		ARN:          secret.ARN,
+		Name:         secret.Name,
+		Description:  secret.Description,
+		Space:        space,
+		LastModified: secret.LastModifiedDate,
+	}
+}
+
+// secretReferencesArn returns true if the valueFrom of a container secret references the secret ARN, either
+// directly or with a json key/version suffix (arn:...:secret:name-AbCdEf:key::)
+func secretReferencesArn(valueFrom, arn string) bool {
+	return arn != "" && (valueFrom == arn || strings.HasPrefix(valueFrom, arn+":"))
+}
+
+// secretConsumers returns the names of the container services in a space with a container definition
+// that references the secret ARN
+func secretConsumers(ctx context.Context, space, arn string) ([]string, error) {
+	resources, err := SpinupClient.ResourcesCtx(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := []*spinup.Resource{}
+	for _, r := range resources {
+		if isContainerService(r) && r.Status == "created" {
+			containers = append(containers, r)
+		}
+	}
+
+	uses := make([]bool, len(containers))
+	if err := SpinupClient.Parallel(ctx, len(containers), func(ctx context.Context, i int) error {
+		r := containers[i]
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"space": space, "name": r.Name}, info); err != nil {
+			return err
+		}
+
+		for _, cdef := range info.TaskDefinition.ContainerDefinitions {
+			for _, s := range cdef.Secrets {
+				if secretReferencesArn(s.ValueFrom, arn) {
+					log.Debugf("container %s in service %s references secret %s", cdef.Name, r.Name, arn)
+					uses[i] = true
+					return nil
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	consumers := []string{}
+	for i, r := range containers {
+		if uses[i] {
+			consumers = append(consumers, r.Name)
+		}
+	}
+
+	return consumers, nil
+}
+
+func spaceSecrets(ctx context.Context, params map[string]string) ([]*spinup.Secret, error) {
 	// collect a list of secrets from the space
 	secrets := &spinup.Secrets{}
-	if err := SpinupClient.GetResource(params, secrets); err != nil {
+	if err := SpinupClient.GetResourceCtx(ctx, params, secrets); err != nil {
 		return nil, err
 	}
 
 	log.Debugf("got list of secrets in space %+v", secrets)
 
 	// get details about each secret (necessary to map the ARN to the name)
-	spaceSecrets := []*spinup.Secret{}
-	for _, s := range *secrets {
+	names := *secrets
+	spaceSecrets := make([]*spinup.Secret, len(names))
+	if err := SpinupClient.Parallel(ctx, len(names), func(ctx context.Context, i int) error {
 		secret := &spinup.Secret{}
-		if err := SpinupClient.GetResource(
+		if err := SpinupClient.GetResourceCtx(ctx,
 			map[string]string{
 				"space":      params["space"],
-				"secretname": string(s),
+				"secretname": string(names[i]),
 			}, secret); err != nil {
-			return nil, err
+			return err
 		}
-		spaceSecrets = append(spaceSecrets, secret)
+		spaceSecrets[i] = secret
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	return spaceSecrets, nil
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
 
 	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
 	log "github.com/sirupsen/logrus"
@@ -19,20 +19,22 @@ var getServerCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("get server: %+v", args)
 
+		ctx := cmd.Context()
+
 		status := getResource.Status
 		if status != "created" && status != "creating" && status != "deleting" {
 			return ingStatus(getResource)
 		}
 
 		var err error
-		var out []byte
+		var out interface{}
 		switch {
 		case detailedGetCmd:
-			if out, err = serverDetails(getParams, getResource); err != nil {
+			if out, err = serverDetails(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		default:
-			if out, err = server(getParams, getResource); err != nil {
+			if out, err = server(ctx, getParams, getResource); err != nil {
 				return err
 			}
 		}
@@ -41,49 +43,49 @@ var getServerCmd = &cobra.Command{
 	},
 }
 
-func server(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.ServerSize(resource.SizeID.String())
+func server(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	size, err := SpinupClient.ServerSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	log.Debugf("collected server size: %+v", size)
 
 	info := &spinup.ServerInfo{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
 	log.Debugf("collected server info: %+v", info)
 
-	return json.MarshalIndent(newResourceSummary(resource, size, info.State), "", "  ")
+	return newResourceSummary(resource, size, info.State), nil
 }
 
-func serverDetails(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.ServerSize(resource.SizeID.String())
+func serverDetails(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	size, err := SpinupClient.ServerSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	log.Debugf("collected server size: %+v", size)
 
 	info := &spinup.ServerInfo{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
 	}
 
 	log.Debugf("collected server info: %+v", info)
 
 	disks := spinup.Disks{}
-	if err := SpinupClient.GetResource(params, &disks); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, &disks); err != nil {
+		return nil, err
 	}
 
 	log.Debugf("collected server disks: %+v", disks)
 
 	snapshots := spinup.Snapshots{}
-	if err := SpinupClient.GetResource(params, &snapshots); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, &snapshots); err != nil {
+		return nil, err
 	}
 
 	log.Debugf("collected server snapshots: %+v", snapshots)
@@ -153,10 +155,5 @@ func serverDetails(params map[string]string, resource *spinup.Resource) ([]byte,
 		},
 	}
 
-	j, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return j, nil
+	return output, nil
 }
@@ -28,6 +28,7 @@ var getSpaceCmd = &cobra.Command{
 	Use:   "space",
 	Short: "Get details about your space(s)",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		spaces, err := parseSpaceInput(args)
 		if err != nil {
 			return err
@@ -39,13 +40,13 @@ var getSpaceCmd = &cobra.Command{
 		for _, s := range spaces {
 			params := map[string]string{"id": s}
 			space := &spinup.GetSpace{}
-			if err := SpinupClient.GetResource(params, space); err != nil {
+			if err := SpinupClient.GetResourceCtx(ctx, params, space); err != nil {
 				return err
 			}
 
 			if includeCost {
 				cost := &spinup.SpaceCosts{}
-				if err := SpinupClient.GetResource(params, cost); err != nil {
+				if err := SpinupClient.GetResourceCtx(ctx, params, cost); err != nil {
 					return err
 				}
 				space.Space.Cost = cost
@@ -53,7 +54,7 @@ var getSpaceCmd = &cobra.Command{
 
 			var resourcesOut []*spinup.Resource
 			if showResources {
-				resources, err := SpinupClient.Resources(s)
+				resources, err := SpinupClient.ResourcesCtx(ctx, s)
 				if err != nil {
 					return err
 				}
@@ -22,15 +22,17 @@ var getSpacesCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Debug("getting all spaces")
 
+		ctx := cmd.Context()
+
 		spaces := spinup.Spaces{}
-		if err := SpinupClient.GetResource(map[string]string{}, &spaces); err != nil {
+		if err := SpinupClient.GetResourceCtx(ctx, map[string]string{}, &spaces); err != nil {
 			return err
 		}
 
 		if includeCost {
 			for _, s := range spaces.Spaces {
 				spaceCost := &spinup.SpaceCosts{}
-				if err := SpinupClient.GetResource(map[string]string{"id": s.Id.String()}, spaceCost); err != nil {
+				if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"id": s.Id.String()}, spaceCost); err != nil {
 					return err
 				}
 
@@ -1,7 +1,7 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 
 	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
@@ -20,18 +20,20 @@ var getStorageCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("get storage: %+v", args)
 
+		ctx := cmd.Context()
+
 		status := getResource.Status
 		if status != "created" && status != "creating" && status != "deleting" {
 			return ingStatus(getResource)
 		}
 
-		var out []byte
+		var out interface{}
 		var err error
 		switch {
 		case detailedGetCmd:
 			switch getResource.Type.Flavor {
 			case "s3", "s3bucket":
-				out, err = s3StorageDetails(getParams, getResource)
+				out, err = s3StorageDetails(ctx, getParams, getResource)
 				if err != nil {
 					return err
 				}
@@ -44,7 +46,7 @@ var getStorageCmd = &cobra.Command{
 		default:
 			switch getResource.Type.Flavor {
 			case "s3", "s3bucket":
-				out, err = s3Storage(getParams, getResource)
+				out, err = s3Storage(ctx, getParams, getResource)
 				if err != nil {
 					return err
 				}
@@ -60,15 +62,15 @@ var getStorageCmd = &cobra.Command{
 	},
 }
 
-func s3Storage(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.S3StorageSize(resource.SizeID.String())
+func s3Storage(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	size, err := SpinupClient.S3StorageSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	info := &spinup.S3StorageInfo{}
-	if err := SpinupClient.GetResource(params, resource); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, resource); err != nil {
+		return nil, err
 	}
 
 	state := "populated"
@@ -76,23 +78,23 @@ func s3Storage(params map[string]string, resource *spinup.Resource) ([]byte, err
 		state = "empty"
 	}
 
-	return json.MarshalIndent(newResourceSummary(resource, size, state), "", "  ")
+	return newResourceSummary(resource, size, state), nil
 }
 
-func s3StorageDetails(params map[string]string, resource *spinup.Resource) ([]byte, error) {
-	size, err := SpinupClient.S3StorageSize(resource.SizeID.String())
+func s3StorageDetails(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	size, err := SpinupClient.S3StorageSizeCtx(ctx, resource.SizeID.String())
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	info := &spinup.S3StorageInfo{}
-	if err := SpinupClient.GetResource(params, resource); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, resource); err != nil {
+		return nil, err
 	}
 
 	users := spinup.S3StorageUsers{}
-	if err := SpinupClient.GetResource(params, &users); err != nil {
-		return []byte{}, err
+	if err := SpinupClient.GetResourceCtx(ctx, params, &users); err != nil {
+		return nil, err
 	}
 
 	state := "populated"
@@ -107,12 +109,16 @@ func s3StorageDetails(params map[string]string, resource *spinup.Resource) ([]by
 		Keys      []string `json:"key_id"`
 	}
 
-	userList := []*User{}
-	for _, u := range users {
-		params["username"] = u.Username
+	userList := make([]*User, len(users))
+	if err := SpinupClient.Parallel(ctx, len(users), func(ctx context.Context, i int) error {
+		u := users[i]
 		user := spinup.S3StorageUser{}
-		if err = SpinupClient.GetResource(params, &user); err != nil {
-			return []byte{}, err
+		if err := SpinupClient.GetResourceCtx(ctx, map[string]string{
+			"space":    params["space"],
+			"name":     params["name"],
+			"username": u.Username,
+		}, &user); err != nil {
+			return err
 		}
 
 		keys := make([]string, 0, len(user.AccessKeys))
@@ -120,12 +126,15 @@ func s3StorageDetails(params map[string]string, resource *spinup.Resource) ([]by
 			keys = append(keys, k.AccessKeyId)
 		}
 
-		userList = append(userList, &User{
+		userList[i] = &User{
 			Username:  u.Username,
 			CreatedAt: u.CreatedAt,
 			LastUsed:  u.LastUsed,
 			Keys:      keys,
-		})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
 	output := struct {
@@ -138,10 +147,5 @@ func s3StorageDetails(params map[string]string, resource *spinup.Resource) ([]by
 		userList,
 	}
 
-	j, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return []byte{}, err
-	}
-
-	return j, nil
+	return output, nil
 }
@@ -1,14 +1,11 @@
 package cli
 
 import (
-	"bufio"
-	"encoding/base64"
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
-	"os"
 	"strings"
 	"time"
 
@@ -36,12 +33,24 @@ func initClient() error {
 	defer timeTrack(time.Now(), "initClient()")
 
 	if err := validateToken(spinupToken); err != nil {
+		return &tokenError{err}
+	}
+
+	s, err := newClient()
+	if err != nil {
 		return err
 	}
 
+	SpinupClient = s
+
+	return nil
+}
+
+// newClient returns a spinup client for the configured url and token, without validating the token
+func newClient() (*spinup.Client, error) {
 	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	httpClient := &http.Client{
@@ -51,12 +60,21 @@ func initClient() error {
 
 	s, err := spinup.New(spinupURL, httpClient, spinupToken)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	SpinupClient = s
+	s.Retry = &spinup.RetryPolicy{
+		MaxRetries: retries,
+		MinWait:    retryWaitMin,
+		MaxWait:    retryWaitMax,
+	}
 
-	return nil
+	if parallelism < 1 {
+		return nil, fmt.Errorf("parallelism must be at least 1, got %d", parallelism)
+	}
+	s.Concurrency = parallelism
+
+	return s, nil
 }
 
 // parseSpaceInput takes a list of space arguments and parses them into space ids, converting from
@@ -76,14 +94,66 @@ func parseSpaceInput(args []string) ([]string, error) {
 	return spaceNames, nil
 }
 
+// parseSpaceAndName parses a space/name argument for objects that aren't spinup resources (like secrets
+// and images), using the default space when exactly one is configured.  Secret names may contain slashes,
+// so everything after the first slash is the name.
+func parseSpaceAndName(arg string) (string, string, error) {
+	parts := strings.SplitN(arg, "/", 2)
+	switch len(parts) {
+	case 2:
+		return parts[0], parts[1], nil
+	case 1:
+		if len(spinupSpaces) != 1 {
+			return "", "", errors.New("space not passed and there isn't exactly one default space")
+		}
+		return spinupSpaces[0], parts[0], nil
+	}
+
+	return "", "", errors.New("space/name required")
+}
+
+// parseResourceParams parses a space/resource argument into the params for the resource, finding the
+// resource in the default spaces when the space isn't passed
+func parseResourceParams(ctx context.Context, args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, errors.New("space/resource required")
+	}
+
+	parts := strings.Split(args[0], "/")
+	switch len(parts) {
+	case 2:
+		return map[string]string{"space": parts[0], "name": parts[1]}, nil
+	case 1:
+		log.Debug("space not found in input, finding resource in default spaces")
+
+		if len(spinupSpaces) == 0 {
+			return nil, errors.New("space not passed and no default spaces found")
+		}
+
+		space, err := findResourceInSpaces(ctx, parts[0], spinupSpaces)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]string{"space": space, "name": parts[0]}, nil
+	}
+
+	return nil, errors.New("space/resource required")
+}
+
+// isContainerService returns true if the resource is a container service
+func isContainerService(r *spinup.Resource) bool {
+	return r.IsA == "container" || (r.Type != nil && r.Type.Type == "container")
+}
+
 // findResourceInSpaces returns the space for the given resource, searching the spaces passed in the space list
-func findResourceInSpaces(name string, spaces []string) (string, error) {
+func findResourceInSpaces(ctx context.Context, name string, spaces []string) (string, error) {
 	log.Debugf("finding %s in spaces %+v", name, spaces)
 
 	for _, s := range spinupSpaces {
 		log.Debugf("listing resources for space %s", s)
 
-		resources, err := SpinupClient.Resources(s)
+		resources, err := SpinupClient.ResourcesCtx(ctx, s)
 		if err != nil {
 			return "", err
 		}
@@ -100,7 +170,7 @@ func findResourceInSpaces(name string, spaces []string) (string, error) {
 
 // ingStatus prints the basic information about a resource and returns.
 func ingStatus(resource *spinup.Resource) error {
-	out, err := json.MarshalIndent(struct {
+	return formatOutput(struct {
 		ID      string `json:"id"`
 		Name    string `json:"name"`
 		Status  string `json:"status"`
@@ -110,16 +180,7 @@ func ingStatus(resource *spinup.Resource) error {
 		Name:    resource.Name,
 		Status:  resource.Status,
 		SpaceID: resource.SpaceID.String(),
-	}, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	f := bufio.NewWriter(os.Stdout)
-	defer f.Flush()
-	f.Write(out)
-
-	return nil
+	})
 }
 
 func newResourceSummary(resource *spinup.Resource, size spinup.Size, state string) *ResourceSummary {
@@ -168,29 +229,7 @@ func mapNameValueFromArray(input []*spinup.NameValueFrom) (map[string]string, er
 	return output, nil
 }
 
-// formatOutput prints the output as json or a string
-func formatOutput(out interface{}) error {
-	var output []byte
-	o, ok := out.([]byte)
-	if ok {
-		output = o
-	} else {
-		var err error
-		output, err = json.MarshalIndent(out, "", "  ")
-		if err != nil {
-			return err
-		}
-	}
-
-	f := bufio.NewWriter(os.Stdout)
-	defer f.Flush()
-	if _, err := f.Write(output); err != nil {
-		return err
-	}
-
-	return nil
-}
-
+// validateToken checks that the token hasn't expired and is already valid, and warns if it expires soon
 func validateToken(tokenString string) error {
 	defer timeTrack(time.Now(), "validateToken()")
 
@@ -201,47 +240,35 @@ func validateToken(tokenString string) error {
 
 	log.Debugf("validating token: %s", tokenString)
 
-	parts := strings.SplitN(tokenString, ".", 3)
-	if l := len(parts); l != 3 {
-		return fmt.Errorf("invalid token, unexpected number of parts (%d)", l)
-	}
-
-	rawPayload, err := base64.RawStdEncoding.DecodeString(parts[1])
+	payload, err := parseToken(tokenString)
 	if err != nil {
-		return fmt.Errorf("invalid token, unable to decode payload: %s", err)
-	}
-
-	log.Debugf("got payload: %s", string(rawPayload))
-
-	var payload map[string]interface{}
-	if err := json.Unmarshal(rawPayload, &payload); err != nil {
-		return fmt.Errorf("invalid token, unable to unmarshal payload: %s", err)
+		return err
 	}
 
 	log.Debugf("unmarshalled payload: %+v", payload)
 
-	exp, ok := payload["exp"].(float64)
+	expirationTime, ok := payload.time("exp")
 	if !ok {
 		return fmt.Errorf("invalid token, unable to parse expiration: %v", payload["exp"])
 	}
 
-	expirationTime := time.Unix(int64(exp), 0)
 	if time.Now().After(expirationTime) {
 		return fmt.Errorf("token is expired (%s)", expirationTime)
 	}
 
-	nbf, ok := payload["nbf"].(float64)
+	notbeforeTime, ok := payload.time("nbf")
 	if !ok {
 		return fmt.Errorf("invalid token, unable to parse notbefore: %v", payload["nbf"])
 	}
 
-	notbeforeTime := time.Unix(int64(nbf), 0)
 	if time.Now().Before(notbeforeTime) {
 		return fmt.Errorf("token is not valid yet (%s)", notbeforeTime)
 	}
 
 	log.Debugf("token is valid (not before: %s, not after: %s", notbeforeTime, expirationTime)
 
+	warnTokenExpiry(payload)
+
 	return nil
 }
 
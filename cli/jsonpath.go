@@ -0,0 +1,423 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed jsonpath output template, a subset of kubectl's.  Expressions are enclosed in
+// braces and support fields (.name), indexes ([0], [-1]), wildcards ([*]), filters comparing a field with
+// a literal ([?(@.status=="created")]), string literals ({"\n"}) and {range ...}...{end} blocks.  Text
+// outside of the braces is printed as-is.
+type jsonPath struct {
+	nodes []jpNode
+}
+
+// jpNode is a piece of a parsed jsonpath template
+type jpNode struct {
+	text     string
+	path     []jpStep
+	isPath   bool
+	children []jpNode
+	isRange  bool
+}
+
+// jpStep is a single step in a path expression, a field, an index or a filter.  A step without any of
+// them is a wildcard.
+type jpStep struct {
+	field  string
+	index  *int
+	filter *jpFilter
+}
+
+// jpFilter compares a path relative to the current element with a string, number or boolean literal
+type jpFilter struct {
+	path    []jpStep
+	equal   bool
+	literal interface{}
+}
+
+// parseJSONPath parses a jsonpath template
+func parseJSONPath(tmpl string) (*jsonPath, error) {
+	nodes, _, err := parseJPNodes(tmpl, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonPath{nodes: nodes}, nil
+}
+
+// parseJPNodes parses the template until the end of the input, or a closing {end} when in a range
+func parseJPNodes(tmpl string, inRange bool) ([]jpNode, string, error) {
+	nodes := []jpNode{}
+	for tmpl != "" {
+		open := strings.Index(tmpl, "{")
+		if open < 0 {
+			nodes = append(nodes, jpNode{text: tmpl})
+			break
+		}
+
+		if open > 0 {
+			nodes = append(nodes, jpNode{text: tmpl[:open]})
+		}
+
+		end := closingJP(tmpl[open:], '{', '}')
+		if end < 0 {
+			return nil, "", errors.New("unclosed {")
+		}
+
+		expr := strings.TrimSpace(tmpl[open+1 : open+end])
+		tmpl = tmpl[open+end+1:]
+
+		switch {
+		case expr == "end":
+			if !inRange {
+				return nil, "", errors.New("unexpected {end}")
+			}
+			return nodes, tmpl, nil
+		case strings.HasPrefix(expr, "range "):
+			path, err := parseJPPath(strings.TrimSpace(strings.TrimPrefix(expr, "range ")))
+			if err != nil {
+				return nil, "", err
+			}
+
+			children, rest, err := parseJPNodes(tmpl, true)
+			if err != nil {
+				return nil, "", err
+			}
+
+			nodes = append(nodes, jpNode{path: path, isRange: true, children: children})
+			tmpl = rest
+		case strings.HasPrefix(expr, `"`) || strings.HasPrefix(expr, "'"):
+			text, err := unquoteJPLiteral(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jpNode{text: text})
+		default:
+			path, err := parseJPPath(expr)
+			if err != nil {
+				return nil, "", err
+			}
+			nodes = append(nodes, jpNode{path: path, isPath: true})
+		}
+	}
+
+	if inRange {
+		return nil, "", errors.New("range without {end}")
+	}
+
+	return nodes, "", nil
+}
+
+// closingJP returns the index of the close character matching the open character at the start of s,
+// skipping quoted strings
+func closingJP(s string, open, close rune) int {
+	depth := 0
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// unquoteJPLiteral unquotes a double quoted string with escapes or a literal single quoted string
+func unquoteJPLiteral(s string) (string, error) {
+	if strings.HasPrefix(s, "'") {
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("invalid string literal %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	}
+
+	u, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string literal %s", s)
+	}
+	return u, nil
+}
+
+// parseJPPath parses a path expression like .tasks[*].containers[0].name
+func parseJPPath(expr string) ([]jpStep, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(expr, "$"), "@")
+
+	// allow paths without a leading dot, like {name}
+	if p != "" && p[0] != '.' && p[0] != '[' {
+		p = "." + p
+	}
+
+	steps := []jpStep{}
+	for p != "" {
+		if p[0] == '[' {
+			end := closingJP(p, '[', ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in %s", expr)
+			}
+
+			step, err := parseJPBracket(strings.TrimSpace(p[1:end]))
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+			p = p[end+1:]
+			continue
+		}
+
+		// p starts with a dot, a lone . is the current object
+		end := strings.IndexAny(p[1:], ".[") + 1
+		if end == 0 {
+			end = len(p)
+		}
+
+		field := p[1:end]
+		switch {
+		case field == "" && p != ".":
+			return nil, fmt.Errorf("expected a field name in %s", expr)
+		case field == "*":
+			return nil, fmt.Errorf("use [*] for a wildcard in %s", expr)
+		}
+
+		if field != "" {
+			steps = append(steps, jpStep{field: field})
+		}
+		p = p[end:]
+	}
+
+	return steps, nil
+}
+
+// parseJPBracket parses the inside of a bracket, a wildcard, a filter or an index
+func parseJPBracket(b string) (jpStep, error) {
+	switch {
+	case b == "*":
+		return jpStep{}, nil
+	case strings.HasPrefix(b, "?(") && strings.HasSuffix(b, ")"):
+		f, err := parseJPFilter(strings.TrimSpace(b[2 : len(b)-1]))
+		if err != nil {
+			return jpStep{}, err
+		}
+		return jpStep{filter: f}, nil
+	}
+
+	n, err := strconv.Atoi(b)
+	if err != nil {
+		return jpStep{}, fmt.Errorf("invalid index [%s]", b)
+	}
+	return jpStep{index: &n}, nil
+}
+
+// parseJPFilter parses a filter like @.status=="created" or @.count!=0
+func parseJPFilter(expr string) (*jpFilter, error) {
+	i := jpFilterOperator(expr)
+	if i < 0 {
+		return nil, fmt.Errorf("expected == or != in filter %s", expr)
+	}
+
+	path, err := parseJPPath(strings.TrimSpace(expr[:i]))
+	if err != nil {
+		return nil, err
+	}
+
+	f := &jpFilter{path: path, equal: expr[i] == '='}
+
+	lit := strings.TrimSpace(expr[i+2:])
+	switch {
+	case strings.HasPrefix(lit, "'") || strings.HasPrefix(lit, `"`):
+		s, err := unquoteJPLiteral(lit)
+		if err != nil {
+			return nil, err
+		}
+		f.literal = s
+	case lit == "true" || lit == "false":
+		f.literal = lit == "true"
+	default:
+		n, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid literal %s in filter", lit)
+		}
+		f.literal = n
+	}
+
+	return f, nil
+}
+
+// jpFilterOperator returns the index of the == or != operator in a filter, or -1 if there isn't one.
+// Operators in quoted literals are skipped.
+func jpFilterOperator(expr string) int {
+	var quote byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(expr[i:], "==") || strings.HasPrefix(expr[i:], "!="):
+			return i
+		}
+	}
+	return -1
+}
+
+// execute writes the template for the data.  Multiple results from an expression are separated by spaces.
+func (jp *jsonPath) execute(w io.Writer, data interface{}) error {
+	return executeJPNodes(w, jp.nodes, data)
+}
+
+func executeJPNodes(w io.Writer, nodes []jpNode, data interface{}) error {
+	for _, n := range nodes {
+		switch {
+		case n.isRange:
+			for _, v := range evalJPPath(n.path, []interface{}{data}) {
+				if err := executeJPNodes(w, n.children, v); err != nil {
+					return err
+				}
+			}
+		case n.isPath:
+			results := evalJPPath(n.path, []interface{}{data})
+			values := make([]string, 0, len(results))
+			for _, v := range results {
+				values = append(values, jpString(v))
+			}
+
+			if _, err := io.WriteString(w, strings.Join(values, " ")); err != nil {
+				return err
+			}
+		default:
+			if _, err := io.WriteString(w, n.text); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// evalJPPath applies the steps to the current values, missing fields are skipped
+func evalJPPath(steps []jpStep, current []interface{}) []interface{} {
+	for _, s := range steps {
+		next := []interface{}{}
+		for _, c := range current {
+			next = append(next, evalJPStep(s, c)...)
+		}
+		current = next
+	}
+	return current
+}
+
+func evalJPStep(s jpStep, v interface{}) []interface{} {
+	switch {
+	case s.field != "":
+		if obj, ok := v.(map[string]interface{}); ok {
+			if f, ok := obj[s.field]; ok {
+				return []interface{}{f}
+			}
+		}
+	case s.index != nil:
+		if list, ok := v.([]interface{}); ok {
+			i := *s.index
+			if i < 0 {
+				i += len(list)
+			}
+
+			if i >= 0 && i < len(list) {
+				return []interface{}{list[i]}
+			}
+		}
+	case s.filter != nil:
+		out := []interface{}{}
+		for _, c := range jpChildren(v) {
+			if s.filter.matches(c) {
+				out = append(out, c)
+			}
+		}
+		return out
+	default:
+		return jpChildren(v)
+	}
+
+	return nil
+}
+
+// jpChildren returns the elements of a list, or the values of an object ordered by key
+func jpChildren(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, v[k])
+		}
+		return out
+	}
+
+	return nil
+}
+
+// matches returns true if the path exists in the value and compares with the literal, a missing path
+// never matches
+func (f *jpFilter) matches(v interface{}) bool {
+	for _, r := range evalJPPath(f.path, []interface{}{v}) {
+		if n, ok := r.(json.Number); ok {
+			if n, err := n.Float64(); err == nil {
+				r = n
+			}
+		}
+
+		if (r == f.literal) == f.equal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jpString formats a result, objects and lists are printed as json
+func jpString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(j)
+}
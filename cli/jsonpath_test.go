@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const testJPData = `{
+  "name": "web",
+  "items": [
+    {"name": "a", "status": "created", "count": 1, "ready": true, "tags": {"env": "dev"}},
+    {"name": "b==c", "status": "deleted", "count": 3, "ready": false},
+    {"name": "d", "status": "created", "count": 5, "ready": true, "tags": {"env": "prod"}}
+  ]
+}`
+
+func testJPInput(t *testing.T) interface{} {
+	var data interface{}
+	dec := json.NewDecoder(strings.NewReader(testJPData))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestJSONPath(t *testing.T) {
+	data := testJPInput(t)
+
+	tests := []struct {
+		tmpl     string
+		expected string
+	}{
+		// paths
+		{"{.name}", "web"},
+		{"{name}", "web"},
+		{"{$.name}", "web"},
+		{"{.items[0].tags}", `{"env":"dev"}`},
+		{"{.items[*].name}", "a b==c d"},
+		{"{.missing}", ""},
+		{"{.items[0].missing.deeper}", ""},
+		{"name: {.name}", "name: web"},
+		{`{.name}{"\t"}{.items[0].name}`, "web\ta"},
+		{`{'{x}'}`, "{x}"},
+
+		// indexes and wildcards
+		{"{.items[1].name}", "b==c"},
+		{"{.items[-1].name}", "d"},
+		{"{.items[5].name}", ""},
+		{"{.items[0].tags[*]}", "dev"},
+
+		// filters
+		{`{.items[?(@.status=="created")].name}`, "a d"},
+		{`{.items[?(@.status!="created")].name}`, "b==c"},
+		{`{.items[?(@.name=="b==c")].count}`, "3"},
+		{`{.items[?(@.name!="b==c")].name}`, "a d"},
+		{`{.items[?(@.name == 'b==c')].count}`, "3"},
+		{`{.items[?(@.name=="a<b")].name}`, ""},
+		{`{.items[?(@.name!="say \"a>b\"")].name}`, "a b==c d"},
+		{`{.items[?(@.count==5)].name}`, "d"},
+		{`{.items[?(@.count!=5.0)].name}`, "a b==c"},
+		{`{.items[?(@.ready==true)].name}`, "a d"},
+		{`{.items[?(@.ready!=true)].name}`, "b==c"},
+		{`{.items[?(@.tags.env=="prod")].name}`, "d"},
+		{`{.items[?(@.tags.env!="prod")].name}`, "a"},
+
+		// ranges
+		{`{range .items[*]}{.name}={.count}{"\n"}{end}`, "a=1\nb==c=3\nd=5\n"},
+		{`{range .items[?(@.ready==true)]}[{.tags.env}]{end}`, "[dev][prod]"},
+		{`{range .items[*]}{.tags.env}{"."}{end}`, "dev..prod."},
+		{`{range .items[-1].tags[*]}{.}{end}`, "prod"},
+	}
+
+	for _, test := range tests {
+		jp, err := parseJSONPath(test.tmpl)
+		if err != nil {
+			t.Errorf("expected nil error parsing %s, got %s", test.tmpl, err)
+			continue
+		}
+
+		out := &bytes.Buffer{}
+		if err := jp.execute(out, data); err != nil {
+			t.Errorf("expected nil error executing %s, got %s", test.tmpl, err)
+			continue
+		}
+
+		if out.String() != test.expected {
+			t.Errorf("expected %q for %s, got %q", test.expected, test.tmpl, out.String())
+		}
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	tests := []string{
+		"{.name",
+		"{end}",
+		"{range .items[*]}{.name}",
+		"{.items[0}",
+		"{.items[x]}",
+		"{.items[a:b]}",
+		`{.items[?(@.count==x)]}`,
+		`{.items[?(@.name=="a)]}`,
+		`{..}`,
+		`{..name}`,
+		`{['name']}`,
+		`{.items.*.name}`,
+		`{.items[0:2]}`,
+		`{.items[?(@.count>1)]}`,
+		`{.items[?(@.tags)]}`,
+		`{"unterminated}`,
+	}
+
+	for _, tmpl := range tests {
+		if _, err := parseJSONPath(tmpl); err == nil {
+			t.Errorf("expected error parsing %s, got nil", tmpl)
+		}
+	}
+}
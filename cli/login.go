@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/credentials"
+	"github.com/YaleSpinup/spinup-cli/pkg/login"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// defaultLoginClientID is the OAuth2 client registered for the cli
+	defaultLoginClientID = "spinup-cli"
+
+	// loginTimeout is how long to wait for the user to finish logging in
+	loginTimeout = 5 * time.Minute
+
+	// tokenRefreshMargin is how long before it expires a token is refreshed
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+var (
+	loginDevice    bool
+	loginNoBrowser bool
+	loginPort      int
+
+	// loginSettings are the authorization server settings, they're saved to the profile when passed as flags
+	loginSettings = map[string]string{
+		"auth-url":        "auth_url",
+		"token-url":       "token_url",
+		"device-auth-url": "device_auth_url",
+		"client-id":       "client_id",
+		"scopes":          "scopes",
+	}
+
+	// tokenRefreshable is true when the token will be refreshed before it expires
+	tokenRefreshable bool
+)
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	loginCmd.Flags().BoolVar(&loginDevice, "device", false, "Log in with a code on another device, instead of a browser on this host")
+	loginCmd.Flags().BoolVar(&loginNoBrowser, "no-browser", false, "Print the login url instead of opening a browser")
+	loginCmd.Flags().IntVar(&loginPort, "port", 0, "The loopback port for the browser redirect (default is a random port)")
+	loginCmd.Flags().String("auth-url", "", "The OAuth2 authorization endpoint")
+	loginCmd.Flags().String("token-url", "", "The OAuth2 token endpoint")
+	loginCmd.Flags().String("device-auth-url", "", "The OAuth2 device authorization endpoint")
+	loginCmd.Flags().String("client-id", "", "The OAuth2 client id (default \""+defaultLoginClientID+"\")")
+	loginCmd.Flags().StringSlice("scopes", nil, "The OAuth2 scopes to request")
+}
+
+// loginConfig returns the authorization server settings for the active profile, flags on the login
+// command take precedence
+func loginConfig(cmd *cobra.Command) *login.Config {
+	setting := func(flag string) string {
+		if f := cmd.Flags().Lookup(flag); f != nil && f.Changed {
+			return f.Value.String()
+		}
+		return viper.GetString(profileKey(cmd, loginSettings[flag]))
+	}
+
+	c := &login.Config{
+		AuthURL:       setting("auth-url"),
+		TokenURL:      setting("token-url"),
+		DeviceAuthURL: setting("device-auth-url"),
+		ClientID:      setting("client-id"),
+		Scopes:        viper.GetStringSlice(profileKey(cmd, "scopes")),
+	}
+
+	if f := cmd.Flags().Lookup("scopes"); f != nil && f.Changed {
+		c.Scopes, _ = cmd.Flags().GetStringSlice("scopes")
+	}
+
+	if c.ClientID == "" {
+		c.ClientID = defaultLoginClientID
+	}
+
+	return c
+}
+
+// loadToken reads the token from the credential store and refreshes it if it's about to expire
+func loadToken(cmd *cobra.Command, tokenRef string) (string, error) {
+	raw, err := resolveToken(tokenRef)
+	if err != nil {
+		return "", err
+	}
+
+	t, err := login.DecodeToken(raw)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the token in %s: %s", tokenRef, err)
+	}
+
+	if t.RefreshToken == "" {
+		return t.AccessToken, nil
+	}
+
+	tokenRefreshable = true
+
+	expiry := tokenExpiry(t)
+	if expiry.IsZero() || time.Until(expiry) > tokenRefreshMargin {
+		return t.AccessToken, nil
+	}
+
+	log.Infof("refreshing the token for profile %s, it expires at %s", activeProfile, expiry)
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	refreshed, err := loginConfig(cmd).Refresh(ctx, t)
+	if err != nil {
+		log.Warnf("failed to refresh the token, run `spinup login --profile %s`: %s", activeProfile, err)
+		return t.AccessToken, nil
+	}
+
+	if err := saveToken(tokenRef, refreshed); err != nil {
+		log.Warnf("failed to store the refreshed token: %s", err)
+	}
+
+	return refreshed.AccessToken, nil
+}
+
+// tokenExpiry returns when the token expires, from the token response or the exp claim
+func tokenExpiry(t *oauth2.Token) time.Time {
+	if !t.Expiry.IsZero() {
+		return t.Expiry
+	}
+
+	claims, err := parseToken(t.AccessToken)
+	if err != nil {
+		return time.Time{}
+	}
+
+	exp, _ := claims.time("exp")
+	return exp
+}
+
+// saveToken stores a token, with its refresh token, in the store the reference points to
+func saveToken(tokenRef string, t *oauth2.Token) error {
+	ref, err := credentials.ParseRef(tokenRef)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := login.EncodeToken(t)
+	if err != nil {
+		return err
+	}
+
+	store, err := newCredentialStore(ref)
+	if err != nil {
+		return err
+	}
+
+	return store.Set(ref.Key, encoded)
+}
+
+// openBrowser opens the url in the default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to Spinup and store the token",
+	Long: `Log in to Spinup through the OAuth2 authorization server and store the token in the credential store.
+By default a browser is opened on this host, pass --device to log in with a code on another device.
+
+The authorization server is configured per profile with the auth_url, token_url, device_auth_url, client_id
+and scopes settings, or with the flags, which are saved to the profile after a successful login.  The token is
+refreshed automatically before it expires.`,
+	Example: `spinup login --auth-url https://login.example.edu/authorize --token-url https://login.example.edu/token
+spinup login --device --profile staging`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := loginConfig(cmd)
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), loginTimeout)
+		defer cancel()
+
+		var t *oauth2.Token
+		var err error
+		if loginDevice || (cfg.AuthURL == "" && cfg.DeviceAuthURL != "") {
+			t, err = cfg.DeviceLogin(ctx, func(da *oauth2.DeviceAuthResponse) error {
+				fmt.Fprintf(os.Stderr, "Open %s and enter the code %s\n", da.VerificationURI, da.UserCode)
+				if da.VerificationURIComplete != "" {
+					fmt.Fprintf(os.Stderr, "or open %s\n", da.VerificationURIComplete)
+				}
+				fmt.Fprintln(os.Stderr, "Waiting for the login to be approved...")
+				return nil
+			})
+		} else {
+			t, err = cfg.BrowserLogin(ctx, loginPort, func(url string) error {
+				if !loginNoBrowser {
+					if err := openBrowser(url); err == nil {
+						fmt.Fprintf(os.Stderr, "Opened a browser to log in, if it didn't open visit:\n\n  %s\n\n", url)
+						return nil
+					}
+				}
+				fmt.Fprintf(os.Stderr, "Open this url in a browser on this host to log in:\n\n  %s\n\n", url)
+				return nil
+			})
+		}
+
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("timed out after %s waiting for the login", loginTimeout)
+			}
+			return fmt.Errorf("login failed: %s", err)
+		}
+
+		tokenRefreshable = t.RefreshToken != ""
+		if err := validateToken(t.AccessToken); err != nil {
+			return fmt.Errorf("login returned an unusable token: %s", err)
+		}
+
+		// keep using the profile's credential store, a credential process can't store tokens
+		backend := storeAuto
+		if current := viper.GetString(profileKey(cmd, "token_ref")); current != "" {
+			ref, err := credentials.ParseRef(current)
+			if err != nil {
+				return err
+			}
+
+			if ref.Backend == credentials.BackendProcess {
+				return fmt.Errorf("profile %s gets its token from a credential process, run `spinup configure --profile %s --credential-store auto` to store tokens instead", activeProfile, activeProfile)
+			}
+			backend = ref.Backend
+		}
+
+		encoded, err := login.EncodeToken(t)
+		if err != nil {
+			return err
+		}
+
+		tokenRef, err := storeToken(backend, activeProfile, encoded)
+		if err != nil {
+			return fmt.Errorf("failed to store the token: %s", err)
+		}
+
+		if err := updateConfig(func(cfg map[string]interface{}) error {
+			settings := cfg
+			if activeProfile != defaultProfile {
+				profiles := configProfiles(cfg)
+
+				p, ok := profiles[activeProfile].(map[string]interface{})
+				if !ok {
+					p = map[string]interface{}{}
+					profiles[activeProfile] = p
+				}
+				settings = p
+			}
+
+			delete(settings, "token")
+			settings["token_ref"] = tokenRef
+
+			for flag, key := range loginSettings {
+				f := cmd.Flags().Lookup(flag)
+				if !f.Changed {
+					continue
+				}
+
+				if flag == "scopes" {
+					settings[key], _ = cmd.Flags().GetStringSlice(flag)
+				} else {
+					settings[key] = f.Value.String()
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		msg := fmt.Sprintf("logged in to profile %s", activeProfile)
+		if claims, err := parseToken(t.AccessToken); err == nil && claims.string("sub") != "" {
+			msg += " as " + claims.string("sub")
+		}
+		fmt.Fprintf(os.Stderr, "%s, stored the token in %s\n", msg, tokenRef)
+
+		return nil
+	},
+}
@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsContainer string
+	logsSince     time.Duration
+	logsFollow    bool
+	logsTask      string
+	logsGrep      string
+)
+
+// logsPollInterval is how often new log events are polled for with --follow
+var logsPollInterval = 2 * time.Second
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.AddCommand(logsContainerCmd)
+	logsContainerCmd.Flags().StringVar(&logsContainer, "container", "", "Only show the logs of this container (default is all containers)")
+	logsContainerCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show logs newer than a relative duration like 30m or 2h (default is all logs)")
+	logsContainerCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Follow the logs, including the logs of tasks that start while following")
+	logsContainerCmd.Flags().StringVar(&logsTask, "task", "", "Only show the logs of this task, which can be a stopped task (default is all running tasks)")
+	logsContainerCmd.Flags().StringVar(&logsGrep, "grep", "", "Only show log lines matching this regular expression")
+}
+
+// logStream is the log stream of a container in a task
+type logStream struct {
+	task      string
+	container string
+
+	// token is the pagination token to get the events after the ones already printed
+	token string
+
+	// stopped is true when the task is no longer running
+	stopped bool
+}
+
+// logLine is a log event from a stream
+type logLine struct {
+	stream *logStream
+	event  *spinup.ContainerLogEvent
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [type] [space]/[resource]",
+	Short: "Get the logs of a resource in a space",
+}
+
+var logsContainerCmd = &cobra.Command{
+	Use:   "container [space]/[resource]",
+	Short: "Get the logs of a container service",
+	Long: `Get the logs of the containers in the running tasks of a container service.  The logs of all tasks and
+containers are interleaved by time, each line prefixed with its timestamp, task and container.
+
+Pass --task to get the logs of a single task, including a task that has stopped, and --follow to keep
+printing new logs until interrupted.  Only containers using the awslogs log driver can be read.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		var grep *regexp.Regexp
+		if logsGrep != "" {
+			var err error
+			if grep, err = regexp.Compile(logsGrep); err != nil {
+				return fmt.Errorf("invalid --grep pattern: %s", err)
+			}
+		}
+
+		if logsSince < 0 {
+			return errors.New("--since must be a positive duration")
+		}
+
+		params, err := parseResourceParams(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return err
+		}
+
+		containers, err := logContainers(info, logsContainer)
+		if err != nil {
+			return err
+		}
+
+		var start time.Time
+		if logsSince > 0 {
+			start = time.Now().Add(-logsSince)
+		}
+
+		streams := []*logStream{}
+		addTasks := func(info *spinup.ContainerService) {
+			tasks := []string{logsTask}
+			if logsTask == "" {
+				tasks = tasks[:0]
+				for _, t := range info.Tasks {
+					tasks = append(tasks, taskID(t))
+				}
+			}
+
+			for _, t := range tasks {
+				for _, c := range containers {
+					if findLogStream(streams, t, c) == nil {
+						log.Debugf("reading logs of task %s container %s", t, c)
+						streams = append(streams, &logStream{task: t, container: c})
+					}
+				}
+			}
+		}
+
+		addTasks(info)
+		if len(streams) == 0 && !logsFollow {
+			return fmt.Errorf("container service %s/%s has no running tasks, pass --task to get the logs of a stopped task", params["space"], params["name"])
+		}
+
+		for {
+			if err := printLogs(ctx, params, streams, start, grep); err != nil {
+				if logsFollow && errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+
+			if !logsFollow {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(logsPollInterval):
+			}
+
+			if logsTask != "" {
+				continue
+			}
+
+			// pick up tasks that started, tasks that are gone are read one last time before they're dropped
+			info := &spinup.ContainerService{}
+			if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
+				return err
+			}
+
+			listed := map[string]bool{}
+			for _, t := range info.Tasks {
+				listed[taskID(t)] = true
+			}
+
+			current := streams[:0]
+			for _, s := range streams {
+				if !s.stopped {
+					current = append(current, s)
+				}
+			}
+			streams = current
+
+			for _, s := range streams {
+				if !listed[s.task] {
+					log.Debugf("task %s is no longer running", s.task)
+					s.stopped = true
+				}
+			}
+
+			addTasks(info)
+		}
+	},
+}
+
+// logContainers returns the names of the containers in the service whose logs can be read, only the
+// named container if one is passed
+func logContainers(info *spinup.ContainerService, name string) ([]string, error) {
+	containers := []string{}
+	for _, cd := range info.TaskDefinition.ContainerDefinitions {
+		if name != "" && cd.Name != name {
+			continue
+		}
+
+		if cd.LogConfiguration.LogDriver != "awslogs" {
+			if name != "" {
+				return nil, fmt.Errorf("container %s uses the %q log driver, only awslogs can be read", name, cd.LogConfiguration.LogDriver)
+			}
+
+			log.Warnf("skipping container %s, it uses the %q log driver and only awslogs can be read", cd.Name, cd.LogConfiguration.LogDriver)
+			continue
+		}
+
+		containers = append(containers, cd.Name)
+	}
+
+	if name != "" && len(containers) == 0 {
+		names := []string{}
+		for _, cd := range info.TaskDefinition.ContainerDefinitions {
+			names = append(names, cd.Name)
+		}
+		return nil, fmt.Errorf("container %s not found, expected one of %s", name, strings.Join(names, ", "))
+	}
+
+	if len(containers) == 0 {
+		return nil, errors.New("none of the containers use the awslogs log driver")
+	}
+
+	return containers, nil
+}
+
+// findLogStream returns the stream for the task and container, or nil
+func findLogStream(streams []*logStream, task, container string) *logStream {
+	for _, s := range streams {
+		if s.task == task && s.container == container {
+			return s
+		}
+	}
+	return nil
+}
+
+// printLogs gets the events logged to the streams since the last call and prints them in order
+func printLogs(ctx context.Context, params map[string]string, streams []*logStream, start time.Time, grep *regexp.Regexp) error {
+	events := make([][]*spinup.ContainerLogEvent, len(streams))
+	if err := SpinupClient.Parallel(ctx, len(streams), func(ctx context.Context, i int) error {
+		s := streams[i]
+		p := map[string]string{"space": params["space"], "name": params["name"], "taskId": s.task, "container": s.container}
+
+		out, token, err := SpinupClient.ContainerLogEventsCtx(ctx, p, start, s.token)
+		if err != nil {
+			// the stream doesn't exist until the container logs something
+			if spinup.IsNotFound(err) {
+				log.Debugf("no logs for task %s container %s", s.task, s.container)
+				return nil
+			}
+			return err
+		}
+
+		events[i] = out
+		s.token = token
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	lines := []*logLine{}
+	for i, out := range events {
+		for _, e := range out {
+			lines = append(lines, &logLine{stream: streams[i], event: e})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].event.Timestamp < lines[j].event.Timestamp })
+
+	for _, l := range lines {
+		message := strings.TrimRight(l.event.Message, "\r\n")
+		if grep != nil && !grep.MatchString(message) {
+			continue
+		}
+
+		fmt.Fprintf(os.Stdout, "%s [%s/%s] %s\n", l.event.Time().UTC().Format("2006-01-02T15:04:05.000Z07:00"), l.stream.task, l.stream.container, message)
+	}
+
+	return nil
+}
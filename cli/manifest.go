@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+)
+
+// spotCapacityProvider is the capacity provider for container services running on spot capacity
+const spotCapacityProvider = "FARGATE_SPOT"
+
+// manifestSpace returns the space for a manifest, from the manifest or the single default space
+func manifestSpace(m *manifest.Manifest) (string, error) {
+	if m.Space != "" {
+		return m.Space, nil
+	}
+
+	if len(spinupSpaces) != 1 {
+		return "", fmt.Errorf("the manifest doesn't set a space and there isn't exactly one default space")
+	}
+
+	return spinupSpaces[0], nil
+}
+
+// resolveSecretValues reads the values of the managed secrets in the manifest from the environment or
+// from files relative to the manifest
+func resolveSecretValues(m *manifest.Manifest, path string) error {
+	dir := "."
+	if path != "-" {
+		dir = filepath.Dir(path)
+	}
+
+	for _, s := range m.Secrets {
+		switch {
+		case s.FromEnv != "":
+			v, ok := os.LookupEnv(s.FromEnv)
+			if !ok || v == "" {
+				return fmt.Errorf("secret %s: environment variable %s isn't set", s.Name, s.FromEnv)
+			}
+			s.Value = v
+		case s.FromFile != "":
+			file := s.FromFile
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+
+			b, err := os.ReadFile(filepath.Clean(file))
+			if err != nil {
+				return fmt.Errorf("secret %s: %s", s.Name, err)
+			}
+
+			// editors add a trailing newline that isn't part of the value
+			s.Value = strings.TrimRight(string(b), "\r\n")
+			if s.Value == "" {
+				return fmt.Errorf("secret %s: %s is empty", s.Name, file)
+			}
+		default:
+			continue
+		}
+
+		if len(s.Value) > maxSecretSize {
+			return fmt.Errorf("secret %s is greater than 4KB", s.Name)
+		}
+	}
+
+	return nil
+}
+
+// isStorageBucket returns true if the resource is a S3 storage bucket
+func isStorageBucket(r *spinup.Resource) bool {
+	if r.Type == nil || r.Type.Type != "storage" {
+		return false
+	}
+	return r.Type.Flavor == "s3" || r.Type.Flavor == "s3bucket"
+}
+
+// isDatabase returns true if the resource is a database
+func isDatabase(r *spinup.Resource) bool {
+	return r.Type != nil && r.Type.Type == "database"
+}
+
+// liveManifest returns the current state of a space as a manifest.  The values of secrets are only read
+// for the secrets whose value is managed by the desired manifest, desired may be nil.
+func liveManifest(ctx context.Context, space string, desired *manifest.Manifest) (*manifest.Manifest, error) {
+	resources, err := SpinupClient.ResourcesCtx(ctx, space)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := spaceSecrets(ctx, map[string]string{"space": space})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifest.Manifest{
+		APIVersion: manifest.APIVersion,
+		Kind:       manifest.KindSpace,
+		Space:      space,
+	}
+
+	var containers, buckets []*spinup.Resource
+	for _, r := range resources {
+		if r.Status == "deleted" {
+			continue
+		}
+
+		switch {
+		case isContainerService(r):
+			containers = append(containers, r)
+		case isStorageBucket(r):
+			buckets = append(buckets, r)
+		case isDatabase(r):
+			m.Databases = append(m.Databases, &manifest.Database{Name: r.Name, SizeID: flexInt(r.SizeID)})
+		}
+	}
+
+	m.Containers = make([]*manifest.ContainerService, len(containers))
+	if err := SpinupClient.Parallel(ctx, len(containers), func(ctx context.Context, i int) error {
+		r := containers[i]
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"space": space, "name": r.Name}, info); err != nil {
+			return err
+		}
+
+		m.Containers[i] = manifestContainer(r, info, secrets)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	m.Storage = make([]*manifest.Storage, len(buckets))
+	if err := SpinupClient.Parallel(ctx, len(buckets), func(ctx context.Context, i int) error {
+		r := buckets[i]
+		users := &spinup.S3StorageUsers{}
+		if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"space": space, "name": r.Name}, users); err != nil {
+			return err
+		}
+
+		s := &manifest.Storage{Name: r.Name, SizeID: flexInt(r.SizeID)}
+		for _, u := range *users {
+			s.Users = append(s.Users, u.Username)
+		}
+		sort.Strings(s.Users)
+
+		m.Storage[i] = s
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	managed := map[string]bool{}
+	if desired != nil {
+		for _, s := range desired.Secrets {
+			managed[s.Name] = s.Managed()
+		}
+	}
+
+	m.Secrets = make([]*manifest.Secret, len(secrets))
+	if err := SpinupClient.Parallel(ctx, len(secrets), func(ctx context.Context, i int) error {
+		s := &manifest.Secret{Name: secrets[i].Name, Description: secrets[i].Description}
+		m.Secrets[i] = s
+
+		if !managed[s.Name] {
+			return nil
+		}
+
+		value, err := SpinupClient.SecretValueCtx(ctx, space, s.Name)
+		if err != nil {
+			log.Warnf("unable to read the current value of %s, it will be updated: %s", s.Name, err)
+			return nil
+		}
+		s.Value = value.Value
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(m.Secrets, func(i, j int) bool { return m.Secrets[i].Name < m.Secrets[j].Name })
+	sort.Slice(m.Containers, func(i, j int) bool { return m.Containers[i].Name < m.Containers[j].Name })
+	sort.Slice(m.Storage, func(i, j int) bool { return m.Storage[i].Name < m.Storage[j].Name })
+	sort.Slice(m.Databases, func(i, j int) bool { return m.Databases[i].Name < m.Databases[j].Name })
+
+	return m, nil
+}
+
+// manifestContainer converts a container service to its manifest representation, secrets referenced by
+// the container definitions are named by the secret in the space with the same ARN
+func manifestContainer(r *spinup.Resource, info *spinup.ContainerService, secrets []*spinup.Secret) *manifest.ContainerService {
+	desiredCount := info.DesiredCount
+	c := &manifest.ContainerService{
+		Name:         r.Name,
+		SizeID:       flexInt(r.SizeID),
+		DesiredCount: &desiredCount,
+		Capacity:     manifest.CapacityOnDemand,
+	}
+
+	for _, cp := range info.CapacityProviderStrategy {
+		if cp.CapacityProvider == spotCapacityProvider {
+			c.Capacity = manifest.CapacitySpot
+		}
+	}
+
+	for _, cd := range info.TaskDefinition.ContainerDefinitions {
+		d := &manifest.Container{
+			Name:    cd.Name,
+			Image:   cd.Image,
+			Command: cd.Command,
+		}
+
+		for _, p := range cd.PortMappings {
+			proto := p.Protocol
+			if proto == "" {
+				proto = "tcp"
+			}
+			d.Ports = append(d.Ports, manifest.FormatPort(p.ContainerPort, proto))
+		}
+
+		if len(cd.Environment) > 0 {
+			d.Env = map[string]string{}
+			for _, e := range cd.Environment {
+				d.Env[e.Name] = e.Value
+			}
+		}
+
+		if len(cd.Secrets) > 0 {
+			d.Secrets = map[string]string{}
+			for _, s := range cd.Secrets {
+				d.Secrets[s.Name] = s.ValueFrom
+				for _, secret := range secrets {
+					if secretReferencesArn(s.ValueFrom, secret.ARN) {
+						d.Secrets[s.Name] = secret.Name
+						break
+					}
+				}
+			}
+		}
+
+		c.Definitions = append(c.Definitions, d)
+	}
+
+	return c
+}
+
+// flexInt returns the value of a FlexInt, or 0 if it's nil
+func flexInt(i *spinup.FlexInt) int {
+	if i == nil {
+		return 0
+	}
+	return int(*i)
+}
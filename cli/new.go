@@ -1,18 +1,27 @@
 package cli
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+// maxSecretSize is the largest secret value (in bytes) accepted by the cli
+const maxSecretSize = 4000
+
 var (
 	secretName        string
+	secretSpace       string
 	secretValue       string
 	secretValueFrom   string
 	secretDescription string
@@ -23,9 +32,10 @@ func init() {
 	newCmd.AddCommand(newSpaceCmd)
 	newCmd.AddCommand(newSecretCmd)
 	newSecretCmd.PersistentFlags().StringVar(&secretName, "name", "", "The name of your secret")
-	newSecretCmd.PersistentFlags().StringVar(&secretValue, "value", "", "The value of your secret")
+	newSecretCmd.PersistentFlags().StringVar(&secretValue, "value", "", "The value of your secret (prefer --from or the prompt to keep it out of your shell history)")
 	newSecretCmd.PersistentFlags().StringVar(&secretDescription, "description", "", "A short description for your secret (optional)")
-	newSecretCmd.PersistentFlags().StringVar(&secretValueFrom, "from", "", "A file containing your secret value")
+	newSecretCmd.PersistentFlags().StringVar(&secretValueFrom, "from", "", "A file containing your secret value, or '-' to read it from stdin")
+	newSecretCmd.MarkFlagsMutuallyExclusive("value", "from")
 }
 
 var newCmd = &cobra.Command{
@@ -42,43 +52,122 @@ var newSpaceCmd = &cobra.Command{
 }
 
 var newSecretCmd = &cobra.Command{
-	Use:   "secret",
+	Use:   "secret [space]",
 	Short: "Command to create a secret in a space",
 	PreRunE: func(cmd *cobra.Command, args []string) error {
 		if secretName == "" {
 			return errors.New("a secret name is required")
 		}
 
-		if secretValue == "" && secretValueFrom == "" {
-			return errors.New("a secret value or file is required")
+		spaces, err := parseSpaceInput(args)
+		if err != nil {
+			return err
 		}
 
-		if cmd.Flags().Changed("from") {
-			secretPath := filepath.Clean(secretValueFrom)
-			f, err := os.Open(secretPath)
-			if err != nil {
-				return err
-			}
-			defer f.Close()
-
-			body, err := ioutil.ReadAll(f)
-			if err != nil {
-				return err
-			}
-
-			log.Debugf("size of body is %d byets", len(body))
-
-			if len(body) > 4000 {
-				return errors.New("File size is greater than 4KB")
-			}
+		if len(spaces) != 1 {
+			return errors.New("exactly one space is required to create a secret")
+		}
+		secretSpace = spaces[0]
 
-			secretValue = string(body)
+		value, err := readSecretValue(cmd)
+		if err != nil {
+			return err
 		}
+		secretValue = value
 
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		log.Infof("creating secret %s:%s", secretName, secretValue)
-		return nil
+		log.Infof("creating secret %s in space %s", secretName, secretSpace)
+
+		ctx := cmd.Context()
+
+		input, err := json.Marshal(spinup.SecretInput{
+			Name:        secretName,
+			Value:       secretValue,
+			Description: secretDescription,
+		})
+		if err != nil {
+			return err
+		}
+
+		out := &spinup.CreateSecretOutput{}
+		if err := SpinupClient.PostResourceCtx(ctx, map[string]string{"space": secretSpace}, input, out); err != nil {
+			return err
+		}
+
+		log.Debugf("created secret %+v", out)
+
+		secret := &spinup.Secret{}
+		if err := SpinupClient.GetResourceCtx(ctx, map[string]string{"space": secretSpace, "secretname": secretName}, secret); err != nil {
+			return err
+		}
+
+		if secret.ARN == "" {
+			secret.ARN = out.ARN
+		}
+
+		return formatOutput(newSecretDetails(secretSpace, secret))
 	},
 }
+
+// readSecretValue reads the secret value from the --value flag, the file passed with --from ('-' for stdin),
+// piped stdin or a prompt that doesn't echo the value.  --value and --from can't be passed together.  The
+// value is never logged.
+func readSecretValue(cmd *cobra.Command) (string, error) {
+	var body []byte
+	switch {
+	case cmd.Flags().Changed("value"):
+		log.Debug("reading secret value from the command line")
+		body = []byte(secretValue)
+	case secretValueFrom == "-":
+		log.Debug("reading secret value from stdin")
+
+		b, err := ioutil.ReadAll(io.LimitReader(os.Stdin, maxSecretSize+1))
+		if err != nil {
+			return "", err
+		}
+		body = []byte(strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r"))
+	case secretValueFrom != "":
+		secretPath := filepath.Clean(secretValueFrom)
+		f, err := os.Open(secretPath)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		b, err := ioutil.ReadAll(io.LimitReader(f, maxSecretSize+1))
+		if err != nil {
+			return "", err
+		}
+		body = b
+	case term.IsTerminal(int(os.Stdin.Fd())):
+		fmt.Fprint(os.Stderr, "Secret value: ")
+		b, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		body = b
+	default:
+		log.Debug("reading secret value from piped stdin")
+
+		b, err := ioutil.ReadAll(io.LimitReader(os.Stdin, maxSecretSize+1))
+		if err != nil {
+			return "", err
+		}
+		body = []byte(strings.TrimSuffix(strings.TrimSuffix(string(b), "\n"), "\r"))
+	}
+
+	log.Debugf("size of secret value is %d bytes", len(body))
+
+	if len(body) == 0 {
+		return "", errors.New("a secret value or file is required")
+	}
+
+	if len(body) > maxSecretSize {
+		return "", errors.New("secret value is greater than 4KB")
+	}
+
+	return string(body), nil
+}
@@ -0,0 +1,497 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormat is the format passed with --output
+var outputFormat string
+
+// outputFormats is the list of supported output formats, for the help text and errors
+var outputFormats = []string{"json", "yaml", "table", "wide", "jsonpath=...", "go-template=..."}
+
+// outputPrinter writes a value in one of the output formats
+type outputPrinter func(w io.Writer, out interface{}) error
+
+// parseOutputFormat parses an output format and returns the printer for it.  Templates are parsed here so
+// that mistakes are reported before any requests are made.
+func parseOutputFormat(format string) (outputPrinter, error) {
+	name, arg, hasArg := strings.Cut(format, "=")
+	switch name {
+	case "", "json":
+		return printJSON, nil
+	case "yaml":
+		return printYAML, nil
+	case "table":
+		return func(w io.Writer, out interface{}) error { return printTable(w, out, false) }, nil
+	case "wide":
+		return func(w io.Writer, out interface{}) error { return printTable(w, out, true) }, nil
+	case "jsonpath":
+		if !hasArg || arg == "" {
+			return nil, fmt.Errorf("jsonpath output requires a template, eg. -o jsonpath='{.name}'")
+		}
+
+		jp, err := parseJSONPath(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse jsonpath %s: %s", arg, err)
+		}
+
+		return func(w io.Writer, out interface{}) error {
+			data, err := normalizeOutput(out)
+			if err != nil {
+				return err
+			}
+			return jp.execute(w, data)
+		}, nil
+	case "go-template":
+		if !hasArg || arg == "" {
+			return nil, fmt.Errorf("go-template output requires a template, eg. -o go-template='{{.name}}'")
+		}
+
+		tmpl, err := template.New("output").Funcs(template.FuncMap{
+			"json": func(v interface{}) (string, error) {
+				j, err := json.Marshal(v)
+				return string(j), err
+			},
+		}).Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse go-template: %s", err)
+		}
+
+		return func(w io.Writer, out interface{}) error {
+			data, err := normalizeOutput(out)
+			if err != nil {
+				return err
+			}
+			return tmpl.Execute(w, data)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported output format %q, expected one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// formatOutput writes the output to stdout in the format selected with --output
+func formatOutput(out interface{}) error {
+	printer, err := parseOutputFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	f := bufio.NewWriter(os.Stdout)
+	defer f.Flush()
+
+	return printer(f, out)
+}
+
+// normalizeOutput round trips the output through json so templates and jsonpaths address the
+// same field names as the json output
+func normalizeOutput(out interface{}) (interface{}, error) {
+	j, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(j))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func printJSON(w io.Writer, out interface{}) error {
+	j, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(j)
+	return err
+}
+
+// printYAML converts the json representation to yaml, preserving the field order
+func printYAML(w io.Writer, out interface{}) error {
+	j, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	// json is yaml, decoding into a node keeps the order of the fields
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(j, node); err != nil {
+		return err
+	}
+	resetYAMLStyle(node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// resetYAMLStyle clears the flow and quoting styles carried over from the json
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		resetYAMLStyle(n)
+	}
+}
+
+// tableColumn is a column in table output, Field is the dotted path to the value in the json output
+type tableColumn struct {
+	Header string
+	Field  string
+}
+
+// tableColumner is implemented by outputs with default table columns, the wide columns are
+// shown with -o wide
+type tableColumner interface {
+	tableColumns(wide bool) []tableColumn
+}
+
+func (r *ResourceSummary) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"NAME", "name"},
+		{"STATUS", "status"},
+		{"STATE", "state"},
+		{"SIZE", "size"},
+		{"IP", "ip"},
+	}
+
+	if wide {
+		columns = append(columns,
+			tableColumn{"TYPE", "type"},
+			tableColumn{"FLAVOR", "flavor"},
+			tableColumn{"SECURITY", "security"},
+			tableColumn{"ID", "id"},
+			tableColumn{"BETA", "beta"},
+			tableColumn{"TRYIT", "tryit"},
+		)
+	}
+
+	return columns
+}
+
+func (s SpaceOutput) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"NAME", "name"},
+		{"ID", "id"},
+		{"OWNER", "owner"},
+		{"SECURITY", "security"},
+	}
+
+	if wide {
+		columns = append(columns,
+			tableColumn{"DEPARTMENT", "department"},
+			tableColumn{"CONTACT", "contact"},
+			tableColumn{"CREATED", "created_at"},
+		)
+	}
+
+	return columns
+}
+
+func (s SpacesOutput) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"NAME", "name"},
+		{"ID", "id"},
+		{"RESOURCES", "resource_count"},
+		{"OWNER", "owner"},
+	}
+
+	if wide {
+		columns = append(columns,
+			tableColumn{"SECURITY", "security"},
+			tableColumn{"DEPARTMENT", "department"},
+			tableColumn{"CREATED", "created_at"},
+		)
+	}
+
+	return columns
+}
+
+func (s *SecretDetails) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"NAME", "name"},
+		{"DESCRIPTION", "description"},
+		{"SPACE", "space"},
+	}
+
+	if wide {
+		columns = append(columns,
+			tableColumn{"LAST MODIFIED", "last_modified"},
+			tableColumn{"ARN", "arn"},
+		)
+	}
+
+	return columns
+}
+
+// outputColumns returns the default table columns for the output, or for the elements of a list or map output
+func outputColumns(out interface{}, wide bool) []tableColumn {
+	if t, ok := out.(tableColumner); ok {
+		return t.tableColumns(wide)
+	}
+
+	v := reflect.ValueOf(out)
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		elem := v.Type().Elem()
+		columner := reflect.TypeOf((*tableColumner)(nil)).Elem()
+		if elem.Implements(columner) {
+			return reflect.Zero(elem).Interface().(tableColumner).tableColumns(wide)
+		}
+
+		if elem.Kind() != reflect.Ptr && reflect.PtrTo(elem).Implements(columner) {
+			return reflect.New(elem).Interface().(tableColumner).tableColumns(wide)
+		}
+	}
+
+	return nil
+}
+
+// printTable prints the output as a table.  Lists (and maps of objects) are printed one row per element,
+// anything else is a single row.  Types without default columns show their scalar fields, or every field
+// when wide.
+func printTable(w io.Writer, out interface{}, wide bool) error {
+	j, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	rows := tableRows(j)
+
+	columns := outputColumns(out, wide)
+	if columns == nil {
+		columns = defaultColumns(rows, wide)
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 3, ' ', 0)
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.Header
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+
+	for _, row := range rows {
+		var data interface{}
+		dec := json.NewDecoder(bytes.NewReader(row))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return err
+		}
+
+		cells := make([]string, len(columns))
+		for i, c := range columns {
+			cells[i] = tableCell(lookupField(data, c.Field))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// tableRows splits the json output into table rows
+func tableRows(j []byte) []json.RawMessage {
+	switch jsonKind(j) {
+	case '[':
+		list := []json.RawMessage{}
+		if err := json.Unmarshal(j, &list); err != nil {
+			return nil
+		}
+		return list
+	case '{':
+		obj := map[string]json.RawMessage{}
+		if err := json.Unmarshal(j, &obj); err != nil {
+			return nil
+		}
+
+		keys := objectKeys(j)
+		if len(keys) == 0 {
+			return nil
+		}
+
+		// unwrap outputs like {"tasks": [...]}
+		if len(keys) == 1 && jsonKind(obj[keys[0]]) == '[' {
+			return tableRows(obj[keys[0]])
+		}
+
+		// maps of objects, like the spaces keyed by name, are a row per object
+		rows := make([]json.RawMessage, 0, len(keys))
+		for _, k := range keys {
+			if jsonKind(obj[k]) != '{' {
+				return []json.RawMessage{j}
+			}
+			rows = append(rows, obj[k])
+		}
+
+		return rows
+	case 'n':
+		return nil
+	}
+
+	return []json.RawMessage{j}
+}
+
+// defaultColumns returns a column for each field in the rows, in the order they first appear.  Only
+// scalar fields are included unless wide.
+func defaultColumns(rows []json.RawMessage, wide bool) []tableColumn {
+	columns := []tableColumn{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if jsonKind(row) != '{' {
+			if !seen["."] {
+				seen["."] = true
+				columns = append(columns, tableColumn{"VALUE", ""})
+			}
+			continue
+		}
+
+		obj := map[string]json.RawMessage{}
+		if err := json.Unmarshal(row, &obj); err != nil {
+			continue
+		}
+
+		for _, k := range objectKeys(row) {
+			if seen[k] {
+				continue
+			}
+
+			if kind := jsonKind(obj[k]); !wide && (kind == '{' || kind == '[') {
+				continue
+			}
+
+			seen[k] = true
+			columns = append(columns, tableColumn{columnHeader(k), k})
+		}
+	}
+
+	return columns
+}
+
+// columnHeader converts a json field name like lastStatus or created_at to a header like LAST STATUS
+func columnHeader(field string) string {
+	var b strings.Builder
+	prev := ' '
+	for _, c := range field {
+		switch {
+		case c == '_' || c == '-':
+			c = ' '
+		case unicode.IsUpper(c) && unicode.IsLower(prev):
+			b.WriteRune(' ')
+		}
+		b.WriteRune(unicode.ToUpper(c))
+		prev = c
+	}
+	return b.String()
+}
+
+// tableCell formats a value for a table cell
+func tableCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case []interface{}:
+		// lists of scalars are comma separated
+		cells := make([]string, 0, len(v))
+		for _, e := range v {
+			switch e.(type) {
+			case map[string]interface{}, []interface{}:
+				j, _ := json.Marshal(v)
+				return string(j)
+			}
+			cells = append(cells, tableCell(e))
+		}
+		return strings.Join(cells, ",")
+	}
+
+	j, _ := json.Marshal(v)
+	return string(j)
+}
+
+// lookupField returns the value at the dotted path in the normalized json data
+func lookupField(data interface{}, field string) interface{} {
+	if field == "" {
+		return data
+	}
+
+	for _, f := range strings.Split(field, ".") {
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		data = obj[f]
+	}
+
+	return data
+}
+
+// jsonKind returns the first non-space character of a json value, which determines its kind
+func jsonKind(j []byte) byte {
+	j = bytes.TrimSpace(j)
+	if len(j) == 0 {
+		return 0
+	}
+	return j[0]
+}
+
+// objectKeys returns the keys of a json object in the order they appear
+func objectKeys(j []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(j))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil
+	}
+
+	keys := []string{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		k, ok := t.(string)
+		if !ok {
+			break
+		}
+		keys = append(keys, k)
+
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			break
+		}
+	}
+
+	return keys
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintTable(t *testing.T) {
+	type task struct {
+		ID         string            `json:"id"`
+		LastStatus string            `json:"lastStatus"`
+		CPU        int               `json:"cpu"`
+		Ready      bool              `json:"ready"`
+		IPs        []string          `json:"ips,omitempty"`
+		Tags       map[string]string `json:"tags,omitempty"`
+	}
+
+	tasks := []*task{
+		{ID: "abc", LastStatus: "RUNNING", CPU: 256, Ready: true, IPs: []string{"10.0.0.1", "10.0.0.2"}},
+		{ID: "def", LastStatus: "STOPPED", CPU: 512, Tags: map[string]string{"env": "dev"}},
+	}
+
+	tests := []struct {
+		out      interface{}
+		wide     bool
+		expected string
+	}{
+		{
+			out:  tasks,
+			wide: false,
+			expected: "ID    LAST STATUS   CPU   READY\n" +
+				"abc   RUNNING       256   true\n" +
+				"def   STOPPED       512   false\n",
+		},
+		{
+			out:  tasks,
+			wide: true,
+			expected: "ID    LAST STATUS   CPU   READY   IPS                 TAGS\n" +
+				"abc   RUNNING       256   true    10.0.0.1,10.0.0.2   \n" +
+				"def   STOPPED       512   false                       {\"env\":\"dev\"}\n",
+		},
+		{
+			out:      map[string]interface{}{"tasks": []string{"abc", "def"}},
+			expected: "VALUE\nabc\ndef\n",
+		},
+		{
+			out: map[string]*SecretDetails{"b": {Name: "b"}, "a": {Name: "a"}},
+			expected: "NAME   DESCRIPTION   SPACE\n" +
+				"a                    \n" +
+				"b                    \n",
+		},
+		{
+			out:      nil,
+			expected: "",
+		},
+	}
+
+	for i, test := range tests {
+		out := &bytes.Buffer{}
+		if err := printTable(out, test.out, test.wide); err != nil {
+			t.Errorf("expected nil error for test %d, got %s", i, err)
+			continue
+		}
+
+		if out.String() != test.expected {
+			t.Errorf("expected for test %d\n%q\ngot\n%q", i, test.expected, out.String())
+		}
+	}
+}
+
+func TestColumnHeader(t *testing.T) {
+	tests := map[string]string{
+		"name":              "NAME",
+		"lastStatus":        "LAST STATUS",
+		"created_at":        "CREATED AT",
+		"task-definition":   "TASK DEFINITION",
+		"taskDefinitionArn": "TASK DEFINITION ARN",
+	}
+
+	for field, expected := range tests {
+		if h := columnHeader(field); h != expected {
+			t.Errorf("expected %s for %s, got %s", expected, field, h)
+		}
+	}
+}
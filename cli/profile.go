@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultProfile is the profile stored in the top level url, token and spaces settings
+	defaultProfile = "default"
+
+	// profileEnv selects the profile when --profile isn't passed
+	profileEnv = "SPINUP_PROFILE"
+)
+
+var (
+	profileFlag   string
+	activeProfile = defaultProfile
+)
+
+// profileSettings are the settings that belong to a profile.  They are never inherited from the
+// default profile, so a token can't be sent to the wrong Spinup instance.
+var profileSettings = []string{"url", "token", "token_ref", "spaces", "auth_url", "token_url", "device_auth_url", "client_id", "scopes"}
+
+var validProfileName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "The configuration profile to use (default is $"+profileEnv+" or the current profile)")
+
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileUseCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+}
+
+// resolveProfile returns the profile selected by the --profile flag, the SPINUP_PROFILE environment
+// variable or the current_profile setting, in that order
+func resolveProfile() (string, error) {
+	name := profileFlag
+	if name == "" {
+		name = os.Getenv(profileEnv)
+	}
+
+	if name == "" {
+		name = viper.GetString("current_profile")
+	}
+
+	if name == "" {
+		return defaultProfile, nil
+	}
+
+	if !validProfileName.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q, only letters, numbers, '-' and '_' are allowed", name)
+	}
+
+	// viper keys are case insensitive
+	return strings.ToLower(name), nil
+}
+
+// profileExists returns true if the named profile has been configured
+func profileExists(name string) bool {
+	return name == defaultProfile || viper.IsSet("profiles."+name)
+}
+
+// profileKey returns the viper key for a setting in the active profile.  Flags take precedence over
+// the profile, and settings that don't belong to a profile fall back to the top level.
+func profileKey(cmd *cobra.Command, key string) string {
+	if f := cmd.Flags().Lookup(key); f != nil && f.Changed {
+		return key
+	}
+
+	if activeProfile == defaultProfile {
+		return key
+	}
+
+	pkey := "profiles." + activeProfile + "." + key
+	for _, s := range profileSettings {
+		if s == key {
+			return pkey
+		}
+	}
+
+	if viper.IsSet(pkey) {
+		return pkey
+	}
+
+	return key
+}
+
+// configFilePath returns the path of the config file in use, or the default config file
+func configFilePath() (string, error) {
+	if f := viper.ConfigFileUsed(); f != "" {
+		return f, nil
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".spinup.json"), nil
+}
+
+// updateConfig reads the config file, applies the update and writes it back.  Only the settings in the
+// file are written, not the flags or defaults.
+func updateConfig(update func(cfg map[string]interface{}) error) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+
+	cfg := map[string]interface{}{}
+	if _, err := os.Stat(path); err == nil {
+		r := viper.New()
+		r.SetConfigFile(path)
+		if err := r.ReadInConfig(); err != nil {
+			return fmt.Errorf("failed to read config file %s: %s", path, err)
+		}
+		cfg = r.AllSettings()
+	}
+
+	if err := update(cfg); err != nil {
+		return err
+	}
+
+	// write with a fresh viper so removed settings don't linger
+	w := viper.New()
+	w.SetConfigFile(path)
+	if err := w.MergeConfigMap(cfg); err != nil {
+		return err
+	}
+
+	log.Debugf("writing config file %s", path)
+
+	if err := w.WriteConfig(); err != nil {
+		return err
+	}
+
+	// config files hold tokens, don't leave them readable by others
+	return os.Chmod(path, 0600)
+}
+
+// configProfiles returns the profiles section of the config, creating it if necessary
+func configProfiles(cfg map[string]interface{}) map[string]interface{} {
+	profiles, ok := cfg["profiles"].(map[string]interface{})
+	if !ok {
+		profiles = map[string]interface{}{}
+		cfg["profiles"] = profiles
+	}
+	return profiles
+}
+
+// maskToken hides all but the ends of a token
+func maskToken(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	if len(token) <= 12 {
+		return "********"
+	}
+
+	return token[:4] + "********" + token[len(token)-4:]
+}
+
+// ProfileSummary is the output for a configured profile, Current is true for the active profile
+type ProfileSummary struct {
+	Name    string   `json:"name"`
+	Current bool     `json:"current"`
+	URL     string   `json:"url"`
+	Spaces  []string `json:"spaces"`
+}
+
+func (p *ProfileSummary) tableColumns(wide bool) []tableColumn {
+	return []tableColumn{
+		{"NAME", "name"},
+		{"CURRENT", "current"},
+		{"URL", "url"},
+		{"SPACES", "spaces"},
+	}
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage the configuration profiles for different Spinup instances and tokens",
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Set the current profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.ToLower(args[0])
+		if !profileExists(name) {
+			return fmt.Errorf("profile %s not found, create it with `spinup configure --profile %s`", name, name)
+		}
+
+		if err := updateConfig(func(cfg map[string]interface{}) error {
+			if name == defaultProfile {
+				delete(cfg, "current_profile")
+			} else {
+				cfg["current_profile"] = name
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "switched to profile %s\n", name)
+		return nil
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured profiles",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the active profile, which may have been selected with --profile or SPINUP_PROFILE
+		current := activeProfile
+
+		out := []*ProfileSummary{{
+			Name:    defaultProfile,
+			Current: current == defaultProfile,
+			URL:     viper.GetString("url"),
+			Spaces:  viper.GetStringSlice("spaces"),
+		}}
+
+		names := []string{}
+		for name := range viper.GetStringMap("profiles") {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			out = append(out, &ProfileSummary{
+				Name:    name,
+				Current: current == name,
+				URL:     viper.GetString("profiles." + name + ".url"),
+				Spaces:  viper.GetStringSlice("profiles." + name + ".spaces"),
+			})
+		}
+
+		return formatOutput(out)
+	},
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.ToLower(args[0])
+		if name == defaultProfile {
+			return errors.New("the default profile can't be deleted")
+		}
+
+		if !profileExists(name) {
+			return fmt.Errorf("profile %s not found", name)
+		}
+
+		if ref := viper.GetString("profiles." + name + ".token_ref"); ref != "" {
+			if err := deleteToken(ref); err != nil {
+				log.Warnf("failed to delete the token for profile %s from %s: %s", name, ref, err)
+			}
+		}
+
+		if err := updateConfig(func(cfg map[string]interface{}) error {
+			delete(configProfiles(cfg), name)
+			if cfg["current_profile"] == name {
+				log.Warnf("deleted the current profile, switching to the %s profile", defaultProfile)
+				delete(cfg, "current_profile")
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "deleted profile %s\n", name)
+		return nil
+	},
+}
@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/journal"
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	homedir "github.com/mitchellh/go-homedir"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	rolloutToRevision int64
+	rolloutWait       bool
+	rolloutTimeout    time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(rolloutCmd)
+
+	rolloutCmd.AddCommand(rolloutHistoryCmd)
+	rolloutHistoryCmd.AddCommand(rolloutHistoryContainerCmd)
+
+	rolloutCmd.AddCommand(rolloutUndoCmd)
+	rolloutUndoCmd.AddCommand(rolloutUndoContainerCmd)
+	rolloutUndoContainerCmd.Flags().Int64Var(&rolloutToRevision, "to-revision", 0, "The revision to roll back to (default is the revision before the current one)")
+	rolloutUndoContainerCmd.Flags().BoolVarP(&rolloutWait, "wait", "w", false, "Wait for the deployment to complete, following its events and tasks")
+	rolloutUndoContainerCmd.Flags().DurationVar(&rolloutTimeout, "timeout", 10*time.Minute, "How long to wait for the deployment")
+}
+
+// RolloutRevision is a revision in the rollout history of a container service
+type RolloutRevision struct {
+	Revision          int64    `json:"revision"`
+	Current           bool     `json:"current"`
+	Images            []string `json:"images"`
+	RecordedAt        string   `json:"recordedAt"`
+	Source            string   `json:"source"`
+	TaskDefinitionArn string   `json:"taskDefinitionArn,omitempty"`
+}
+
+func (r *RolloutRevision) tableColumns(wide bool) []tableColumn {
+	columns := []tableColumn{
+		{"REVISION", "revision"},
+		{"CURRENT", "current"},
+		{"IMAGES", "images"},
+		{"RECORDED", "recordedAt"},
+		{"SOURCE", "source"},
+	}
+
+	if wide {
+		columns = append(columns, tableColumn{"ARN", "taskDefinitionArn"})
+	}
+
+	return columns
+}
+
+var rolloutCmd = &cobra.Command{
+	Use:   "rollout",
+	Short: "Show the history of a resource and roll it back",
+	Long: `Show the deployment history of a resource and roll it back to an earlier revision.
+
+The history is kept in a local deployment journal (~/.spinup-deployments.json, or the deployment_journal
+setting).  Spinup only returns the current revision of a container service, so the journal records each
+revision this cli deploys or sees, with the container definitions needed to deploy it again.`,
+}
+
+var rolloutHistoryCmd = &cobra.Command{
+	Use:   "history [type] [space]/[resource]",
+	Short: "List the revisions of a resource",
+}
+
+var rolloutUndoCmd = &cobra.Command{
+	Use:   "undo [type] [space]/[resource]",
+	Short: "Roll a resource back to an earlier revision",
+}
+
+var rolloutHistoryContainerCmd = &cobra.Command{
+	Use:   "container [space]/[resource]",
+	Short: "List the task definition revisions of a container service, newest first",
+	Example: `  spinup rollout history container my-space/my-service -o table
+  spinup rollout history container my-service -o wide`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		params, err := parseResourceParams(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return err
+		}
+		recordRevision(params, info, journal.SourceObserved)
+
+		history, err := revisionHistory(params)
+		if err != nil {
+			return err
+		}
+
+		out := make([]*RolloutRevision, 0, len(history))
+		for i := len(history) - 1; i >= 0; i-- {
+			r := history[i]
+
+			images := make([]string, 0, len(r.Images))
+			for _, name := range sortedMapKeys(r.Images) {
+				images = append(images, name+"="+r.Images[name])
+			}
+
+			out = append(out, &RolloutRevision{
+				Revision:          r.Revision,
+				Current:           r.Revision == info.TaskDefinition.Revision,
+				Images:            images,
+				RecordedAt:        r.RecordedAt.Local().Format(time.RFC3339),
+				Source:            r.Source,
+				TaskDefinitionArn: r.TaskDefinitionArn,
+			})
+		}
+
+		return formatOutput(out)
+	},
+}
+
+var rolloutUndoContainerCmd = &cobra.Command{
+	Use:   "container [space]/[resource]",
+	Short: "Redeploy an earlier task definition revision of a container service",
+	Long: `Redeploy the container definitions and volumes of an earlier task definition revision from the
+deployment journal, the revision before the current one by default.  The desired count and capacity
+providers aren't changed.  Spinup registers the definitions as a new revision, which can be undone in
+turn.`,
+	Example: `  spinup rollout undo container my-space/my-service
+  spinup rollout undo container my-space/my-service --to-revision 12 --wait`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		params, err := parseResourceParams(ctx, args)
+		if err != nil {
+			return err
+		}
+
+		resource := &spinup.Resource{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, resource); err != nil {
+			return err
+		}
+
+		if !isContainerService(resource) {
+			return fmt.Errorf("%s/%s is not a container service", params["space"], params["name"])
+		}
+
+		info := &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return err
+		}
+		recordRevision(params, info, journal.SourceObserved)
+
+		history, err := revisionHistory(params)
+		if err != nil {
+			return err
+		}
+
+		target, err := undoRevision(params, history, info.TaskDefinition.Revision, rolloutToRevision)
+		if err != nil {
+			return err
+		}
+
+		// only the definitions are rolled back, the service keeps its count and capacity
+		rollback := &spinup.ContainerService{
+			CapacityProviderStrategy: info.CapacityProviderStrategy,
+			DesiredCount:             info.DesiredCount,
+		}
+		rollback.TaskDefinition.ContainerDefinitions = target.ContainerDefinitions
+		rollback.TaskDefinition.Volumes = target.Volumes
+
+		fields, err := manifest.DiffServiceSpec(serviceSpec(info, nil), serviceSpec(rollback, nil))
+		if err != nil {
+			return err
+		}
+
+		color, err := colorEnabled("auto", os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "rolling back %s/%s from revision %d to revision %d\n", params["space"], params["name"], info.TaskDefinition.Revision, target.Revision)
+		writeChange(os.Stderr, &manifest.Change{Action: manifest.ActionUpdate, Kind: manifest.KindContainer, Name: params["name"], Fields: fields}, color)
+
+		var tracker *deploymentTracker
+		if rolloutWait {
+			if tracker, err = newDeploymentTracker(ctx, params, true, true); err != nil {
+				return err
+			}
+		}
+
+		input, err := json.Marshal(spinup.ContainerServiceInput{
+			ForceRedeploy: true,
+			Size:          resource.SizeID,
+			Service: &spinup.ContainerServiceDefinitionInput{
+				CapacityProviderStrategy: capacityProviderStrategy("", info.CapacityProviderStrategy),
+				ContainerDefinitions:     target.ContainerDefinitions,
+				DesiredCount:             info.DesiredCount,
+				PlatformVersion:          "LATEST",
+				Volumes:                  target.Volumes,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		log.Debugf("putting input: %s", string(input))
+
+		updatedInfo := &spinup.ContainerService{}
+		if err := SpinupClient.PutResourceCtx(ctx, params, input, updatedInfo); err != nil {
+			return err
+		}
+		recordDeployed(ctx, params, fmt.Sprintf("%s to revision %d", cmd.CommandPath(), target.Revision))
+
+		if tracker != nil {
+			if err := tracker.wait(ctx, rolloutTimeout); err != nil {
+				return err
+			}
+			updatedInfo = &spinup.ContainerService{}
+		}
+
+		out, err := updatedContainer(ctx, params, resource, updatedInfo)
+		if err != nil {
+			return err
+		}
+
+		return formatOutput(out)
+	},
+}
+
+// undoRevision returns the revision to roll back to from the history, the revision passed or the latest
+// revision before the current one
+func undoRevision(params map[string]string, history []*journal.Revision, current, to int64) (*journal.Revision, error) {
+	name := params["space"] + "/" + params["name"]
+
+	if to != 0 {
+		if to == current {
+			return nil, fmt.Errorf("revision %d is already the current revision of %s", to, name)
+		}
+
+		for _, r := range history {
+			if r.Revision == to {
+				return r, nil
+			}
+		}
+
+		return nil, fmt.Errorf("revision %d of %s isn't in the deployment journal, see spinup rollout history container %s", to, name, name)
+	}
+
+	candidates := make([]*journal.Revision, 0, len(history))
+	for _, r := range history {
+		if r.Revision < current {
+			candidates = append(candidates, r)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no revision of %s before revision %d in the deployment journal", name, current)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Revision < candidates[j].Revision })
+	return candidates[len(candidates)-1], nil
+}
+
+// deploymentJournal returns the journal of deployed container service revisions
+func deploymentJournal() (*journal.Journal, error) {
+	if f := viper.GetString("deployment_journal"); f != "" {
+		path, err := homedir.Expand(f)
+		if err != nil {
+			return nil, err
+		}
+		return &journal.Journal{Path: path}, nil
+	}
+
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	return &journal.Journal{Path: filepath.Join(home, ".spinup-deployments.json")}, nil
+}
+
+// revisionHistory returns the revisions of a container service in the deployment journal, oldest first
+func revisionHistory(params map[string]string) ([]*journal.Revision, error) {
+	j, err := deploymentJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	return j.History(journal.Key(SpinupClient.BaseURL, params["space"], params["name"]))
+}
+
+// recordRevision records the current task definition revision of a container service in the deployment
+// journal.  The journal is best effort, failures are logged and don't fail the command.
+func recordRevision(params map[string]string, info *spinup.ContainerService, source string) {
+	j, err := deploymentJournal()
+	if err != nil {
+		log.Warnf("failed to open the deployment journal: %s", err)
+		return
+	}
+
+	added, err := j.Record(journal.Key(SpinupClient.BaseURL, params["space"], params["name"]), journal.NewRevision(info, source))
+	if err != nil {
+		log.Warnf("failed to record revision %d of %s/%s: %s", info.TaskDefinition.Revision, params["space"], params["name"], err)
+		return
+	}
+
+	if added {
+		log.Debugf("recorded revision %d of %s/%s from %s", info.TaskDefinition.Revision, params["space"], params["name"], source)
+	}
+}
+
+// recordDeployed records the revision deployed by a command, after the update is accepted
+func recordDeployed(ctx context.Context, params map[string]string, source string) {
+	info := &spinup.ContainerService{}
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		log.Warnf("failed to get the deployed revision of %s/%s: %s", params["space"], params["name"], err)
+		return
+	}
+
+	recordRevision(params, info, source)
+}
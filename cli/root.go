@@ -17,8 +17,13 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
 	log "github.com/sirupsen/logrus"
@@ -36,13 +41,21 @@ var (
 	verbose      bool
 	SpinupClient *spinup.Client
 	spinupSpaces []string
+	retries      int
+	retryWaitMin time.Duration
+	retryWaitMax time.Duration
+	parallelism  int
 )
 
 // rootCmd represents the base command when called without any subcommands, it propogates the configuration items from the config file.
 var rootCmd = &cobra.Command{
-	Use:   "spinup ",
-	Short: "A small CLI for interacting with Yale's Spinup service",
+	Use:           "spinup ",
+	Short:         "A small CLI for interacting with Yale's Spinup service",
+	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// flags have been parsed, don't print the usage for errors returned from the api
+		cmd.SilenceUsage = true
+
 		if debug {
 			log.SetLevel(log.DebugLevel)
 		} else if verbose {
@@ -53,18 +66,64 @@ var rootCmd = &cobra.Command{
 
 		log.Debug("running root level prerun")
 
-		spinupURL = viper.GetString("url")
-		spinupToken = viper.GetString("token")
-		spinupSpaces = viper.GetStringSlice("spaces")
+		profile, err := resolveProfile()
+		if err != nil {
+			return err
+		}
+		activeProfile = profile
+
+		log.Debugf("using profile %s", activeProfile)
+
+		spinupURL = viper.GetString(profileKey(cmd, "url"))
+		spinupToken = viper.GetString(profileKey(cmd, "token"))
+		spinupSpaces = viper.GetStringSlice(profileKey(cmd, "spaces"))
+		retries = viper.GetInt(profileKey(cmd, "retries"))
+		retryWaitMin = viper.GetDuration(profileKey(cmd, "retry_wait_min"))
+		retryWaitMax = viper.GetDuration(profileKey(cmd, "retry_wait_max"))
+		parallelism = viper.GetInt(profileKey(cmd, "parallelism"))
+		outputFormat = viper.GetString(profileKey(cmd, "output"))
+		tokenExpiryWarning = viper.GetDuration(profileKey(cmd, "token_expiry_warning"))
+
+		// catch unknown formats and broken templates before making any requests
+		if _, err := parseOutputFormat(outputFormat); err != nil {
+			return err
+		}
 
 		log.Debugf("command: %+v, args: %+v", cmd, args)
 
-		called := cmd.CalledAs()
-		if called != "version" && called != "help" && called != "configure" {
+		// the command name rather than the name it was called as, so aliases like config are skipped too
+		name := cmd.Name()
+		if name != "version" && name != "help" && name != "configure" && name != "login" && cmd.Parent() != profileCmd {
+			if !profileExists(activeProfile) {
+				return fmt.Errorf("profile %s not found, create it with `spinup configure --profile %s`", activeProfile, activeProfile)
+			}
+
+			// the token flag or a plaintext token take precedence over the credential store
+			tokenSource = "config"
+			if f := cmd.Flags().Lookup("token"); f != nil && f.Changed {
+				tokenSource = "flag"
+			}
+
+			if spinupToken == "" {
+				if ref := viper.GetString(profileKey(cmd, "token_ref")); ref != "" {
+					token, err := loadToken(cmd, ref)
+					if err != nil {
+						return err
+					}
+					spinupToken = token
+					tokenSource = ref
+				}
+			}
+
+			// auth commands inspect the token themselves, an expired token shouldn't stop them
+			if cmd.Parent() == authCmd {
+				return nil
+			}
+
 			log.Debug("initializaing client from execute()")
 
 			if err := initClient(); err != nil {
-				log.Fatalf("failed to create client: %s", err)
+				return fmt.Errorf("failed to create client: %w", err)
 			}
 		}
 
@@ -76,8 +135,22 @@ var rootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	log.Debug("executing root command")
-	if err := rootCmd.Execute(); err != nil {
-		log.Fatalf("failed to execute command: %s", err)
+
+	// cancel any in-flight requests when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		stop()
+
+		msg, code := errorExit(err)
+		if msg != "" {
+			log.Errorf("failed to execute command: %s", msg)
+		}
+		os.Exit(code)
 	}
 }
 
@@ -89,6 +162,9 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "", false, "Enable debug logging")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
 	rootCmd.PersistentFlags().StringSliceVarP(&spinupSpaces, "spaces", "s", nil, "Default Space(s)")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", spinup.DefaultRetryPolicy.MaxRetries, "Number of times to retry requests that fail with transient errors")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "json", "Output format, one of: "+strings.Join(outputFormats, ", "))
+	rootCmd.PersistentFlags().IntVar(&parallelism, "parallelism", spinup.DefaultConcurrency, "Maximum number of concurrent requests when looking up details")
 
 	log.Debug("viper binding flags")
 
@@ -96,6 +172,9 @@ func init() {
 		"url",
 		"token",
 		"spaces",
+		"retries",
+		"parallelism",
+		"output",
 	}
 
 	for _, b := range bflags {
@@ -104,6 +183,10 @@ func init() {
 		}
 	}
 
+	viper.SetDefault("retry_wait_min", spinup.DefaultRetryPolicy.MinWait)
+	viper.SetDefault("retry_wait_max", spinup.DefaultRetryPolicy.MaxWait)
+	viper.SetDefault("token_expiry_warning", defaultTokenExpiryWarning)
+
 	log.Debug("initializing configuration")
 
 	cobra.OnInitialize(initConfig)
@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	secretsFile          string
+	secretsPrefix        string
+	secretsDryRun        bool
+	secretsDeleteMissing bool
+	secretsFormat        string
+	secretsReveal        bool
+)
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.PersistentFlags().StringVar(&secretsPrefix, "prefix", "", "Prefix prepended to each key to make the secret name (eg. myapp/)")
+
+	secretsCmd.AddCommand(secretsImportCmd)
+	secretsImportCmd.Flags().StringVarP(&secretsFile, "file", "f", "", "The dotenv file to import ('-' for stdin)")
+	secretsImportCmd.Flags().BoolVar(&secretsDryRun, "dry-run", false, "Show the secrets that would be added, changed and removed without changing anything")
+	secretsImportCmd.Flags().BoolVar(&secretsDeleteMissing, "delete-missing", false, "Delete secrets with the prefix that aren't in the file")
+
+	secretsCmd.AddCommand(secretsExportCmd)
+	secretsExportCmd.Flags().StringVarP(&secretsFile, "file", "f", "", "The file to write (default is stdout)")
+	secretsExportCmd.Flags().StringVar(&secretsFormat, "format", "dotenv", "The export format (dotenv or json)")
+	secretsExportCmd.Flags().BoolVar(&secretsReveal, "reveal", false, "Include the secret values in the export")
+}
+
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Bulk import and export the secrets in a space",
+}
+
+// SecretChange is a planned change to a secret from an import
+type SecretChange struct {
+	Action string `json:"action"`
+	Name   string `json:"name"`
+	Key    string `json:"key,omitempty"`
+}
+
+const (
+	secretCreate    = "create"
+	secretUpdate    = "update"
+	secretUnchanged = "unchanged"
+	secretRemove    = "remove"
+)
+
+// singleSpace returns the space from the args or the default spaces, requiring exactly one
+func singleSpace(args []string) (string, error) {
+	spaces, err := parseSpaceInput(args)
+	if err != nil {
+		return "", err
+	}
+
+	if len(spaces) != 1 {
+		return "", errors.New("exactly one space is required")
+	}
+
+	return spaces[0], nil
+}
+
+var secretsImportCmd = &cobra.Command{
+	Use:   "import [space]",
+	Short: "Create or update one secret per key in a dotenv file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		space, err := singleSpace(args)
+		if err != nil {
+			return err
+		}
+
+		if secretsFile == "" {
+			return errors.New("a dotenv file is required (--file)")
+		}
+
+		if secretsDeleteMissing && secretsPrefix == "" {
+			return errors.New("--delete-missing requires a --prefix to limit the secrets that may be removed")
+		}
+
+		var in io.Reader = os.Stdin
+		if secretsFile != "-" {
+			f, err := os.Open(filepath.Clean(secretsFile))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		entries, err := parseDotEnv(in)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %s", secretsFile, err)
+		}
+
+		for _, e := range entries {
+			if len(e.Value) == 0 {
+				return fmt.Errorf("key %s has an empty value", e.Key)
+			}
+
+			if len(e.Value) > maxSecretSize {
+				return fmt.Errorf("value for key %s is greater than 4KB", e.Key)
+			}
+		}
+
+		log.Infof("importing %d secrets into space %s", len(entries), space)
+
+		changes, err := planSecretImport(ctx, space, entries)
+		if err != nil {
+			return err
+		}
+
+		if secretsDryRun {
+			return formatOutput(changes)
+		}
+
+		byKey := map[string]*DotEnvEntry{}
+		for _, e := range entries {
+			byKey[e.Key] = e
+		}
+
+		for _, c := range changes {
+			if err := applySecretChange(ctx, space, c, byKey[c.Key]); err != nil {
+				return fmt.Errorf("failed to %s secret %s: %s", c.Action, c.Name, err)
+			}
+		}
+
+		return formatOutput(changes)
+	},
+}
+
+// planSecretImport compares the entries with the secrets in the space and returns the changes needed
+func planSecretImport(ctx context.Context, space string, entries []*DotEnvEntry) ([]*SecretChange, error) {
+	secrets, err := spaceSecrets(ctx, map[string]string{"space": space})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]*spinup.Secret{}
+	for _, s := range secrets {
+		existing[s.Name] = s
+	}
+
+	changes := []*SecretChange{}
+	inFile := map[string]bool{}
+	for _, e := range entries {
+		name := secretsPrefix + e.Key
+		inFile[name] = true
+
+		current, ok := existing[name]
+		if !ok {
+			changes = append(changes, &SecretChange{Action: secretCreate, Name: name, Key: e.Key})
+			continue
+		}
+
+		action := secretUpdate
+		if e.Description == "" || e.Description == current.Description {
+			value, err := SpinupClient.SecretValueCtx(ctx, space, name)
+			switch {
+			case err != nil:
+				log.Warnf("unable to read the current value of %s, it will be updated: %s", name, err)
+			case value.Value == e.Value:
+				action = secretUnchanged
+			}
+		}
+
+		changes = append(changes, &SecretChange{Action: action, Name: name, Key: e.Key})
+	}
+
+	if secretsDeleteMissing {
+		names := []string{}
+		for name := range existing {
+			if strings.HasPrefix(name, secretsPrefix) && !inFile[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			changes = append(changes, &SecretChange{Action: secretRemove, Name: name})
+		}
+	}
+
+	return changes, nil
+}
+
+// applySecretChange creates, updates or removes a secret
+func applySecretChange(ctx context.Context, space string, c *SecretChange, e *DotEnvEntry) error {
+	params := map[string]string{"space": space, "secretname": c.Name}
+
+	switch c.Action {
+	case secretCreate:
+		log.Infof("creating secret %s", c.Name)
+
+		input, err := json.Marshal(spinup.SecretInput{Name: c.Name, Value: e.Value, Description: e.Description})
+		if err != nil {
+			return err
+		}
+		return SpinupClient.PostResourceCtx(ctx, params, input, &spinup.CreateSecretOutput{})
+	case secretUpdate:
+		log.Infof("updating secret %s", c.Name)
+
+		input, err := json.Marshal(spinup.SecretUpdateInput{Value: e.Value, Description: e.Description})
+		if err != nil {
+			return err
+		}
+		return SpinupClient.PutResourceCtx(ctx, params, input, &spinup.Secret{})
+	case secretRemove:
+		log.Infof("removing secret %s", c.Name)
+		return SpinupClient.DeleteResourceCtx(ctx, params, &spinup.Secret{})
+	}
+
+	log.Debugf("secret %s is unchanged", c.Name)
+	return nil
+}
+
+var secretsExportCmd = &cobra.Command{
+	Use:   "export [space]",
+	Short: "Export the names and descriptions of the secrets in a space to a dotenv or json file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		space, err := singleSpace(args)
+		if err != nil {
+			return err
+		}
+
+		if secretsFormat != "dotenv" && secretsFormat != "json" {
+			return fmt.Errorf("unsupported export format %s, expected dotenv or json", secretsFormat)
+		}
+
+		secrets, err := spaceSecrets(ctx, map[string]string{"space": space})
+		if err != nil {
+			return err
+		}
+
+		entries := []*DotEnvEntry{}
+		for _, s := range secrets {
+			if !strings.HasPrefix(s.Name, secretsPrefix) {
+				continue
+			}
+
+			entries = append(entries, &DotEnvEntry{
+				Key:         strings.TrimPrefix(s.Name, secretsPrefix),
+				Description: s.Description,
+			})
+		}
+
+		if secretsReveal {
+			if err := SpinupClient.Parallel(ctx, len(entries), func(ctx context.Context, i int) error {
+				value, err := SpinupClient.SecretValueCtx(ctx, space, secretsPrefix+entries[i].Key)
+				if err != nil {
+					return err
+				}
+				entries[i].Value = value.Value
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+
+		var out io.Writer = os.Stdout
+		if secretsFile != "" && secretsFile != "-" {
+			// the export may contain secret values, don't make it readable by others
+			f, err := os.OpenFile(filepath.Clean(secretsFile), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if secretsFormat == "json" {
+			j, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(out, string(j))
+			return err
+		}
+
+		return writeDotEnv(out, entries)
+	},
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/term"
+)
+
+// watchTerminalSize calls fn with the new size of the terminal each time it's resized, until the
+// context is done
+func watchTerminalSize(ctx context.Context, fd int, fn func(width, height int)) {
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+
+	go func() {
+		defer signal.Stop(resized)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-resized:
+				if width, height, err := term.GetSize(fd); err == nil {
+					fn(width, height)
+				}
+			}
+		}
+	}()
+}
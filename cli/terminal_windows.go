@@ -0,0 +1,35 @@
+//go:build windows
+
+package cli
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// watchTerminalSize calls fn with the new size of the terminal each time it's resized, until the
+// context is done.  Windows doesn't signal resizes, so the size is polled.
+func watchTerminalSize(ctx context.Context, fd int, fn func(width, height int)) {
+	width, height, _ := term.GetSize(fd)
+
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w, h, err := term.GetSize(fd)
+				if err != nil || (w == width && h == height) {
+					continue
+				}
+				width, height = w, h
+				fn(width, height)
+			}
+		}
+	}()
+}
@@ -1,22 +1,33 @@
 package cli
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/YaleSpinup/spinup-cli/pkg/journal"
+	"github.com/YaleSpinup/spinup-cli/pkg/manifest"
 	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 var (
-	redeployContainerCmd bool
-	scaleContainerCmd    int64
-	containerNameCmd     string
-	containerTagCmd      string
+	redeployContainerCmd    bool
+	scaleContainerCmd       int64
+	containerNameCmd        string
+	containerTagCmd         string
+	updateContainerWait     bool
+	updateContainerTimeout  time.Duration
+	containerEnvCmd         []string
+	containerUnsetEnvCmd    []string
+	containerSecretCmd      []string
+	containerUnsetSecretCmd []string
 )
 
 func init() {
@@ -25,6 +36,12 @@ func init() {
 	updateContainerCmd.PersistentFlags().Int64Var(&scaleContainerCmd, "scale", 0, "Scale the container service")
 	updateContainerCmd.PersistentFlags().StringVar(&containerNameCmd, "container", "", "The name of the container to update")
 	updateContainerCmd.PersistentFlags().StringVar(&containerTagCmd, "tag", "", "The new image tag for the container")
+	updateContainerCmd.PersistentFlags().StringArrayVar(&containerEnvCmd, "env", nil, "Set an environment variable in the container, as KEY=VALUE (can be repeated)")
+	updateContainerCmd.PersistentFlags().StringArrayVar(&containerUnsetEnvCmd, "unset-env", nil, "Remove an environment variable from the container (can be repeated)")
+	updateContainerCmd.PersistentFlags().StringArrayVar(&containerSecretCmd, "secret", nil, "Set an environment variable in the container from a secret in the space, as ENV_NAME=secret-name (can be repeated)")
+	updateContainerCmd.PersistentFlags().StringArrayVar(&containerUnsetSecretCmd, "unset-secret", nil, "Remove an environment variable set from a secret from the container (can be repeated)")
+	updateContainerCmd.PersistentFlags().BoolVarP(&updateContainerWait, "wait", "w", false, "Wait for the deployment to complete, following its events and tasks")
+	updateContainerCmd.PersistentFlags().DurationVar(&updateContainerTimeout, "timeout", 10*time.Minute, "How long to wait for the deployment")
 }
 
 var updateContainerCmd = &cobra.Command{
@@ -34,55 +51,151 @@ var updateContainerCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Infof("update container: %+v", args)
 
+		ctx := cmd.Context()
+
 		if updateResource == nil {
 			return errors.New("no resource provided")
 		}
 
-		var j []byte
+		var j interface{}
 		var err error
 
+		updateTag := cmd.Flags().Changed("container") && cmd.Flags().Changed("tag")
+		updateEnv := cmd.Flags().Changed("env") || cmd.Flags().Changed("unset-env") || cmd.Flags().Changed("secret") || cmd.Flags().Changed("unset-secret")
+
+		var edit *containerEdit
+		if updateTag || updateEnv {
+			if containerNameCmd == "" {
+				return errors.New("--container must be specified to update the environment or secrets of a container")
+			}
+
+			tag := ""
+			if updateTag {
+				tag = containerTagCmd
+			}
+
+			if edit, err = newContainerEdit(containerNameCmd, tag, containerEnvCmd, containerUnsetEnvCmd, containerSecretCmd, containerUnsetSecretCmd); err != nil {
+				return err
+			}
+
+			// scale in the same update, the desired count is part of the definitions that are put
+			if cmd.Flags().Changed("scale") {
+				edit.Scale = &scaleContainerCmd
+			}
+
+			if err := edit.plan(ctx, updateParams, updateResource); err != nil {
+				return err
+			}
+
+			color, err := colorEnabled("auto", os.Stderr)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case edit.changed():
+				writeChange(os.Stderr, edit.change, color)
+			case edit.Tag != "":
+				fmt.Fprintf(os.Stderr, "container %s already uses tag %s, redeploying to pull the image again\n", containerNameCmd, edit.Tag)
+			default:
+				fmt.Fprintf(os.Stderr, "no changes to container %s\n", containerNameCmd)
+			}
+		}
+
+		// a tag is always deployed so an updated image with the same tag is pulled, like a ci pipeline
+		// pushing latest, other edits only when they change something
+		deploy := edit != nil && (edit.Tag != "" || edit.changed())
+
+		var tracker *deploymentTracker
+		if updateContainerWait && (deploy || cmd.Flags().Changed("scale") || redeployContainerCmd) {
+			// a deployed edit registers a new task definition, redeploys replace the running tasks
+			if tracker, err = newDeploymentTracker(ctx, updateParams, deploy, deploy || redeployContainerCmd); err != nil {
+				return err
+			}
+		}
+
 		// Check if container update flags are set
-		if cmd.Flags().Changed("container") && cmd.Flags().Changed("tag") {
-			if j, err = updateContainerImageTag(updateParams, updateResource, containerNameCmd, containerTagCmd, redeployContainerCmd); err != nil {
+		if deploy {
+			if j, err = edit.apply(ctx, updateParams, updateResource); err != nil {
 				return err
 			}
+			recordDeployed(ctx, updateParams, cmd.CommandPath())
 		} else if cmd.Flags().Changed("scale") {
-			if j, err = scaleContainer(updateParams, updateResource, scaleContainerCmd, redeployContainerCmd); err != nil {
+			if j, err = scaleContainer(ctx, updateParams, updateResource, scaleContainerCmd, redeployContainerCmd); err != nil {
 				return err
 			}
 		} else if redeployContainerCmd {
-			if j, err = redeployContainer(updateParams, updateResource); err != nil {
+			if j, err = redeployContainer(ctx, updateParams, updateResource); err != nil {
+				return err
+			}
+		} else if edit != nil {
+			if j, err = updatedContainer(ctx, updateParams, updateResource, edit.info); err != nil {
 				return err
 			}
 		} else if cmd.Flags().Changed("container") || cmd.Flags().Changed("tag") {
 			return errors.New("both --container and --tag must be specified to update the container image")
 		}
 
-		f := bufio.NewWriter(os.Stdout)
-		defer f.Flush()
-		f.Write(j)
+		// nothing was updated
+		if j == nil {
+			return nil
+		}
+
+		if tracker != nil {
+			if err := tracker.wait(ctx, updateContainerTimeout); err != nil {
+				return err
+			}
 
-		return nil
+			// the state after the deployment, rather than when it was accepted
+			if j, err = updatedContainer(ctx, updateParams, updateResource, &spinup.ContainerService{}); err != nil {
+				return err
+			}
+		}
+
+		return formatOutput(j)
 	},
 }
 
-func redeployContainer(params map[string]string, resource *spinup.Resource) ([]byte, error) {
+// updatedContainer returns the state of a container service after an update, in the same format as the
+// get container command.  If the api accepted the update without returning the service, it's fetched again.
+func updatedContainer(ctx context.Context, params map[string]string, resource *spinup.Resource, info *spinup.ContainerService) (interface{}, error) {
+	if info.Job != nil {
+		fmt.Fprintf(os.Stderr, "update accepted as job %s %s\n", info.Job.ID, info.Job.Location)
+	}
+
+	if info.ServiceArn == "" {
+		log.Debug("update didn't return the container service, getting current state")
+
+		info = &spinup.ContainerService{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+			return nil, err
+		}
+	}
+
+	if detailedUpdateCmd {
+		return containerServiceDetails(ctx, params, resource, info)
+	}
+
+	return containerSummary(ctx, resource, info)
+}
+
+func redeployContainer(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
 	input, err := json.Marshal(map[string]bool{"only_redeploy": true})
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	log.Debugf("putting input: %s", string(input))
 
 	info := &spinup.ContainerService{}
-	if err = SpinupClient.PutResource(params, input, info); err != nil {
-		return []byte{}, err
+	if err = SpinupClient.PutResourceCtx(ctx, params, input, info); err != nil {
+		return nil, err
 	}
 
-	return []byte("OK\n"), nil
+	return updatedContainer(ctx, params, resource, info)
 }
 
-func scaleContainer(params map[string]string, resource *spinup.Resource, scale int64, force bool) ([]byte, error) {
+func scaleContainer(ctx context.Context, params map[string]string, resource *spinup.Resource, scale int64, force bool) (interface{}, error) {
 	log.Infof("scaling container service to %d", scale)
 
 	input, err := json.Marshal(spinup.ContainerServiceWrapperUpdateInput{
@@ -101,87 +214,269 @@ func scaleContainer(params map[string]string, resource *spinup.Resource, scale i
 		ForceRedeploy: force,
 	})
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	log.Debugf("putting input: %s", string(input))
 
 	info := &spinup.ContainerService{}
-	if err = SpinupClient.PutResource(params, input, info); err != nil {
-		return []byte{}, err
+	if err = SpinupClient.PutResourceCtx(ctx, params, input, info); err != nil {
+		return nil, err
 	}
 
-	return []byte("OK\n"), nil
+	return updatedContainer(ctx, params, resource, info)
+}
+
+// containerEdit is a change to a container definition of a container service, planned from the update flags
+type containerEdit struct {
+	Container    string
+	Tag          string
+	SetEnv       map[string]string
+	UnsetEnv     []string
+	SetSecrets   map[string]string
+	UnsetSecrets []string
+
+	// Scale is the desired count to set with the edit, if any
+	Scale *int64
+
+	// info is the container service before the edit, with the edit applied to its container definitions
+	info *spinup.ContainerService
+
+	// change is the change to the container service in its manifest representation, for the diff
+	change *manifest.Change
 }
 
-func updateContainerImageTag(params map[string]string, resource *spinup.Resource, containerName, newTag string, forceRedeploy bool) ([]byte, error) {
-	log.Infof("updating container %s image tag to %s", containerName, newTag)
+// newContainerEdit parses the update flags that edit a container definition
+func newContainerEdit(container, tag string, setEnv, unsetEnv, setSecrets, unsetSecrets []string) (*containerEdit, error) {
+	e := &containerEdit{
+		Container:    container,
+		Tag:          tag,
+		SetEnv:       map[string]string{},
+		UnsetEnv:     unsetEnv,
+		SetSecrets:   map[string]string{},
+		UnsetSecrets: unsetSecrets,
+	}
+
+	for _, kv := range setEnv {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --env %q, expected KEY=VALUE", kv)
+		}
+		e.SetEnv[k] = v
+	}
 
-	// Get the container service details
+	for _, kv := range setSecrets {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid --secret %q, expected ENV_NAME=secret-name", kv)
+		}
+		e.SetSecrets[k] = v
+	}
+
+	for _, k := range unsetEnv {
+		if _, ok := e.SetEnv[k]; ok {
+			return nil, fmt.Errorf("%s can't be passed to both --env and --unset-env", k)
+		}
+	}
+
+	for _, k := range unsetSecrets {
+		if _, ok := e.SetSecrets[k]; ok {
+			return nil, fmt.Errorf("%s can't be passed to both --secret and --unset-secret", k)
+		}
+	}
+
+	return e, nil
+}
+
+// plan applies the edit to the current container definitions.  Secret names are resolved to the ARNs of
+// the secrets in the space.
+func (e *containerEdit) plan(ctx context.Context, params map[string]string, resource *spinup.Resource) error {
 	info := &spinup.ContainerService{}
-	if err := SpinupClient.GetResource(params, info); err != nil {
-		return []byte{}, err
-	}
-
-	// Create a copy of the task definition
-	taskDefinition := info.TaskDefinition
-	containerUpdated := false
-
-	// Loop through container definitions and update the specified container
-	for i, container := range taskDefinition.ContainerDefinitions {
-		if container.Name == containerName {
-			// Parse the current image to get repository and update the tag
-			imageParts := strings.Split(container.Image, ":")
-			if len(imageParts) < 2 {
-				return []byte{}, errors.New("current image format is not valid, expected repository:tag")
-			}
-			
-			// Update the image with the new tag
-			taskDefinition.ContainerDefinitions[i].Image = imageParts[0] + ":" + newTag
-			containerUpdated = true
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return err
+	}
+	recordRevision(params, info, journal.SourceObserved)
+
+	// the secrets in the space are only needed to resolve secret names, not for a tag or the environment
+	var secrets []*spinup.Secret
+	if len(e.SetSecrets) > 0 || len(e.UnsetSecrets) > 0 {
+		s, err := spaceSecrets(ctx, map[string]string{"space": params["space"]})
+		if err != nil {
+			return err
+		}
+		secrets = s
+	}
+
+	current := manifestContainer(resource, info, secrets)
+
+	var cd *spinup.ContainerDefinition
+	for _, d := range info.TaskDefinition.ContainerDefinitions {
+		if d.Name == e.Container {
+			cd = d
 			break
 		}
 	}
 
-	if !containerUpdated {
-		return []byte{}, errors.New("container with name " + containerName + " not found in task definition")
+	if cd == nil {
+		return errors.New("container with name " + e.Container + " not found in task definition")
 	}
 
-	// Create a wrapper for the update input
-	updateWrapper := map[string]interface{}{
-		"force_redeploy": forceRedeploy || true,
-		"size_id":        resource.SizeID,
-		"service": map[string]interface{}{
-			"container_definitions": taskDefinition.ContainerDefinitions,
-			"platform_version": "LATEST",
-			"desired_count": info.DesiredCount,
-		},
+	if e.Tag != "" {
+		image, err := imageWithTag(cd.Image, e.Tag)
+		if err != nil {
+			return err
+		}
+		cd.Image = image
+	}
+
+	if e.Scale != nil {
+		info.DesiredCount = *e.Scale
+	}
+
+	env := map[string]*spinup.NameValue{}
+	for _, nv := range cd.Environment {
+		env[nv.Name] = nv
+	}
+
+	for _, k := range sortedMapKeys(e.SetEnv) {
+		if nv, ok := env[k]; ok {
+			nv.Value = e.SetEnv[k]
+			continue
+		}
+		cd.Environment = append(cd.Environment, &spinup.NameValue{Name: k, Value: e.SetEnv[k]})
+	}
+
+	for _, k := range e.UnsetEnv {
+		if _, ok := env[k]; !ok {
+			log.Warnf("environment variable %s isn't set in container %s", k, e.Container)
+		}
+	}
+	cd.Environment = removeNameValues(cd.Environment, e.UnsetEnv)
+
+	refs := map[string]*spinup.NameValueFrom{}
+	for _, nv := range cd.Secrets {
+		refs[nv.Name] = nv
+	}
+
+	for _, k := range sortedMapKeys(e.SetSecrets) {
+		var arn string
+		for _, s := range secrets {
+			if s.Name == e.SetSecrets[k] {
+				arn = s.ARN
+				break
+			}
+		}
+
+		if arn == "" {
+			return fmt.Errorf("secret %s not found in space %s", e.SetSecrets[k], params["space"])
+		}
+
+		if nv, ok := refs[k]; ok {
+			// keep references to a key or version of the same secret
+			if !secretReferencesArn(nv.ValueFrom, arn) {
+				nv.ValueFrom = arn
+			}
+			continue
+		}
+		cd.Secrets = append(cd.Secrets, &spinup.NameValueFrom{Name: k, ValueFrom: arn})
+	}
+
+	for _, k := range e.UnsetSecrets {
+		if _, ok := refs[k]; !ok {
+			log.Warnf("secret %s isn't set in container %s", k, e.Container)
+		}
 	}
+	cd.Secrets = removeNameValueFroms(cd.Secrets, e.UnsetSecrets)
 
-	// Add the capacity provider strategy if it exists
-	if len(info.CapacityProviderStrategy) > 0 {
-		capProviders := make([]map[string]interface{}, 0, len(info.CapacityProviderStrategy))
-		for _, cp := range info.CapacityProviderStrategy {
-			capProviders = append(capProviders, map[string]interface{}{
-				"base":              cp.Base,
-				"capacity_provider": cp.CapacityProvider,
-				"weight":            cp.Weight,
-			})
+	desired := manifestContainer(resource, info, secrets)
+	for _, d := range desired.Definitions {
+		// compare all of the environment and secrets, including when they're now empty
+		if d.Env == nil {
+			d.Env = map[string]string{}
+		}
+		if d.Secrets == nil {
+			d.Secrets = map[string]string{}
 		}
-		updateWrapper["service"].(map[string]interface{})["capacity_provider_strategy"] = capProviders
 	}
 
-	input, err := json.Marshal(updateWrapper)
+	changes := manifest.Plan(
+		&manifest.Manifest{Containers: []*manifest.ContainerService{desired}},
+		&manifest.Manifest{Containers: []*manifest.ContainerService{current}},
+		false,
+	)
+
+	e.info = info
+	e.change = changes[0]
+
+	return nil
+}
+
+// changed returns true if the planned edit changes the container definitions or the desired count
+func (e *containerEdit) changed() bool {
+	return e.change != nil && e.change.Action == manifest.ActionUpdate
+}
+
+// apply puts the edited container definitions, which registers a new task definition and deploys it
+func (e *containerEdit) apply(ctx context.Context, params map[string]string, resource *spinup.Resource) (interface{}, error) {
+	info := e.info
+
+	input, err := json.Marshal(spinup.ContainerServiceInput{
+		// the new task definition is always deployed, so the running tasks are replaced regardless
+		ForceRedeploy: true,
+		Size:          resource.SizeID,
+		Service: &spinup.ContainerServiceDefinitionInput{
+			CapacityProviderStrategy: capacityProviderStrategy("", info.CapacityProviderStrategy),
+			ContainerDefinitions:     info.TaskDefinition.ContainerDefinitions,
+			DesiredCount:             info.DesiredCount,
+			PlatformVersion:          "LATEST",
+			Volumes:                  info.TaskDefinition.Volumes,
+		},
+	})
 	if err != nil {
-		return []byte{}, err
+		return nil, err
 	}
 
 	log.Debugf("putting input: %s", string(input))
 
 	updatedInfo := &spinup.ContainerService{}
-	if err = SpinupClient.PutResource(params, input, updatedInfo); err != nil {
-		return []byte{}, err
+	if err = SpinupClient.PutResourceCtx(ctx, params, input, updatedInfo); err != nil {
+		return nil, err
+	}
+
+	return updatedContainer(ctx, params, resource, updatedInfo)
+}
+
+// imageWithTag replaces the tag of an image like nginx:1.25 or registry:5000/app:1.2, the colon of a
+// registry port comes before the last slash so it isn't mistaken for the tag.  A digest is dropped.
+func imageWithTag(image, tag string) (string, error) {
+	repo, _, _ := strings.Cut(image, "@")
+
+	i := strings.LastIndex(repo, ":")
+	if i < 0 || i < strings.LastIndex(repo, "/") {
+		return "", fmt.Errorf("current image %s is not valid, expected repository:tag", image)
 	}
 
-	return []byte("OK\n"), nil
-}
\ No newline at end of file
+	return repo[:i] + ":" + tag, nil
+}
+
+// removeNameValues returns the name/value pairs without the given names
+func removeNameValues(in []*spinup.NameValue, names []string) []*spinup.NameValue {
+	out := make([]*spinup.NameValue, 0, len(in))
+	for _, nv := range in {
+		if !slices.Contains(names, nv.Name) {
+			out = append(out, nv)
+		}
+	}
+	return out
+}
+
+// removeNameValueFroms returns the name/value from pairs without the given names
+func removeNameValueFroms(in []*spinup.NameValueFrom, names []string) []*spinup.NameValueFrom {
+	out := make([]*spinup.NameValueFrom, 0, len(in))
+	for _, nv := range in {
+		if !slices.Contains(names, nv.Name) {
+			out = append(out, nv)
+		}
+	}
+	return out
+}
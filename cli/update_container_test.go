@@ -0,0 +1,31 @@
+package cli
+
+import "testing"
+
+func TestImageWithTag(t *testing.T) {
+	tests := map[string]string{
+		"nginx:1.25":                           "nginx:2.0",
+		"library/nginx:1.25":                   "library/nginx:2.0",
+		"registry:5000/app:1.2":                "registry:5000/app:2.0",
+		"registry.example.edu:5000/team/app:1": "registry.example.edu:5000/team/app:2.0",
+		"app:1.2@sha256:abc123":                "app:2.0",
+	}
+
+	for image, expected := range tests {
+		out, err := imageWithTag(image, "2.0")
+		if err != nil {
+			t.Errorf("expected nil error for %s, got %s", image, err)
+			continue
+		}
+
+		if out != expected {
+			t.Errorf("expected %s for %s, got %s", expected, image, out)
+		}
+	}
+
+	for _, image := range []string{"nginx", "registry:5000/app", "app@sha256:abc123"} {
+		if _, err := imageWithTag(image, "2.0"); err == nil {
+			t.Errorf("expected error for %s without a tag, got nil", image)
+		}
+	}
+}
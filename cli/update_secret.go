@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var redeploySecretConsumers bool
+
+func init() {
+	updateCmd.AddCommand(updateSecretCmd)
+	updateSecretCmd.Flags().StringVar(&secretValue, "value", "", "The new value of your secret (prefer --from or the prompt to keep it out of your shell history)")
+	updateSecretCmd.Flags().StringVar(&secretValueFrom, "from", "", "A file containing your new secret value, or '-' to read it from stdin")
+	updateSecretCmd.Flags().StringVar(&secretDescription, "description", "", "A new description for your secret")
+	updateSecretCmd.Flags().BoolVar(&redeploySecretConsumers, "redeploy-consumers", false, "Redeploy the container services that reference the secret so they pick up the new value")
+	updateSecretCmd.MarkFlagsMutuallyExclusive("value", "from")
+}
+
+var updateSecretCmd = &cobra.Command{
+	Use:   "secret [space]/[name]",
+	Short: "Update or rotate the value of a secret",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("space/name required")
+		}
+
+		space, name, err := parseSpaceAndName(args[0])
+		if err != nil {
+			return err
+		}
+		secretSpace = space
+		secretName = name
+
+		// a description change alone doesn't need a new value
+		if cmd.Flags().Changed("description") && !cmd.Flags().Changed("value") && !cmd.Flags().Changed("from") {
+			secretValue = ""
+			return nil
+		}
+
+		value, err := readSecretValue(cmd)
+		if err != nil {
+			return err
+		}
+		secretValue = value
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.Infof("updating secret %s in space %s", secretName, secretSpace)
+
+		ctx := cmd.Context()
+		params := map[string]string{"space": secretSpace, "secretname": secretName}
+
+		input, err := json.Marshal(spinup.SecretUpdateInput{
+			Value:       secretValue,
+			Description: secretDescription,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := SpinupClient.PutResourceCtx(ctx, params, input, &spinup.Secret{}); err != nil {
+			return err
+		}
+
+		secret := &spinup.Secret{}
+		if err := SpinupClient.GetResourceCtx(ctx, params, secret); err != nil {
+			return err
+		}
+
+		redeployed := []string{}
+		if redeploySecretConsumers {
+			if secretValue == "" {
+				log.Warn("secret value wasn't changed, not redeploying consumers")
+			} else {
+				consumers, err := secretConsumers(ctx, secretSpace, secret.ARN)
+				if err != nil {
+					return err
+				}
+
+				for _, c := range consumers {
+					log.Infof("redeploying container service %s/%s", secretSpace, c)
+
+					input, err := json.Marshal(map[string]bool{"only_redeploy": true})
+					if err != nil {
+						return err
+					}
+
+					if err := SpinupClient.PutResourceCtx(ctx, map[string]string{"space": secretSpace, "name": c}, input, &spinup.ContainerService{}); err != nil {
+						return err
+					}
+
+					redeployed = append(redeployed, c)
+				}
+			}
+		}
+
+		return formatOutput(struct {
+			*SecretDetails
+			Redeployed []string `json:"redeployed,omitempty"`
+		}{
+			newSecretDetails(secretSpace, secret),
+			redeployed,
+		})
+	},
+}
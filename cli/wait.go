@@ -0,0 +1,272 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	waitFor     string
+	waitTimeout time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(waitCmd)
+	waitCmd.PersistentFlags().StringVar(&waitFor, "for", "status=created", "The condition to wait for, one of: status=created, status=running, status=stopped, status=deleted")
+	waitCmd.PersistentFlags().DurationVar(&waitTimeout, "timeout", 10*time.Minute, "How long to wait for the condition")
+
+	waitCmd.AddCommand(waitContainerCmd)
+	waitCmd.AddCommand(waitServerCmd)
+	waitCmd.AddCommand(waitDatabaseCmd)
+	waitCmd.AddCommand(waitStorageCmd)
+}
+
+// statuses that can be waited for
+const (
+	waitCreated = "created"
+	waitRunning = "running"
+	waitStopped = "stopped"
+	waitDeleted = "deleted"
+)
+
+// waitState is the type specific state of a resource
+type waitState struct {
+	State   string
+	Running bool
+	Stopped bool
+}
+
+// waitStateFunc returns the type specific state of a created resource, nil for types that can't be
+// running or stopped
+type waitStateFunc func(ctx context.Context, params map[string]string, resource *spinup.Resource) (*waitState, error)
+
+// WaitResult is the output of a wait that succeeded
+type WaitResult struct {
+	Name   string `json:"name"`
+	Space  string `json:"space"`
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	State  string `json:"state,omitempty"`
+	Waited string `json:"waited"`
+}
+
+var waitCmd = &cobra.Command{
+	Use:   "wait [type] [space]/[resource] --for status=[status]",
+	Short: "Wait for a resource in a space to reach a status",
+	Long: `Wait for a resource to be created, running, stopped or deleted, polling with backoff.  A resource that
+doesn't exist yet is waited for, so wait can follow a create.  Exits non-zero if the resource fails or the
+timeout passes first.
+
+A container service is running when all of its desired tasks are running, and stopped when it's scaled
+to zero.  A serverless database that's scaled to zero is paused, which counts as stopped.`,
+}
+
+var waitContainerCmd = &cobra.Command{
+	Use:   "container [space]/[resource]",
+	Short: "Wait for a container service",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return waitForResource(cmd.Context(), "container", args, containerWaitState)
+	},
+}
+
+var waitServerCmd = &cobra.Command{
+	Use:   "server [space]/[resource]",
+	Short: "Wait for a server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return waitForResource(cmd.Context(), "server", args, serverWaitState)
+	},
+}
+
+var waitDatabaseCmd = &cobra.Command{
+	Use:   "database [space]/[resource]",
+	Short: "Wait for a database",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return waitForResource(cmd.Context(), "database", args, databaseWaitState)
+	},
+}
+
+var waitStorageCmd = &cobra.Command{
+	Use:   "storage [space]/[resource]",
+	Short: "Wait for a storage service",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return waitForResource(cmd.Context(), "storage", args, nil)
+	},
+}
+
+func containerWaitState(ctx context.Context, params map[string]string, resource *spinup.Resource) (*waitState, error) {
+	info := &spinup.ContainerService{}
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
+	}
+
+	return &waitState{
+		State:   fmt.Sprintf("%s, %d/%d tasks running", strings.ToLower(info.Status), info.RunningCount, info.DesiredCount),
+		Running: info.Status == "ACTIVE" && info.DesiredCount > 0 && info.RunningCount == info.DesiredCount && info.PendingCount == 0,
+		Stopped: info.DesiredCount == 0 && info.RunningCount == 0,
+	}, nil
+}
+
+func serverWaitState(ctx context.Context, params map[string]string, resource *spinup.Resource) (*waitState, error) {
+	info := &spinup.ServerInfo{}
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
+	}
+
+	return &waitState{
+		State:   info.State,
+		Running: info.State == "running",
+		Stopped: info.State == "stopped",
+	}, nil
+}
+
+func databaseWaitState(ctx context.Context, params map[string]string, resource *spinup.Resource) (*waitState, error) {
+	info := &spinup.DatabaseInfo{}
+	if err := SpinupClient.GetResourceCtx(ctx, params, info); err != nil {
+		return nil, err
+	}
+
+	status := databaseStatus(resource, info)
+	return &waitState{
+		State:   status,
+		Running: status == "available",
+		Stopped: status == "stopped" || status == "paused",
+	}, nil
+}
+
+// parseWaitFor parses the --for condition
+func parseWaitFor(condition string, state waitStateFunc) (string, error) {
+	key, status, ok := strings.Cut(condition, "=")
+	if !ok || key != "status" {
+		return "", fmt.Errorf("unsupported condition %q, expected status=[status]", condition)
+	}
+
+	switch status {
+	case waitCreated, waitDeleted:
+	case waitRunning, waitStopped:
+		if state == nil {
+			return "", fmt.Errorf("status %s isn't supported for this type, expected %s or %s", status, waitCreated, waitDeleted)
+		}
+	default:
+		return "", fmt.Errorf("unsupported status %q, expected one of %s, %s, %s or %s", status, waitCreated, waitRunning, waitStopped, waitDeleted)
+	}
+
+	return status, nil
+}
+
+// waitForResource polls the resource until it reaches the status in --for, fails or the timeout passes
+func waitForResource(ctx context.Context, kind string, args []string, state waitStateFunc) error {
+	target, err := parseWaitFor(waitFor, state)
+	if err != nil {
+		return err
+	}
+
+	// a resource that doesn't exist yet can't be found in the default spaces, it's waited for in the only one
+	var params map[string]string
+	if len(args) > 0 && !strings.Contains(args[0], "/") && len(spinupSpaces) == 1 {
+		params = map[string]string{"space": spinupSpaces[0], "name": args[0]}
+	} else if params, err = parseResourceParams(ctx, args); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, waitTimeout)
+	defer cancel()
+
+	timedOut := func(last string) error {
+		return fmt.Errorf("timed out after %s waiting for %s %s/%s to be %s, last status %s", waitTimeout, kind, params["space"], params["name"], target, last)
+	}
+
+	var last string
+	interval := 2 * time.Second
+	for {
+		status, current, done, err := checkWaitStatus(ctx, params, target, state)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return timedOut(last)
+			}
+			return err
+		}
+
+		if status == "failed" {
+			return fmt.Errorf("%s %s/%s failed", kind, params["space"], params["name"])
+		}
+
+		if current != last {
+			fmt.Fprintf(os.Stderr, "%s/%s: %s\n", params["space"], params["name"], current)
+			last = current
+		}
+
+		if done {
+			result := &WaitResult{
+				Name:   params["name"],
+				Space:  params["space"],
+				Type:   kind,
+				Status: status,
+				Waited: time.Since(start).Round(time.Second).String(),
+			}
+
+			if current != status {
+				result.State = current
+			}
+
+			return formatOutput(result)
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return timedOut(last)
+			}
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		if interval < 15*time.Second {
+			interval *= 2
+		}
+	}
+}
+
+// checkWaitStatus returns the spinup status of the resource, its current status or state for display and
+// whether it has reached the target status
+func checkWaitStatus(ctx context.Context, params map[string]string, target string, state waitStateFunc) (string, string, bool, error) {
+	resource := &spinup.Resource{}
+	if err := SpinupClient.GetResourceCtx(ctx, params, resource); err != nil {
+		if spinup.IsNotFound(err) {
+			log.Debugf("resource %s/%s not found", params["space"], params["name"])
+			return waitDeleted, "not found", target == waitDeleted, nil
+		}
+		return "", "", false, err
+	}
+
+	log.Infof("resource %s/%s is %s", params["space"], params["name"], resource.Status)
+
+	switch target {
+	case waitCreated:
+		return resource.Status, resource.Status, resource.Status == waitCreated, nil
+	case waitDeleted:
+		return resource.Status, resource.Status, resource.Status == waitDeleted, nil
+	}
+
+	if resource.Status != waitCreated {
+		return resource.Status, resource.Status, false, nil
+	}
+
+	s, err := state(ctx, params, resource)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	if target == waitRunning {
+		return resource.Status, s.State, s.Running, nil
+	}
+
+	return resource.Status, s.State, s.Stopped, nil
+}
@@ -0,0 +1,196 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+)
+
+// FileStore stores tokens in a file encrypted with a passphrase using age, for hosts without a keyring
+type FileStore struct {
+	Path string
+
+	// Passphrase returns the passphrase for the file.  confirm is true when the file is being created,
+	// so an interactive prompt can ask for the passphrase twice.
+	Passphrase func(confirm bool) (string, error)
+
+	// WorkFactor is the scrypt work factor (log2 N) used when encrypting, 0 uses the age default
+	WorkFactor int
+
+	// pass caches the passphrase so it's only asked for once
+	pass string
+}
+
+// Get returns the token for the key
+func (f *FileStore) Get(key string) (string, error) {
+	tokens, err := f.read()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+
+	t, ok := tokens[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return t, nil
+}
+
+// Set stores the token for the key
+func (f *FileStore) Set(key, token string) error {
+	tokens, err := f.read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if tokens == nil {
+		tokens = map[string]string{}
+	}
+	tokens[key] = token
+
+	return f.write(tokens)
+}
+
+// Delete removes the token for the key
+func (f *FileStore) Delete(key string) error {
+	tokens, err := f.read()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	if _, ok := tokens[key]; !ok {
+		return ErrNotFound
+	}
+	delete(tokens, key)
+
+	return f.write(tokens)
+}
+
+// passphrase returns the cached passphrase, or asks for it
+func (f *FileStore) passphrase(confirm bool) (string, error) {
+	if f.pass != "" {
+		return f.pass, nil
+	}
+
+	if f.Passphrase == nil {
+		return "", errors.New("no passphrase provided for the credential file")
+	}
+
+	pass, err := f.Passphrase(confirm)
+	if err != nil {
+		return "", err
+	}
+
+	if pass == "" {
+		return "", errors.New("the credential file passphrase can't be empty")
+	}
+
+	f.pass = pass
+	return pass, nil
+}
+
+// read decrypts the tokens in the file
+func (f *FileStore) read() (map[string]string, error) {
+	in, err := os.Open(f.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("credential file %s: %w", f.Path, os.ErrNotExist)
+		}
+		return nil, err
+	}
+	defer in.Close()
+
+	pass, err := f.passphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := age.NewScryptIdentity(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(in, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential file %s (wrong passphrase?): %s", f.Path, err)
+	}
+
+	tokens := map[string]string{}
+	if err := json.NewDecoder(r).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode credential file %s: %s", f.Path, err)
+	}
+
+	return tokens, nil
+}
+
+// write encrypts the tokens into the file, replacing it atomically
+func (f *FileStore) write(tokens map[string]string) error {
+	_, statErr := os.Stat(f.Path)
+
+	pass, err := f.passphrase(errors.Is(statErr, os.ErrNotExist))
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(pass)
+	if err != nil {
+		return err
+	}
+
+	if f.WorkFactor > 0 {
+		recipient.SetWorkFactor(f.WorkFactor)
+	}
+
+	plain, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := age.Encrypt(buf, recipient)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, bytes.NewReader(plain)); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".spinup-credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file 0600
+	return os.Rename(tmp.Name(), f.Path)
+}
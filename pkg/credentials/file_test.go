@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestFileStore(path, pass string) *FileStore {
+	return &FileStore{
+		Path:       path,
+		Passphrase: func(bool) (string, error) { return pass, nil },
+		// keep the tests fast
+		WorkFactor: 10,
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.age")
+	testStore(t, newTestFileStore(path, "correct horse"))
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected credential file, got %s", err)
+	}
+
+	if mode := fi.Mode().Perm(); mode != 0600 {
+		t.Errorf("expected mode 0600, got %o", mode)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(raw), "token2") {
+		t.Error("expected the token to be encrypted")
+	}
+
+	// a new store with the same passphrase can read the file
+	out, err := newTestFileStore(path, "correct horse").Get("staging")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if out != "token2" {
+		t.Errorf("expected token2, got %s", out)
+	}
+}
+
+func TestFileStoreWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.age")
+	if err := newTestFileStore(path, "correct horse").Set("default", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTestFileStore(path, "battery staple").Get("default"); err == nil {
+		t.Error("expected error with the wrong passphrase, got nil")
+	}
+}
+
+func TestFileStoreAsksOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.age")
+
+	var asked, confirmed int
+	s := &FileStore{
+		Path: path,
+		Passphrase: func(confirm bool) (string, error) {
+			asked++
+			if confirm {
+				confirmed++
+			}
+			return "correct horse", nil
+		},
+		WorkFactor: 10,
+	}
+
+	if err := s.Set("default", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Set("staging", "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if asked != 1 || confirmed != 1 {
+		t.Errorf("expected the passphrase to be asked for once with confirmation, got %d/%d", asked, confirmed)
+	}
+}
+
+func TestFileStoreMissing(t *testing.T) {
+	s := newTestFileStore(filepath.Join(t.TempDir(), "missing.age"), "pass")
+	if _, err := s.Get("default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Delete("default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// DefaultKeyringService is the service name tokens are stored under in the OS keyring
+const DefaultKeyringService = "spinup-cli"
+
+// KeyringStore stores tokens in the OS keyring (the Secret Service on Linux, the Keychain on macOS
+// and the Credential Manager on Windows)
+type KeyringStore struct {
+	Service string
+}
+
+// NewKeyringStore returns a KeyringStore for the default service
+func NewKeyringStore() *KeyringStore {
+	return &KeyringStore{Service: DefaultKeyringService}
+}
+
+// Get returns the token for the key
+func (k *KeyringStore) Get(key string) (string, error) {
+	t, err := keyring.Get(k.Service, key)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("failed to read %s from the keyring: %s", key, err)
+	}
+	return t, nil
+}
+
+// Set stores the token for the key
+func (k *KeyringStore) Set(key, token string) error {
+	if err := keyring.Set(k.Service, key, token); err != nil {
+		return fmt.Errorf("failed to store %s in the keyring: %s", key, err)
+	}
+	return nil
+}
+
+// Delete removes the token for the key
+func (k *KeyringStore) Delete(key string) error {
+	if err := keyring.Delete(k.Service, key); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to delete %s from the keyring: %s", key, err)
+	}
+	return nil
+}
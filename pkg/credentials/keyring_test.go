@@ -0,0 +1,27 @@
+package credentials
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestKeyringStore(t *testing.T) {
+	keyring.MockInit()
+	testStore(t, NewKeyringStore())
+}
+
+func TestKeyringStoreError(t *testing.T) {
+	keyring.MockInitWithError(errors.New("no secret service"))
+	defer keyring.MockInit()
+
+	s := NewKeyringStore()
+	if err := s.Set("default", "token"); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if _, err := s.Get("default"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected keyring error, got %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultProcessTimeout is how long a credential process may run before it's killed
+var DefaultProcessTimeout = time.Minute
+
+// ProcessStore gets the token by running an external command, like the AWS credential_process setting.
+// The command prints either the token, or a JSON object with a "token" field.  The command is run with
+// the shell so it can include arguments and pipes.
+type ProcessStore struct {
+	Command string
+	Timeout time.Duration
+}
+
+// Get runs the command and returns the token it prints, the key is ignored
+func (p *ProcessStore) Get(key string) (string, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultProcessTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.Command)
+	}
+
+	// don't wait on children of the shell that hold the output open after it's killed
+	cmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("credential process timed out after %s", timeout)
+		}
+		return "", fmt.Errorf("credential process failed: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseProcessOutput(stdout.Bytes())
+}
+
+// Set isn't supported, the token is managed by the external command
+func (p *ProcessStore) Set(key, token string) error {
+	return ErrReadOnly
+}
+
+// Delete isn't supported, the token is managed by the external command
+func (p *ProcessStore) Delete(key string) error {
+	return ErrReadOnly
+}
+
+// parseProcessOutput returns the token from the output of a credential process
+func parseProcessOutput(out []byte) (string, error) {
+	out = bytes.TrimSpace(out)
+	if len(out) == 0 {
+		return "", fmt.Errorf("credential process didn't print a token")
+	}
+
+	if out[0] != '{' {
+		return string(out), nil
+	}
+
+	output := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+
+	if err := json.Unmarshal(out, &output); err != nil {
+		return "", fmt.Errorf("failed to decode credential process output: %s", err)
+	}
+
+	if output.Token != "" {
+		return output.Token, nil
+	}
+
+	if output.AccessToken != "" {
+		return output.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("credential process output doesn't include a token")
+}
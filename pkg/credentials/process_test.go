@@ -0,0 +1,67 @@
+package credentials
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestProcessStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	tests := []struct {
+		command string
+		token   string
+		err     bool
+	}{
+		{"echo abc123", "abc123", false},
+		{`printf '{"token":"abc123","expires_at":"2030-01-01T00:00:00Z"}'`, "abc123", false},
+		{`printf '{"access_token":"xyz"}'`, "xyz", false},
+		{`printf '{"other":"xyz"}'`, "", true},
+		{"true", "", true},
+		{"echo oops >&2; exit 3", "", true},
+	}
+
+	for _, tt := range tests {
+		out, err := (&ProcessStore{Command: tt.command}).Get("ignored")
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected error for %s, got nil", tt.command)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("expected nil error for %s, got %s", tt.command, err)
+		}
+
+		if out != tt.token {
+			t.Errorf("expected %s, got %s", tt.token, out)
+		}
+	}
+}
+
+func TestProcessStoreTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	p := &ProcessStore{Command: "sleep 5", Timeout: 50 * time.Millisecond}
+	if _, err := p.Get("ignored"); err == nil {
+		t.Error("expected timeout error, got nil")
+	}
+}
+
+func TestProcessStoreReadOnly(t *testing.T) {
+	p := &ProcessStore{Command: "echo abc"}
+	if err := p.Set("default", "token"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+
+	if err := p.Delete("default"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+}
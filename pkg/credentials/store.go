@@ -0,0 +1,102 @@
+// Package credentials stores the Spinup API token outside of the plaintext configuration file.  The
+// configuration only holds a Ref, like keyring:default, which names the backend and the key of the token.
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned when there is no token stored for a key
+var ErrNotFound = errors.New("credential not found")
+
+// ErrReadOnly is returned when storing or deleting a token in a backend that can only be read
+var ErrReadOnly = errors.New("credential store is read only")
+
+// Store stores and retrieves tokens by key
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, token string) error
+	Delete(key string) error
+}
+
+// Supported backends
+const (
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+	BackendProcess = "process"
+)
+
+// Ref is a reference to a token in a credential store.  For the keyring and file backends the key
+// names the token, for the process backend it's the command that prints the token.
+type Ref struct {
+	Backend string
+	Key     string
+}
+
+// ParseRef parses a reference like keyring:default, file:staging or process:pass show spinup
+func ParseRef(ref string) (*Ref, error) {
+	backend, key, ok := strings.Cut(ref, ":")
+	if !ok || strings.TrimSpace(key) == "" {
+		return nil, fmt.Errorf("invalid credential reference %q, expected backend:key", ref)
+	}
+
+	switch backend {
+	case BackendKeyring, BackendFile, BackendProcess:
+	default:
+		return nil, fmt.Errorf("unknown credential backend %q in %q", backend, ref)
+	}
+
+	return &Ref{Backend: backend, Key: key}, nil
+}
+
+// String returns the reference in the form stored in the configuration
+func (r *Ref) String() string {
+	return r.Backend + ":" + r.Key
+}
+
+// MemoryStore is an in-memory Store, useful as a fake in tests
+type MemoryStore struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: map[string]string{}}
+}
+
+// Get returns the token for the key
+func (m *MemoryStore) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return t, nil
+}
+
+// Set stores the token for the key
+func (m *MemoryStore) Set(key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens[key] = token
+	return nil
+}
+
+// Delete removes the token for the key
+func (m *MemoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tokens[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.tokens, key)
+	return nil
+}
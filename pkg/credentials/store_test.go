@@ -0,0 +1,89 @@
+package credentials
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		backend string
+		key     string
+		err     bool
+	}{
+		{"keyring:default", BackendKeyring, "default", false},
+		{"file:staging", BackendFile, "staging", false},
+		{"process:pass show spinup:prod", BackendProcess, "pass show spinup:prod", false},
+		{"keyring:", "", "", true},
+		{"default", "", "", true},
+		{"vault:default", "", "", true},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseRef(tt.ref)
+		if tt.err {
+			if err == nil {
+				t.Errorf("expected error for %s, got nil", tt.ref)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("expected nil error for %s, got %s", tt.ref, err)
+			continue
+		}
+
+		if r.Backend != tt.backend || r.Key != tt.key {
+			t.Errorf("expected %s/%s, got %s/%s", tt.backend, tt.key, r.Backend, r.Key)
+		}
+
+		if r.String() != tt.ref {
+			t.Errorf("expected %s, got %s", tt.ref, r.String())
+		}
+	}
+}
+
+// testStore exercises the common behavior of a Store
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+
+	if _, err := s.Get("default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := s.Set("default", "token1"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if err := s.Set("staging", "token2"); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	for k, v := range map[string]string{"default": "token1", "staging": "token2"} {
+		out, err := s.Get(k)
+		if err != nil {
+			t.Errorf("expected nil error, got %s", err)
+		}
+
+		if out != v {
+			t.Errorf("expected %s, got %s", v, out)
+		}
+	}
+
+	if err := s.Delete("default"); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if _, err := s.Get("default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+
+	if err := s.Delete("default"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting a missing key, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
@@ -0,0 +1,240 @@
+// Package journal records the task definition revisions of container services in a local file.  Spinup
+// only returns the current revision of a service, the journal keeps the earlier ones so they can be listed
+// and deployed again.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+// DefaultMaxRevisions is the number of revisions kept for each container service by default
+const DefaultMaxRevisions = 25
+
+var (
+	// lockTimeout is how long Record waits for another process to release the journal
+	lockTimeout = 10 * time.Second
+
+	// staleLockAge is the age after which a lock is assumed to be left by a process that died holding it
+	staleLockAge = 30 * time.Second
+
+	// lockRetry is the wait between attempts to take the lock
+	lockRetry = 20 * time.Millisecond
+)
+
+// SourceObserved is the source of revisions that were seen on a container service, rather than deployed
+// by a command
+const SourceObserved = "observed"
+
+// Revision is a task definition revision of a container service, with the definitions needed to deploy it
+// again
+type Revision struct {
+	Revision             int64                         `json:"revision"`
+	TaskDefinitionArn    string                        `json:"taskDefinitionArn,omitempty"`
+	Images               map[string]string             `json:"images"`
+	RecordedAt           time.Time                     `json:"recordedAt"`
+	Source               string                        `json:"source"`
+	ContainerDefinitions []*spinup.ContainerDefinition `json:"containerDefinitions"`
+	Volumes              []*spinup.ContainerVolume     `json:"volumes,omitempty"`
+}
+
+// Journal is a json file of the revisions of container services, by service key
+type Journal struct {
+	Path string
+
+	// MaxRevisions is the number of revisions kept for each container service, the oldest are dropped
+	MaxRevisions int
+
+	now func() time.Time
+}
+
+// journalFile is the content of the journal file
+type journalFile struct {
+	Services map[string][]*Revision `json:"services"`
+}
+
+// Key returns the journal key of a container service in a space of a spinup instance
+func Key(url, space, name string) string {
+	return strings.TrimSuffix(url, "/") + "/" + space + "/" + name
+}
+
+// NewRevision returns the revision of the task definition of a container service, or nil if the service
+// doesn't have one
+func NewRevision(info *spinup.ContainerService, source string) *Revision {
+	td := info.TaskDefinition
+	if td.Revision == 0 && td.TaskDefinitionArn == "" {
+		return nil
+	}
+
+	images := map[string]string{}
+	for _, cd := range td.ContainerDefinitions {
+		images[cd.Name] = cd.Image
+	}
+
+	return &Revision{
+		Revision:             td.Revision,
+		TaskDefinitionArn:    td.TaskDefinitionArn,
+		Images:               images,
+		Source:               source,
+		ContainerDefinitions: td.ContainerDefinitions,
+		Volumes:              td.Volumes,
+	}
+}
+
+// Record adds the revision to the history of the container service, unless it's already recorded.  It
+// returns true if the revision was added.  The journal is locked while it's updated, so commands running
+// at the same time don't lose each other's revisions.
+func (j *Journal) Record(key string, r *Revision) (bool, error) {
+	if r == nil {
+		return false, nil
+	}
+
+	unlock, err := j.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	f, err := j.read()
+	if err != nil {
+		return false, err
+	}
+
+	for _, existing := range f.Services[key] {
+		if existing.Revision == r.Revision && existing.TaskDefinitionArn == r.TaskDefinitionArn {
+			return false, nil
+		}
+	}
+
+	if r.RecordedAt.IsZero() {
+		r.RecordedAt = j.timeNow()
+	}
+
+	revisions := append(f.Services[key], r)
+	sort.SliceStable(revisions, func(a, b int) bool {
+		return revisions[a].Revision < revisions[b].Revision
+	})
+
+	max := j.MaxRevisions
+	if max <= 0 {
+		max = DefaultMaxRevisions
+	}
+
+	if len(revisions) > max {
+		revisions = revisions[len(revisions)-max:]
+	}
+	f.Services[key] = revisions
+
+	return true, j.write(f)
+}
+
+// History returns the recorded revisions of the container service, oldest first.  It doesn't need the lock,
+// the journal file is replaced in one step when it's written.
+func (j *Journal) History(key string) ([]*Revision, error) {
+	f, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Services[key], nil
+}
+
+func (j *Journal) timeNow() time.Time {
+	if j.now != nil {
+		return j.now()
+	}
+	return time.Now().UTC()
+}
+
+// lock creates a lock file next to the journal, waiting for it to be released if another process holds
+// it.  A lock file is used rather than flock so it works the same on every platform.
+func (j *Journal) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(j.Path), 0700); err != nil {
+		return nil, err
+	}
+
+	path := j.Path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		l, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			l.Close()
+			return func() { os.Remove(path) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		// a record takes milliseconds, an old lock was left behind by a process that died
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for the deployment journal lock %s, remove it if no other spinup command is running", path)
+		}
+
+		time.Sleep(lockRetry)
+	}
+}
+
+func (j *Journal) read() (*journalFile, error) {
+	f := &journalFile{}
+
+	data, err := os.ReadFile(j.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("failed to read deployment journal %s: %s", j.Path, err)
+		}
+	}
+
+	if f.Services == nil {
+		f.Services = map[string][]*Revision{}
+	}
+
+	return f, nil
+}
+
+// write replaces the journal file, writing a temporary file first so it isn't left partly written
+func (j *Journal) write(f *journalFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(j.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".spinup-deployments-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), j.Path)
+}
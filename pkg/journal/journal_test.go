@@ -0,0 +1,194 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YaleSpinup/spinup-cli/pkg/spinup"
+)
+
+func testService(revision int64, image string) *spinup.ContainerService {
+	info := &spinup.ContainerService{}
+	info.TaskDefinition.Revision = revision
+	info.TaskDefinition.TaskDefinitionArn = fmt.Sprintf("arn:aws:ecs:us-east-1:123456789012:task-definition/web:%d", revision)
+	info.TaskDefinition.ContainerDefinitions = []*spinup.ContainerDefinition{
+		{Name: "app", Image: image, Essential: true},
+		{Name: "proxy", Image: "envoy"},
+	}
+	return info
+}
+
+func revisions(history []*Revision) []int64 {
+	out := []int64{}
+	for _, r := range history {
+		out = append(out, r.Revision)
+	}
+	return out
+}
+
+func TestKey(t *testing.T) {
+	if k := Key("https://spinup.example.edu/", "myspace", "web"); k != "https://spinup.example.edu/myspace/web" {
+		t.Errorf("unexpected key %s", k)
+	}
+}
+
+func TestNewRevision(t *testing.T) {
+	r := NewRevision(testService(3, "nginx:1.25"), SourceObserved)
+
+	expected := map[string]string{"app": "nginx:1.25", "proxy": "envoy"}
+	if r.Revision != 3 || r.Source != SourceObserved || !reflect.DeepEqual(r.Images, expected) || len(r.ContainerDefinitions) != 2 {
+		t.Errorf("unexpected revision %+v", r)
+	}
+
+	if r := NewRevision(&spinup.ContainerService{}, SourceObserved); r != nil {
+		t.Errorf("expected nil revision for a service without a task definition, got %+v", r)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	j := &Journal{
+		Path:         filepath.Join(t.TempDir(), "journal", "deployments.json"),
+		MaxRevisions: 3,
+		now:          func() time.Time { return now },
+	}
+
+	history, err := j.History("web")
+	if err != nil || len(history) != 0 {
+		t.Fatalf("expected empty history without a journal file, got %v, %v", history, err)
+	}
+
+	for i, rev := range []int64{2, 1, 3, 2} {
+		added, err := j.Record("web", NewRevision(testService(rev, "nginx"), SourceObserved))
+		if err != nil {
+			t.Fatalf("expected nil error, got %s", err)
+		}
+
+		// revision 2 is only added the first time
+		if expected := i < 3; added != expected {
+			t.Errorf("expected added %t for revision %d, got %t", expected, rev, added)
+		}
+		now = now.Add(time.Hour)
+	}
+
+	if _, err := j.Record("worker", NewRevision(testService(1, "worker"), "spinup update container")); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	history, err = j.History("web")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if got := revisions(history); !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Errorf("expected revisions [1 2 3], got %v", got)
+	}
+
+	if !history[1].RecordedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected revision 2 to keep the time it was first recorded, got %s", history[1].RecordedAt)
+	}
+
+	// the oldest revisions are dropped
+	if _, err := j.Record("web", NewRevision(testService(4, "nginx:1.26"), "spinup rollout undo container")); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	history, _ = j.History("web")
+	if got := revisions(history); !reflect.DeepEqual(got, []int64{2, 3, 4}) {
+		t.Errorf("expected revisions [2 3 4], got %v", got)
+	}
+
+	if history[2].Images["app"] != "nginx:1.26" || history[2].Source != "spinup rollout undo container" {
+		t.Errorf("unexpected revision %+v", history[2])
+	}
+
+	history, _ = j.History("worker")
+	if got := revisions(history); !reflect.DeepEqual(got, []int64{1}) {
+		t.Errorf("expected revisions [1] for worker, got %v", got)
+	}
+}
+
+func TestJournalInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deployments.json")
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	j := &Journal{Path: path}
+	if _, err := j.History("web"); err == nil {
+		t.Error("expected error for an invalid journal, got nil")
+	}
+
+	if _, err := j.Record("web", NewRevision(testService(1, "nginx"), SourceObserved)); err == nil {
+		t.Error("expected error recording to an invalid journal, got nil")
+	}
+}
+
+func TestJournalConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deployments.json")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 1; i <= 40; i++ {
+		wg.Add(1)
+		go func(rev int64) {
+			defer wg.Done()
+
+			// a journal per writer, like separate commands
+			j := &Journal{Path: path, MaxRevisions: 100}
+			if _, err := j.Record("web", NewRevision(testService(rev, "nginx"), SourceObserved)); err != nil {
+				errs <- err
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	history, err := (&Journal{Path: path}).History("web")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(history) != 40 {
+		t.Errorf("expected 40 revisions, got %v", revisions(history))
+	}
+
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected the lock to be removed, got %v", err)
+	}
+}
+
+func TestJournalLock(t *testing.T) {
+	defer func(timeout time.Duration) { lockTimeout = timeout }(lockTimeout)
+	lockTimeout = 100 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "deployments.json")
+	if err := os.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	j := &Journal{Path: path}
+	if _, err := j.Record("web", NewRevision(testService(1, "nginx"), SourceObserved)); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout while the journal is locked, got %v", err)
+	}
+
+	// a stale lock is taken over
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if added, err := j.Record("web", NewRevision(testService(1, "nginx"), SourceObserved)); err != nil || !added {
+		t.Errorf("expected the revision to be added after a stale lock, got %t, %v", added, err)
+	}
+}
@@ -0,0 +1,30 @@
+package login
+
+import (
+	"context"
+	"errors"
+
+	"golang.org/x/oauth2"
+)
+
+// DeviceLogin logs in with the device authorization flow.  prompt is called with the verification url
+// and the code the user enters there, then the token endpoint is polled until the user approves the
+// login, denies it or the code expires.
+func (c *Config) DeviceLogin(ctx context.Context, prompt func(*oauth2.DeviceAuthResponse) error) (*oauth2.Token, error) {
+	if c.DeviceAuthURL == "" || c.TokenURL == "" {
+		return nil, errors.New("a device authorization url and token url are required for the device flow")
+	}
+
+	cfg := c.oauth2("")
+
+	da, err := cfg.DeviceAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prompt(da); err != nil {
+		return nil, err
+	}
+
+	return cfg.DeviceAccessToken(ctx, da)
+}
@@ -0,0 +1,59 @@
+package login
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestDeviceLogin(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	var prompted *oauth2.DeviceAuthResponse
+	out, err := f.config().DeviceLogin(context.Background(), func(da *oauth2.DeviceAuthResponse) error {
+		prompted = da
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if prompted == nil || prompted.UserCode != "ABCD-EFGH" || prompted.VerificationURI != f.URL+"/activate" {
+		t.Errorf("unexpected device authorization %+v", prompted)
+	}
+
+	if out.AccessToken != "device-access" || out.RefreshToken != "refresh1" {
+		t.Errorf("unexpected token %+v", out)
+	}
+
+	if f.polls != 2 {
+		t.Errorf("expected 2 polls, got %d", f.polls)
+	}
+}
+
+func TestDeviceLoginDenied(t *testing.T) {
+	f := newFakeAuthServer(t)
+	f.deny = true
+
+	if _, err := f.config().DeviceLogin(context.Background(), func(*oauth2.DeviceAuthResponse) error { return nil }); err == nil {
+		t.Error("expected error when the login is denied, got nil")
+	}
+}
+
+func TestDeviceLoginPromptError(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	promptErr := errors.New("boom")
+	if _, err := f.config().DeviceLogin(context.Background(), func(*oauth2.DeviceAuthResponse) error { return promptErr }); !errors.Is(err, promptErr) {
+		t.Errorf("expected prompt error, got %v", err)
+	}
+}
+
+func TestDeviceLoginNotConfigured(t *testing.T) {
+	c := &Config{TokenURL: "http://127.0.0.1/token", ClientID: "spinup-cli"}
+	if _, err := c.DeviceLogin(context.Background(), nil); err == nil {
+		t.Error("expected error without a device authorization url, got nil")
+	}
+}
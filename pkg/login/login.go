@@ -0,0 +1,106 @@
+// Package login gets Spinup API tokens from an OAuth2 authorization server, with either the device
+// authorization flow (RFC 8628) for hosts without a browser, or the authorization code flow with PKCE
+// and a loopback redirect (RFC 8252).
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Config is the authorization server and client used to log in
+type Config struct {
+	// AuthURL is the authorization endpoint for the browser flow
+	AuthURL string
+
+	// TokenURL is the token endpoint, used by both flows and to refresh tokens
+	TokenURL string
+
+	// DeviceAuthURL is the device authorization endpoint for the device flow
+	DeviceAuthURL string
+
+	// ClientID is the public client registered for the cli, there is no client secret
+	ClientID string
+
+	Scopes []string
+}
+
+// oauth2 returns the oauth2 configuration, redirectURL is only used by the browser flow
+func (c *Config) oauth2(redirectURL string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID: c.ClientID,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:       c.AuthURL,
+			TokenURL:      c.TokenURL,
+			DeviceAuthURL: c.DeviceAuthURL,
+			// public clients send the client id in the form
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+		RedirectURL: redirectURL,
+		Scopes:      c.Scopes,
+	}
+}
+
+// Refresh gets a new token with the refresh token, the refresh token is kept if the server
+// doesn't return a new one
+func (c *Config) Refresh(ctx context.Context, t *oauth2.Token) (*oauth2.Token, error) {
+	if c.TokenURL == "" {
+		return nil, errors.New("a token url is required to refresh the token")
+	}
+
+	if t.RefreshToken == "" {
+		return nil, errors.New("the token can't be refreshed, it doesn't have a refresh token")
+	}
+
+	// a token without an access token is always invalid, so the token source refreshes it
+	return c.oauth2("").TokenSource(ctx, &oauth2.Token{RefreshToken: t.RefreshToken}).Token()
+}
+
+// storedToken is the form of a token kept in the credential store
+type storedToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// EncodeToken encodes a token, with its refresh token and expiry, to be kept in a credential store
+func EncodeToken(t *oauth2.Token) (string, error) {
+	out, err := json.Marshal(storedToken{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		Expiry:       t.Expiry,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// DecodeToken decodes a token from a credential store.  Tokens that were pasted into
+// spinup configure are stored as the bare access token.
+func DecodeToken(s string) (*oauth2.Token, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") {
+		return &oauth2.Token{AccessToken: s}, nil
+	}
+
+	var t storedToken
+	if err := json.Unmarshal([]byte(s), &t); err != nil {
+		return nil, err
+	}
+
+	if t.AccessToken == "" {
+		return nil, errors.New("stored token doesn't have an access token")
+	}
+
+	return &oauth2.Token{
+		AccessToken:  t.AccessToken,
+		RefreshToken: t.RefreshToken,
+		Expiry:       t.Expiry,
+	}, nil
+}
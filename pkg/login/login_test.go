@@ -0,0 +1,203 @@
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeAuthServer is a minimal OAuth2 authorization server
+type fakeAuthServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	challenge string
+	polls     int
+	deny      bool
+	refreshes int
+}
+
+func newFakeAuthServer(t *testing.T) *fakeAuthServer {
+	f := &fakeAuthServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("client_id") != "spinup-cli" || q.Get("code_challenge_method") != "S256" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		f.mu.Lock()
+		f.challenge = q.Get("code_challenge")
+		deny := f.deny
+		f.mu.Unlock()
+
+		redirect, _ := url.Parse(q.Get("redirect_uri"))
+		v := url.Values{"state": {q.Get("state")}}
+		if deny {
+			v.Set("error", "access_denied")
+		} else {
+			v.Set("code", "authcode")
+		}
+		redirect.RawQuery = v.Encode()
+
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"device_code":      "devicecode",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": f.URL + "/activate",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			if r.Form.Get("code") != "authcode" || oauth2.S256ChallengeFromVerifier(r.Form.Get("code_verifier")) != f.challenge {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			writeJSON(w, http.StatusOK, tokenResponse("browser-access", "refresh1"))
+		case "urn:ietf:params:oauth:grant-type:device_code":
+			f.polls++
+			switch {
+			case f.deny:
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "access_denied"})
+			case f.polls < 2:
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "authorization_pending"})
+			default:
+				writeJSON(w, http.StatusOK, tokenResponse("device-access", "refresh1"))
+			}
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "refresh1" {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+				return
+			}
+			f.refreshes++
+			// no new refresh token, the client keeps the old one
+			writeJSON(w, http.StatusOK, tokenResponse("refreshed-access", ""))
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		}
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+func (f *fakeAuthServer) config() *Config {
+	return &Config{
+		AuthURL:       f.URL + "/authorize",
+		TokenURL:      f.URL + "/token",
+		DeviceAuthURL: f.URL + "/device",
+		ClientID:      "spinup-cli",
+		Scopes:        []string{"openid", "offline_access"},
+	}
+}
+
+func tokenResponse(access, refresh string) map[string]interface{} {
+	out := map[string]interface{}{
+		"access_token": access,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+	if refresh != "" {
+		out["refresh_token"] = refresh
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestRefresh(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	out, err := f.config().Refresh(context.Background(), &oauth2.Token{AccessToken: "old", RefreshToken: "refresh1"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out.AccessToken != "refreshed-access" {
+		t.Errorf("expected refreshed-access, got %s", out.AccessToken)
+	}
+
+	if out.RefreshToken != "refresh1" {
+		t.Errorf("expected the refresh token to be kept, got %q", out.RefreshToken)
+	}
+
+	if out.Expiry.IsZero() {
+		t.Error("expected an expiry")
+	}
+
+	if _, err := f.config().Refresh(context.Background(), &oauth2.Token{AccessToken: "old", RefreshToken: "wrong"}); err == nil {
+		t.Error("expected error for an invalid refresh token, got nil")
+	}
+
+	if _, err := f.config().Refresh(context.Background(), &oauth2.Token{AccessToken: "old"}); err == nil {
+		t.Error("expected error without a refresh token, got nil")
+	}
+}
+
+func TestEncodeDecodeToken(t *testing.T) {
+	expiry := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	s, err := EncodeToken(&oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: expiry})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	out, err := DecodeToken(s)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out.AccessToken != "access" || out.RefreshToken != "refresh" || !out.Expiry.Equal(expiry) {
+		t.Errorf("unexpected token %+v", out)
+	}
+
+	// tokens pasted into configure are stored bare
+	out, err = DecodeToken("aaa.bbb.ccc\n")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out.AccessToken != "aaa.bbb.ccc" || out.RefreshToken != "" {
+		t.Errorf("unexpected token %+v", out)
+	}
+
+	for _, s := range []string{`{"refresh_token":"x"}`, `{bad`} {
+		if _, err := DecodeToken(s); err == nil {
+			t.Errorf("expected error for %s, got nil", s)
+		}
+	}
+
+	if strings.Contains(s, "token_type") {
+		t.Errorf("expected only the stored fields, got %s", s)
+	}
+}
@@ -0,0 +1,104 @@
+package login
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+)
+
+// callbackPath is the path of the loopback redirect
+const callbackPath = "/callback"
+
+// BrowserLogin logs in with the authorization code flow.  It listens for the redirect on the loopback
+// interface, port 0 picks a free port, and calls open with the authorization url so it can be opened
+// in a browser or shown to the user.  PKCE and a random state protect the code from other local processes.
+func (c *Config) BrowserLogin(ctx context.Context, port int, open func(url string) error) (*oauth2.Token, error) {
+	if c.AuthURL == "" || c.TokenURL == "" {
+		return nil, errors.New("an authorization url and token url are required for the browser flow")
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the login redirect: %s", err)
+	}
+	defer l.Close()
+
+	redirectURL := fmt.Sprintf("http://%s%s", l.Addr().String(), callbackPath)
+	cfg := c.oauth2(redirectURL)
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		var res result
+		switch {
+		case q.Get("state") != state:
+			// not our redirect, keep waiting for the real one
+			http.Error(w, "invalid state", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			res.err = fmt.Errorf("authorization failed: %s %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("code") == "":
+			res.err = errors.New("authorization failed: no code was returned")
+		default:
+			res.code = q.Get("code")
+		}
+
+		if res.err != nil {
+			http.Error(w, res.err.Error()+", return to the terminal for details.", http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in to Spinup, you can close this window and return to the terminal.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	if err := open(cfg.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-results:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return cfg.Exchange(ctx, res.code, oauth2.VerifierOption(verifier))
+	}
+}
+
+// randomState returns a random value for the state parameter
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
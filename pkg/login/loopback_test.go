@@ -0,0 +1,80 @@
+package login
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// browser follows the authorization url and the redirect back to the loopback listener
+func browser(t *testing.T) func(string) error {
+	return func(u string) error {
+		go func() {
+			res, err := http.Get(u)
+			if err != nil {
+				t.Errorf("browser request failed: %s", err)
+				return
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}()
+		return nil
+	}
+}
+
+func TestBrowserLogin(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	out, err := f.config().BrowserLogin(context.Background(), 0, browser(t))
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out.AccessToken != "browser-access" || out.RefreshToken != "refresh1" {
+		t.Errorf("unexpected token %+v", out)
+	}
+}
+
+func TestBrowserLoginDenied(t *testing.T) {
+	f := newFakeAuthServer(t)
+	f.deny = true
+
+	if _, err := f.config().BrowserLogin(context.Background(), 0, browser(t)); err == nil {
+		t.Error("expected error when the login is denied, got nil")
+	}
+}
+
+func TestBrowserLoginCancelled(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// the browser never comes back
+	if _, err := f.config().BrowserLogin(ctx, 0, func(string) error { return nil }); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestBrowserLoginWrongState(t *testing.T) {
+	f := newFakeAuthServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// a redirect with the wrong state is rejected and the login keeps waiting
+	_, err := f.config().BrowserLogin(ctx, 0, func(u string) error {
+		go func() {
+			res, err := http.Get(f.URL + "/authorize?client_id=x")
+			if err == nil {
+				res.Body.Close()
+			}
+		}()
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
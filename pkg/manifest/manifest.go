@@ -0,0 +1,274 @@
+// Package manifest describes the desired resources in a Spinup space as YAML, and plans the changes
+// needed to make a space match it.
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// APIVersion is the version of the manifest format
+	APIVersion = "spinup/v1"
+
+	// KindSpace is the kind of a manifest describing a space
+	KindSpace = "Space"
+)
+
+// Capacity for container services
+const (
+	CapacitySpot     = "spot"
+	CapacityOnDemand = "on-demand"
+)
+
+// Manifest is the desired state of the resources in a space.  Resources in the space that aren't in the
+// manifest are left alone, unless they're pruned.
+type Manifest struct {
+	APIVersion string              `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string              `yaml:"kind" json:"kind"`
+	Space      string              `yaml:"space" json:"space"`
+	Secrets    []*Secret           `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	Containers []*ContainerService `yaml:"containers,omitempty" json:"containers,omitempty"`
+	Storage    []*Storage          `yaml:"storage,omitempty" json:"storage,omitempty"`
+	Databases  []*Database         `yaml:"databases,omitempty" json:"databases,omitempty"`
+}
+
+// Secret is a secret in the space.  Secrets without a value source are only referenced, they must already
+// exist and their value isn't managed.
+type Secret struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// FromEnv is the environment variable holding the value
+	FromEnv string `yaml:"fromEnv,omitempty" json:"fromEnv,omitempty"`
+
+	// FromFile is the file holding the value, relative to the manifest
+	FromFile string `yaml:"fromFile,omitempty" json:"fromFile,omitempty"`
+
+	// Value is the resolved value, it's never read from or written to the manifest
+	Value string `yaml:"-" json:"-"`
+}
+
+// Managed returns true if the value of the secret is managed by the manifest
+func (s *Secret) Managed() bool {
+	return s.FromEnv != "" || s.FromFile != ""
+}
+
+// ContainerService is a container service and its container definitions
+type ContainerService struct {
+	Name         string       `yaml:"name" json:"name"`
+	SizeID       int          `yaml:"sizeId,omitempty" json:"sizeId,omitempty"`
+	DesiredCount *int64       `yaml:"desiredCount,omitempty" json:"desiredCount,omitempty"`
+	Capacity     string       `yaml:"capacity,omitempty" json:"capacity,omitempty"`
+	Definitions  []*Container `yaml:"definitions" json:"definitions"`
+}
+
+// Container is a container definition in a container service
+type Container struct {
+	Name    string   `yaml:"name" json:"name"`
+	Image   string   `yaml:"image" json:"image"`
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Ports are the container ports, like 8080/tcp
+	Ports []string `yaml:"ports,omitempty" json:"ports,omitempty"`
+
+	Env map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+
+	// Secrets maps environment variables to the names of secrets in the space
+	Secrets map[string]string `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+}
+
+// Storage is a S3 storage bucket and its users
+type Storage struct {
+	Name   string   `yaml:"name" json:"name"`
+	SizeID int      `yaml:"sizeId,omitempty" json:"sizeId,omitempty"`
+	Users  []string `yaml:"users,omitempty" json:"users,omitempty"`
+}
+
+// Database is a database and its size
+type Database struct {
+	Name   string `yaml:"name" json:"name"`
+	SizeID int    `yaml:"sizeId,omitempty" json:"sizeId,omitempty"`
+}
+
+// Load reads a manifest from a file, or stdin if the path is -
+func Load(path string) (*Manifest, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	m, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	return m, nil
+}
+
+// Parse decodes and validates a manifest, unknown fields are an error so typos aren't silently ignored
+func Parse(r io.Reader) (*Manifest, error) {
+	d := yaml.NewDecoder(r)
+	d.KnownFields(true)
+
+	m := &Manifest{}
+	if err := d.Decode(m); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("manifest is empty")
+		}
+		return nil, err
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+var portPattern = regexp.MustCompile(`^[0-9]+(/(tcp|udp))?$`)
+
+// Validate checks the manifest for missing and duplicate names and invalid values
+func (m *Manifest) Validate() error {
+	var errs []string
+	fail := func(format string, a ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, a...))
+	}
+
+	if m.APIVersion != APIVersion {
+		fail("apiVersion must be %s, got %q", APIVersion, m.APIVersion)
+	}
+
+	if m.Kind != KindSpace {
+		fail("kind must be %s, got %q", KindSpace, m.Kind)
+	}
+
+	names := map[string]bool{}
+	unique := func(kind, name string) {
+		if name == "" {
+			fail("%s name is required", kind)
+			return
+		}
+
+		if names[kind+"/"+name] {
+			fail("%s %s is defined more than once", kind, name)
+		}
+		names[kind+"/"+name] = true
+	}
+
+	for _, s := range m.Secrets {
+		unique("secret", s.Name)
+		if s.FromEnv != "" && s.FromFile != "" {
+			fail("secret %s can only have one of fromEnv and fromFile", s.Name)
+		}
+	}
+
+	for _, c := range m.Containers {
+		unique("container", c.Name)
+
+		if c.DesiredCount != nil && *c.DesiredCount < 0 {
+			fail("container %s desiredCount can't be negative", c.Name)
+		}
+
+		switch c.Capacity {
+		case "", CapacitySpot, CapacityOnDemand:
+		default:
+			fail("container %s capacity must be %s or %s, got %q", c.Name, CapacitySpot, CapacityOnDemand, c.Capacity)
+		}
+
+		if len(c.Definitions) == 0 {
+			fail("container %s needs at least one container definition", c.Name)
+		}
+
+		defs := map[string]bool{}
+		for _, d := range c.Definitions {
+			if d.Name == "" {
+				fail("container %s has a definition without a name", c.Name)
+			} else if defs[d.Name] {
+				fail("container %s defines %s more than once", c.Name, d.Name)
+			}
+			defs[d.Name] = true
+
+			if d.Image == "" {
+				fail("container %s definition %s needs an image", c.Name, d.Name)
+			}
+
+			for _, p := range d.Ports {
+				if !portPattern.MatchString(p) {
+					fail("container %s definition %s has an invalid port %q, expected port[/tcp|udp]", c.Name, d.Name, p)
+				}
+			}
+
+			for k := range d.Secrets {
+				if _, ok := d.Env[k]; ok {
+					fail("container %s definition %s sets %s as both env and a secret", c.Name, d.Name, k)
+				}
+			}
+		}
+	}
+
+	for _, s := range m.Storage {
+		unique("storage", s.Name)
+	}
+
+	for _, d := range m.Databases {
+		unique("database", d.Name)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid manifest:\n  %s", strings.Join(errs, "\n  "))
+	}
+
+	return nil
+}
+
+// Encode writes the manifest as YAML
+func (m *Manifest) Encode(w io.Writer) error {
+	buf := &bytes.Buffer{}
+	e := yaml.NewEncoder(buf)
+	e.SetIndent(2)
+
+	if err := e.Encode(m); err != nil {
+		return err
+	}
+
+	if err := e.Close(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// ParsePort splits a port like 8080/tcp into the port and protocol, tcp is the default
+func ParsePort(p string) (int64, string, error) {
+	port, proto, _ := strings.Cut(p, "/")
+	if proto == "" {
+		proto = "tcp"
+	}
+
+	n, err := strconv.ParseInt(port, 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid port %q", p)
+	}
+
+	return n, proto, nil
+}
+
+// FormatPort formats a port and protocol like 8080/tcp
+func FormatPort(port int64, proto string) string {
+	return strconv.FormatInt(port, 10) + "/" + strings.ToLower(proto)
+}
@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifest = `apiVersion: spinup/v1
+kind: Space
+space: myspace
+secrets:
+  - name: db-password
+    fromEnv: DB_PASSWORD
+  - name: api-key
+containers:
+  - name: web
+    sizeId: 12
+    desiredCount: 2
+    capacity: spot
+    definitions:
+      - name: app
+        image: nginx:1.25
+        ports: ["8080", "53/udp"]
+        env:
+          LOG_LEVEL: info
+        secrets:
+          DB_PASSWORD: db-password
+storage:
+  - name: assets
+    users: [deploy]
+databases:
+  - name: appdb
+    sizeId: 3
+`
+
+func TestParse(t *testing.T) {
+	m, err := Parse(strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if m.Space != "myspace" {
+		t.Errorf("expected space myspace, got %s", m.Space)
+	}
+
+	if len(m.Secrets) != 2 || !m.Secrets[0].Managed() || m.Secrets[1].Managed() {
+		t.Errorf("unexpected secrets %+v", m.Secrets)
+	}
+
+	if len(m.Containers) != 1 || *m.Containers[0].DesiredCount != 2 {
+		t.Fatalf("unexpected containers %+v", m.Containers)
+	}
+
+	def := m.Containers[0].Definitions[0]
+	if !reflect.DeepEqual(def.Ports, []string{"8080", "53/udp"}) {
+		t.Errorf("unexpected ports %+v", def.Ports)
+	}
+
+	if def.Secrets["DB_PASSWORD"] != "db-password" {
+		t.Errorf("unexpected secrets %+v", def.Secrets)
+	}
+
+	// round trip
+	buf := &bytes.Buffer{}
+	if err := m.Encode(buf); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	again, err := Parse(buf)
+	if err != nil {
+		t.Fatalf("expected nil error parsing encoded manifest, got %s", err)
+	}
+
+	if !reflect.DeepEqual(m, again) {
+		t.Errorf("expected %+v after round trip, got %+v", m, again)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		err   string
+	}{
+		"empty": {
+			input: "",
+			err:   "manifest is empty",
+		},
+		"unknown field": {
+			input: "apiVersion: spinup/v1\nkind: Space\nspaces: foo\n",
+			err:   "field spaces not found",
+		},
+		"wrong version": {
+			input: "apiVersion: v2\nkind: Space\n",
+			err:   "apiVersion must be spinup/v1",
+		},
+		"duplicate secret": {
+			input: "apiVersion: spinup/v1\nkind: Space\nsecrets:\n  - name: a\n  - name: a\n",
+			err:   "secret a is defined more than once",
+		},
+		"two sources": {
+			input: "apiVersion: spinup/v1\nkind: Space\nsecrets:\n  - name: a\n    fromEnv: A\n    fromFile: a.txt\n",
+			err:   "only have one of fromEnv and fromFile",
+		},
+		"no definitions": {
+			input: "apiVersion: spinup/v1\nkind: Space\ncontainers:\n  - name: web\n",
+			err:   "needs at least one container definition",
+		},
+		"bad container": {
+			input: "apiVersion: spinup/v1\nkind: Space\ncontainers:\n  - name: web\n    capacity: cheap\n    definitions:\n      - name: app\n        ports: [http]\n",
+			err:   "capacity must be spot or on-demand",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.input))
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tc.err)
+			}
+
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %s", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestParseErrorsAggregated(t *testing.T) {
+	input := "apiVersion: spinup/v1\nkind: Space\ncontainers:\n  - name: web\n    definitions:\n      - name: app\n        ports: [http]\n"
+
+	_, err := Parse(strings.NewReader(input))
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	for _, want := range []string{"needs an image", `invalid port "http"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error containing %q, got %s", want, err)
+		}
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		input string
+		port  int64
+		proto string
+		err   bool
+	}{
+		{input: "80", port: 80, proto: "tcp"},
+		{input: "53/udp", port: 53, proto: "udp"},
+		{input: "http", err: true},
+	}
+
+	for _, tc := range tests {
+		port, proto, err := ParsePort(tc.input)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected error for %s, got nil", tc.input)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("expected nil error for %s, got %s", tc.input, err)
+		}
+
+		if port != tc.port || proto != tc.proto {
+			t.Errorf("expected %d/%s for %s, got %d/%s", tc.port, tc.proto, tc.input, port, proto)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package manifest
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Action is what a change does to a resource
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionDelete    Action = "delete"
+	ActionUnchanged Action = "unchanged"
+)
+
+// Kinds of resources in a manifest
+const (
+	KindSecret    = "secret"
+	KindContainer = "container"
+	KindStorage   = "storage"
+	KindDatabase  = "database"
+)
+
+// FieldChange is a changed field of a resource.  The values of sensitive fields aren't included.
+type FieldChange struct {
+	Field     string      `json:"field"`
+	Old       interface{} `json:"old,omitempty"`
+	New       interface{} `json:"new,omitempty"`
+	Sensitive bool        `json:"sensitive,omitempty"`
+}
+
+// Change is a planned change to a resource
+type Change struct {
+	Action Action         `json:"action"`
+	Kind   string         `json:"kind"`
+	Name   string         `json:"name"`
+	Fields []*FieldChange `json:"fields,omitempty"`
+
+	// Desired is the resource in the manifest, nil when it's deleted
+	Desired interface{} `json:"-"`
+
+	// Current is the resource in the space, nil when it's created
+	Current interface{} `json:"-"`
+}
+
+// Plan returns the changes needed to make the current state of the space match the desired manifest, in
+// the order they should be applied.  Resources are created and updated in dependency order, secrets before
+// the containers that reference them, then deleted in the reverse order.  Resources that aren't in the
+// manifest are only deleted when prune is true and the manifest has a section for their kind, so an
+// empty secrets list prunes every secret but a manifest without one leaves them alone.
+//
+// Fields that are omitted from the manifest aren't managed and are never changed.
+func Plan(desired, current *Manifest, prune bool) []*Change {
+	changes := []*Change{}
+
+	secrets := map[string]*Secret{}
+	for _, s := range current.Secrets {
+		secrets[s.Name] = s
+	}
+	for _, d := range desired.Secrets {
+		changes = append(changes, planSecret(d, secrets[d.Name]))
+	}
+
+	storage := map[string]*Storage{}
+	for _, s := range current.Storage {
+		storage[s.Name] = s
+	}
+	for _, d := range desired.Storage {
+		changes = append(changes, planStorage(d, storage[d.Name]))
+	}
+
+	databases := map[string]*Database{}
+	for _, db := range current.Databases {
+		databases[db.Name] = db
+	}
+	for _, d := range desired.Databases {
+		changes = append(changes, planDatabase(d, databases[d.Name]))
+	}
+
+	containers := map[string]*ContainerService{}
+	for _, c := range current.Containers {
+		containers[c.Name] = c
+	}
+	for _, d := range desired.Containers {
+		changes = append(changes, planContainer(d, containers[d.Name]))
+	}
+
+	if !prune {
+		return changes
+	}
+
+	if desired.Containers != nil {
+		keep := map[string]bool{}
+		for _, d := range desired.Containers {
+			keep[d.Name] = true
+		}
+		for _, c := range current.Containers {
+			if !keep[c.Name] {
+				changes = append(changes, deleteChange(KindContainer, c.Name, c))
+			}
+		}
+	}
+
+	if desired.Databases != nil {
+		keep := map[string]bool{}
+		for _, d := range desired.Databases {
+			keep[d.Name] = true
+		}
+		for _, db := range current.Databases {
+			if !keep[db.Name] {
+				changes = append(changes, deleteChange(KindDatabase, db.Name, db))
+			}
+		}
+	}
+
+	if desired.Storage != nil {
+		keep := map[string]bool{}
+		for _, d := range desired.Storage {
+			keep[d.Name] = true
+		}
+		for _, s := range current.Storage {
+			if !keep[s.Name] {
+				changes = append(changes, deleteChange(KindStorage, s.Name, s))
+			}
+		}
+	}
+
+	if desired.Secrets != nil {
+		keep := map[string]bool{}
+		for _, d := range desired.Secrets {
+			keep[d.Name] = true
+		}
+		for _, s := range current.Secrets {
+			if !keep[s.Name] {
+				changes = append(changes, deleteChange(KindSecret, s.Name, s))
+			}
+		}
+	}
+
+	return changes
+}
+
+// HasChanges returns true if any of the changes would modify the space
+func HasChanges(changes []*Change) bool {
+	for _, c := range changes {
+		if c.Action != ActionUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+func deleteChange(kind, name string, current interface{}) *Change {
+	return &Change{Action: ActionDelete, Kind: kind, Name: name, Current: current}
+}
+
+// newChange returns a create change if there's no current resource, otherwise an update or unchanged
+// change depending on the fields
+func newChange(kind, name string, desired, current interface{}, fields []*FieldChange) *Change {
+	c := &Change{Kind: kind, Name: name, Desired: desired, Current: current, Fields: fields}
+	switch {
+	case reflect.ValueOf(current).IsNil():
+		c.Action = ActionCreate
+		c.Current = nil
+	case len(fields) > 0:
+		c.Action = ActionUpdate
+	default:
+		c.Action = ActionUnchanged
+	}
+	return c
+}
+
+func planSecret(d, c *Secret) *Change {
+	if c == nil {
+		return newChange(KindSecret, d.Name, d, c, nil)
+	}
+
+	var fields []*FieldChange
+	if d.Description != "" && d.Description != c.Description {
+		fields = append(fields, &FieldChange{Field: "description", Old: c.Description, New: d.Description})
+	}
+
+	if d.Managed() && d.Value != c.Value {
+		fields = append(fields, &FieldChange{Field: "value", Sensitive: true})
+	}
+
+	return newChange(KindSecret, d.Name, d, c, fields)
+}
+
+func planStorage(d, c *Storage) *Change {
+	if c == nil {
+		return newChange(KindStorage, d.Name, d, c, nil)
+	}
+
+	var fields []*FieldChange
+	if d.SizeID != 0 && d.SizeID != c.SizeID {
+		fields = append(fields, &FieldChange{Field: "sizeId", Old: c.SizeID, New: d.SizeID})
+	}
+
+	if d.Users != nil && !sameStrings(d.Users, c.Users) {
+		fields = append(fields, &FieldChange{Field: "users", Old: sorted(c.Users), New: sorted(d.Users)})
+	}
+
+	return newChange(KindStorage, d.Name, d, c, fields)
+}
+
+func planDatabase(d, c *Database) *Change {
+	if c == nil {
+		return newChange(KindDatabase, d.Name, d, c, nil)
+	}
+
+	var fields []*FieldChange
+	if d.SizeID != 0 && d.SizeID != c.SizeID {
+		fields = append(fields, &FieldChange{Field: "sizeId", Old: c.SizeID, New: d.SizeID})
+	}
+
+	return newChange(KindDatabase, d.Name, d, c, fields)
+}
+
+func planContainer(d, c *ContainerService) *Change {
+	if c == nil {
+		return newChange(KindContainer, d.Name, d, c, nil)
+	}
+
+	var fields []*FieldChange
+	if d.SizeID != 0 && d.SizeID != c.SizeID {
+		fields = append(fields, &FieldChange{Field: "sizeId", Old: c.SizeID, New: d.SizeID})
+	}
+
+	if d.DesiredCount != nil && (c.DesiredCount == nil || *d.DesiredCount != *c.DesiredCount) {
+		f := &FieldChange{Field: "desiredCount", New: *d.DesiredCount}
+		if c.DesiredCount != nil {
+			f.Old = *c.DesiredCount
+		}
+		fields = append(fields, f)
+	}
+
+	if d.Capacity != "" && d.Capacity != c.Capacity {
+		fields = append(fields, &FieldChange{Field: "capacity", Old: c.Capacity, New: d.Capacity})
+	}
+
+	current := map[string]*Container{}
+	for _, cd := range c.Definitions {
+		current[cd.Name] = cd
+	}
+
+	keep := map[string]bool{}
+	for _, dd := range d.Definitions {
+		keep[dd.Name] = true
+
+		prefix := "definitions." + dd.Name
+		cd, ok := current[dd.Name]
+		if !ok {
+			fields = append(fields, &FieldChange{Field: prefix, New: dd})
+			continue
+		}
+
+		if dd.Image != cd.Image {
+			fields = append(fields, &FieldChange{Field: prefix + ".image", Old: cd.Image, New: dd.Image})
+		}
+
+		if dd.Command != nil && !reflect.DeepEqual(dd.Command, cd.Command) {
+			fields = append(fields, &FieldChange{Field: prefix + ".command", Old: cd.Command, New: dd.Command})
+		}
+
+		if dd.Ports != nil && !sameStrings(normalizePorts(dd.Ports), normalizePorts(cd.Ports)) {
+			fields = append(fields, &FieldChange{Field: prefix + ".ports", Old: sorted(normalizePorts(cd.Ports)), New: sorted(normalizePorts(dd.Ports))})
+		}
+
+		if dd.Env != nil {
+			fields = append(fields, diffMap(prefix+".env", cd.Env, dd.Env)...)
+		}
+
+		if dd.Secrets != nil {
+			fields = append(fields, diffMap(prefix+".secrets", cd.Secrets, dd.Secrets)...)
+		}
+	}
+
+	for _, cd := range c.Definitions {
+		if !keep[cd.Name] {
+			fields = append(fields, &FieldChange{Field: "definitions." + cd.Name, Old: cd})
+		}
+	}
+
+	return newChange(KindContainer, d.Name, d, c, fields)
+}
+
+// diffMap returns a field change for each key that's added, removed or changed
+func diffMap(prefix string, old, new map[string]string) []*FieldChange {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+
+	var fields []*FieldChange
+	for _, k := range sortedKeys(keys) {
+		o, inOld := old[k]
+		n, inNew := new[k]
+
+		switch {
+		case !inOld:
+			fields = append(fields, &FieldChange{Field: prefix + "." + k, New: n})
+		case !inNew:
+			fields = append(fields, &FieldChange{Field: prefix + "." + k, Old: o})
+		case o != n:
+			fields = append(fields, &FieldChange{Field: prefix + "." + k, Old: o, New: n})
+		}
+	}
+
+	return fields
+}
+
+// normalizePorts adds the default protocol to ports
+func normalizePorts(ports []string) []string {
+	out := make([]string, 0, len(ports))
+	for _, p := range ports {
+		port, proto, err := ParsePort(p)
+		if err != nil {
+			out = append(out, p)
+			continue
+		}
+		out = append(out, FormatPort(port, proto))
+	}
+	return out
+}
+
+func sameStrings(a, b []string) bool {
+	return reflect.DeepEqual(sorted(a), sorted(b))
+}
+
+func sorted(s []string) []string {
+	out := append([]string{}, s...)
+	sort.Strings(out)
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,177 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func int64Ptr(i int64) *int64 { return &i }
+
+func summarize(changes []*Change) []string {
+	out := []string{}
+	for _, c := range changes {
+		out = append(out, string(c.Action)+" "+c.Kind+" "+c.Name)
+	}
+	return out
+}
+
+func currentState() *Manifest {
+	return &Manifest{
+		Secrets: []*Secret{
+			{Name: "db-password", Value: "old"},
+			{Name: "unused"},
+		},
+		Containers: []*ContainerService{
+			{
+				Name:         "web",
+				SizeID:       12,
+				DesiredCount: int64Ptr(1),
+				Capacity:     CapacityOnDemand,
+				Definitions: []*Container{
+					{
+						Name:    "app",
+						Image:   "nginx:1.24",
+						Command: []string{"nginx"},
+						Ports:   []string{"8080/tcp"},
+						Env:     map[string]string{"LOG_LEVEL": "debug", "OLD": "x"},
+					},
+					{Name: "sidecar", Image: "envoy"},
+				},
+			},
+			{Name: "worker", Definitions: []*Container{{Name: "worker", Image: "worker"}}},
+		},
+		Storage: []*Storage{
+			{Name: "assets", SizeID: 1, Users: []string{"deploy"}},
+		},
+		Databases: []*Database{
+			{Name: "appdb", SizeID: 3},
+			{Name: "olddb", SizeID: 3},
+		},
+	}
+}
+
+func TestPlanNoPrune(t *testing.T) {
+	desired := &Manifest{
+		Secrets: []*Secret{
+			{Name: "db-password", FromEnv: "DB_PASSWORD", Value: "new"},
+			{Name: "api-key"},
+		},
+		Containers: []*ContainerService{
+			{
+				Name:         "web",
+				DesiredCount: int64Ptr(2),
+				Capacity:     CapacitySpot,
+				Definitions: []*Container{
+					{
+						Name:    "app",
+						Image:   "nginx:1.25",
+						Ports:   []string{"8080"},
+						Env:     map[string]string{"LOG_LEVEL": "info"},
+						Secrets: map[string]string{"DB_PASSWORD": "db-password"},
+					},
+				},
+			},
+		},
+		Storage: []*Storage{
+			{Name: "assets"},
+		},
+		Databases: []*Database{
+			{Name: "appdb", SizeID: 3},
+		},
+	}
+
+	changes := Plan(desired, currentState(), false)
+
+	expected := []string{
+		"update secret db-password",
+		"create secret api-key",
+		"unchanged storage assets",
+		"unchanged database appdb",
+		"update container web",
+	}
+	if out := summarize(changes); !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, out)
+	}
+
+	if f := changes[0].Fields; len(f) != 1 || !f[0].Sensitive || f[0].New != nil {
+		t.Errorf("expected a sensitive value change, got %+v", f)
+	}
+
+	fields := map[string][2]interface{}{}
+	for _, f := range changes[4].Fields {
+		fields[f.Field] = [2]interface{}{f.Old, f.New}
+	}
+
+	expectedFields := map[string][2]interface{}{
+		"desiredCount":                        {int64(1), int64(2)},
+		"capacity":                            {CapacityOnDemand, CapacitySpot},
+		"definitions.app.image":               {"nginx:1.24", "nginx:1.25"},
+		"definitions.app.env.LOG_LEVEL":       {"debug", "info"},
+		"definitions.app.env.OLD":             {"x", nil},
+		"definitions.app.secrets.DB_PASSWORD": {nil, "db-password"},
+		"definitions.sidecar":                 {currentState().Containers[0].Definitions[1], nil},
+	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected fields %+v, got %+v", expectedFields, fields)
+	}
+
+	if HasChanges(changes[2:4]) {
+		t.Error("expected no changes for unchanged resources")
+	}
+
+	if !HasChanges(changes) {
+		t.Error("expected changes")
+	}
+}
+
+func TestPlanPrune(t *testing.T) {
+	desired := &Manifest{
+		Secrets: []*Secret{
+			{Name: "db-password"},
+		},
+		Containers: []*ContainerService{},
+		Databases: []*Database{
+			{Name: "appdb"},
+		},
+	}
+
+	changes := Plan(desired, currentState(), true)
+
+	// storage isn't in the manifest so it's left alone, deletes come last in reverse dependency order
+	expected := []string{
+		"unchanged secret db-password",
+		"unchanged database appdb",
+		"delete container web",
+		"delete container worker",
+		"delete database olddb",
+		"delete secret unused",
+	}
+	if out := summarize(changes); !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, out)
+	}
+
+	if changes[2].Desired != nil || changes[2].Current == nil {
+		t.Errorf("expected only the current resource for a delete, got %+v", changes[2])
+	}
+}
+
+func TestPlanCreate(t *testing.T) {
+	desired := &Manifest{
+		Storage: []*Storage{{Name: "new", Users: []string{"b", "a"}}},
+	}
+
+	changes := Plan(desired, &Manifest{}, true)
+	if len(changes) != 1 || changes[0].Action != ActionCreate || changes[0].Current != nil {
+		t.Fatalf("expected a create change, got %+v", changes)
+	}
+
+	current := &Manifest{
+		Storage: []*Storage{{Name: "new", Users: []string{"a", "c"}}},
+	}
+
+	changes = Plan(desired, current, false)
+	expected := []*FieldChange{{Field: "users", Old: []string{"a", "c"}, New: []string{"a", "b"}}}
+	if !reflect.DeepEqual(changes[0].Fields, expected) {
+		t.Errorf("expected %+v, got %+v", expected, changes[0].Fields)
+	}
+}
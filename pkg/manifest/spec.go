@@ -0,0 +1,617 @@
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceSpec is the editable definition of a container service: its desired count, capacity, volumes and
+// container definitions
+type ServiceSpec struct {
+	DesiredCount      int64                   `yaml:"desiredCount" json:"desiredCount"`
+	CapacityProviders []*CapacityProviderSpec `yaml:"capacityProviders,omitempty" json:"capacityProviders,omitempty"`
+	Volumes           []*VolumeSpec           `yaml:"volumes,omitempty" json:"volumes,omitempty"`
+	Containers        []*ContainerSpec        `yaml:"containers" json:"containers"`
+}
+
+// CapacityProviderSpec is an item in the capacity provider strategy of a container service
+type CapacityProviderSpec struct {
+	Name   string `yaml:"name" json:"name"`
+	Base   int64  `yaml:"base" json:"base"`
+	Weight int64  `yaml:"weight" json:"weight"`
+}
+
+// VolumeSpec is a volume that can be mounted in the containers
+type VolumeSpec struct {
+	Name string         `yaml:"name" json:"name"`
+	EFS  *EFSVolumeSpec `yaml:"efs,omitempty" json:"efs,omitempty"`
+}
+
+// EFSVolumeSpec is an EFS filesystem volume
+type EFSVolumeSpec struct {
+	FileSystemID          string `yaml:"fileSystemId" json:"fileSystemId"`
+	RootDirectory         string `yaml:"rootDirectory,omitempty" json:"rootDirectory,omitempty"`
+	TransitEncryption     string `yaml:"transitEncryption,omitempty" json:"transitEncryption,omitempty"`
+	TransitEncryptionPort string `yaml:"transitEncryptionPort,omitempty" json:"transitEncryptionPort,omitempty"`
+	AccessPointID         string `yaml:"accessPointId,omitempty" json:"accessPointId,omitempty"`
+	IAM                   string `yaml:"iam,omitempty" json:"iam,omitempty"`
+}
+
+// ContainerSpec is a container definition
+type ContainerSpec struct {
+	Name                   string            `yaml:"name" json:"name"`
+	Image                  string            `yaml:"image" json:"image"`
+	Essential              bool              `yaml:"essential" json:"essential"`
+	Command                []string          `yaml:"command,omitempty" json:"command,omitempty"`
+	EntryPoint             []string          `yaml:"entryPoint,omitempty" json:"entryPoint,omitempty"`
+	WorkingDirectory       string            `yaml:"workingDirectory,omitempty" json:"workingDirectory,omitempty"`
+	User                   string            `yaml:"user,omitempty" json:"user,omitempty"`
+	CPU                    int64             `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+	Memory                 int64             `yaml:"memory,omitempty" json:"memory,omitempty"`
+	MemoryReservation      int64             `yaml:"memoryReservation,omitempty" json:"memoryReservation,omitempty"`
+	Ports                  []*PortSpec       `yaml:"ports,omitempty" json:"ports,omitempty"`
+	Env                    map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	Secrets                map[string]string `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	HealthCheck            *HealthCheckSpec  `yaml:"healthCheck,omitempty" json:"healthCheck,omitempty"`
+	MountPoints            []*MountPointSpec `yaml:"mountPoints,omitempty" json:"mountPoints,omitempty"`
+	Ulimits                []*UlimitSpec     `yaml:"ulimits,omitempty" json:"ulimits,omitempty"`
+	DependsOn              []*DependencySpec `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
+	DockerLabels           map[string]string `yaml:"dockerLabels,omitempty" json:"dockerLabels,omitempty"`
+	ReadonlyRootFilesystem bool              `yaml:"readonlyRootFilesystem,omitempty" json:"readonlyRootFilesystem,omitempty"`
+	StartTimeout           int64             `yaml:"startTimeout,omitempty" json:"startTimeout,omitempty"`
+	StopTimeout            int64             `yaml:"stopTimeout,omitempty" json:"stopTimeout,omitempty"`
+}
+
+// PortSpec is a port exposed by a container
+type PortSpec struct {
+	ContainerPort int64  `yaml:"containerPort" json:"containerPort"`
+	Protocol      string `yaml:"protocol,omitempty" json:"protocol,omitempty"`
+}
+
+// HealthCheckSpec is the health check of a container, times are in seconds
+type HealthCheckSpec struct {
+	Command     []string `yaml:"command" json:"command"`
+	Interval    int64    `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Retries     int64    `yaml:"retries,omitempty" json:"retries,omitempty"`
+	StartPeriod int64    `yaml:"startPeriod,omitempty" json:"startPeriod,omitempty"`
+	Timeout     int64    `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// MountPointSpec mounts a volume in a container
+type MountPointSpec struct {
+	SourceVolume  string `yaml:"sourceVolume" json:"sourceVolume"`
+	ContainerPath string `yaml:"containerPath" json:"containerPath"`
+	ReadOnly      bool   `yaml:"readOnly,omitempty" json:"readOnly,omitempty"`
+}
+
+// UlimitSpec is a resource limit for a container
+type UlimitSpec struct {
+	Name      string `yaml:"name" json:"name"`
+	SoftLimit int64  `yaml:"softLimit" json:"softLimit"`
+	HardLimit int64  `yaml:"hardLimit" json:"hardLimit"`
+}
+
+// DependencySpec is a container that has to reach a condition before a container starts
+type DependencySpec struct {
+	ContainerName string `yaml:"containerName" json:"containerName"`
+	Condition     string `yaml:"condition" json:"condition"`
+}
+
+// SpecError is a problem with a service spec, at a line of the document when it's known
+type SpecError struct {
+	Line    int
+	Field   string
+	Message string
+
+	path []interface{}
+}
+
+func (e *SpecError) Error() string {
+	msg := e.Message
+	if e.Field != "" {
+		msg = e.Field + ": " + msg
+	}
+
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, msg)
+	}
+	return msg
+}
+
+// errorComment is the prefix of the comments added to a document for its errors
+const errorComment = "# error: "
+
+var (
+	containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,255}$`)
+	yamlLinePattern      = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+)
+
+// dependency conditions
+var dependencyConditions = []string{"START", "COMPLETE", "SUCCESS", "HEALTHY"}
+
+// ParseServiceSpec decodes and validates a service spec document, unknown fields are an error so typos
+// aren't silently ignored.  secretExists reports whether a secret name is in the space, secrets can also be
+// referenced by ARN.  A document without any content returns a nil spec and no errors.
+func ParseServiceSpec(doc []byte, secretExists func(string) bool) (*ServiceSpec, []*SpecError) {
+	root := &yaml.Node{}
+	if err := yaml.Unmarshal(doc, root); err != nil {
+		return nil, yamlErrors(err)
+	}
+
+	if len(root.Content) == 0 {
+		return nil, nil
+	}
+
+	d := yaml.NewDecoder(bytes.NewReader(doc))
+	d.KnownFields(true)
+
+	s := &ServiceSpec{}
+	if err := d.Decode(s); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, yamlErrors(err)
+	}
+
+	errs := s.Validate(secretExists)
+	for _, e := range errs {
+		e.Line = nodeLine(root.Content[0], e.path)
+	}
+
+	return s, errs
+}
+
+// yamlErrors converts decoding errors to spec errors with the line they're on
+func yamlErrors(err error) []*SpecError {
+	msgs := []string{err.Error()}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+
+	errs := make([]*SpecError, 0, len(msgs))
+	for _, msg := range msgs {
+		e := &SpecError{Message: msg}
+		if m := yamlLinePattern.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Message = m[2]
+		}
+		errs = append(errs, e)
+	}
+
+	return errs
+}
+
+// Validate checks the spec for missing, duplicate and invalid values and references to volumes, containers
+// and secrets that don't exist
+func (s *ServiceSpec) Validate(secretExists func(string) bool) []*SpecError {
+	var errs []*SpecError
+	fail := func(path []interface{}, format string, a ...interface{}) {
+		errs = append(errs, &SpecError{Field: fieldPath(path), Message: fmt.Sprintf(format, a...), path: path})
+	}
+
+	if s.DesiredCount < 0 {
+		fail([]interface{}{"desiredCount"}, "must be 0 or more")
+	}
+
+	for i, cp := range s.CapacityProviders {
+		path := []interface{}{"capacityProviders", i}
+		if cp.Name == "" {
+			fail(path, "name is required")
+		}
+
+		if cp.Base < 0 || cp.Weight < 0 {
+			fail(path, "base and weight must be 0 or more")
+		}
+	}
+
+	volumes := map[string]bool{}
+	for i, v := range s.Volumes {
+		path := []interface{}{"volumes", i}
+		switch {
+		case v.Name == "":
+			fail(path, "name is required")
+		case volumes[v.Name]:
+			fail(append(path, "name"), "duplicate volume %s", v.Name)
+		}
+		volumes[v.Name] = true
+
+		if v.EFS != nil && v.EFS.FileSystemID == "" {
+			fail(append(path, "efs"), "fileSystemId is required")
+		}
+	}
+
+	if len(s.Containers) == 0 {
+		fail([]interface{}{"containers"}, "at least one container is required")
+	}
+
+	containers := map[string]*ContainerSpec{}
+	essential := false
+	for i, c := range s.Containers {
+		path := []interface{}{"containers", i}
+		switch {
+		case c.Name == "":
+			fail(path, "name is required")
+		case !containerNamePattern.MatchString(c.Name):
+			fail(append(path, "name"), "invalid container name %q, only letters, numbers, hyphens and underscores are allowed", c.Name)
+		case containers[c.Name] != nil:
+			fail(append(path, "name"), "duplicate container %s", c.Name)
+		}
+		containers[c.Name] = c
+
+		if c.Essential {
+			essential = true
+		}
+	}
+
+	if len(s.Containers) > 0 && !essential {
+		fail([]interface{}{"containers"}, "at least one container must be essential")
+	}
+
+	for i, c := range s.Containers {
+		errs = append(errs, c.validate([]interface{}{"containers", i}, volumes, containers, secretExists)...)
+	}
+
+	return errs
+}
+
+func (c *ContainerSpec) validate(path []interface{}, volumes map[string]bool, containers map[string]*ContainerSpec, secretExists func(string) bool) []*SpecError {
+	var errs []*SpecError
+	fail := func(field []interface{}, format string, a ...interface{}) {
+		p := append(append([]interface{}{}, path...), field...)
+		errs = append(errs, &SpecError{Field: fieldPath(p), Message: fmt.Sprintf(format, a...), path: p})
+	}
+
+	if c.Image == "" {
+		fail([]interface{}{}, "image is required")
+	}
+
+	if c.CPU < 0 || c.Memory < 0 || c.MemoryReservation < 0 {
+		fail([]interface{}{}, "cpu, memory and memoryReservation must be 0 or more")
+	}
+
+	if c.Memory > 0 && c.MemoryReservation > c.Memory {
+		fail([]interface{}{"memoryReservation"}, "must not be more than memory (%d)", c.Memory)
+	}
+
+	ports := map[string]bool{}
+	for i, p := range c.Ports {
+		if p.ContainerPort < 1 || p.ContainerPort > 65535 {
+			fail([]interface{}{"ports", i, "containerPort"}, "must be between 1 and 65535, got %d", p.ContainerPort)
+		}
+
+		proto := strings.ToLower(p.Protocol)
+		if proto == "" {
+			proto = "tcp"
+		}
+
+		if proto != "tcp" && proto != "udp" {
+			fail([]interface{}{"ports", i, "protocol"}, "must be tcp or udp, got %q", p.Protocol)
+		}
+
+		port := FormatPort(p.ContainerPort, proto)
+		if ports[port] {
+			fail([]interface{}{"ports", i}, "duplicate port %s", port)
+		}
+		ports[port] = true
+	}
+
+	for _, k := range sortedMapKeys(c.Env) {
+		if strings.TrimSpace(k) == "" {
+			fail([]interface{}{"env"}, "environment variable names can't be empty")
+		}
+	}
+
+	for _, k := range sortedMapKeys(c.Secrets) {
+		if _, ok := c.Env[k]; ok {
+			fail([]interface{}{"secrets", k}, "%s is also set in env", k)
+		}
+
+		name := c.Secrets[k]
+		if name == "" {
+			fail([]interface{}{"secrets", k}, "a secret name is required")
+			continue
+		}
+
+		if !strings.HasPrefix(name, "arn:") && secretExists != nil && !secretExists(name) {
+			fail([]interface{}{"secrets", k}, "secret %s doesn't exist in the space", name)
+		}
+	}
+
+	if hc := c.HealthCheck; hc != nil {
+		if len(hc.Command) < 2 || (hc.Command[0] != "CMD" && hc.Command[0] != "CMD-SHELL") {
+			fail([]interface{}{"healthCheck", "command"}, "must start with CMD or CMD-SHELL followed by the command")
+		}
+
+		if hc.Interval < 0 || hc.Retries < 0 || hc.StartPeriod < 0 || hc.Timeout < 0 {
+			fail([]interface{}{"healthCheck"}, "interval, retries, startPeriod and timeout must be 0 or more")
+		}
+	}
+
+	for i, m := range c.MountPoints {
+		if !volumes[m.SourceVolume] {
+			fail([]interface{}{"mountPoints", i, "sourceVolume"}, "volume %q isn't defined in volumes", m.SourceVolume)
+		}
+
+		if !strings.HasPrefix(m.ContainerPath, "/") {
+			fail([]interface{}{"mountPoints", i, "containerPath"}, "must be an absolute path, got %q", m.ContainerPath)
+		}
+	}
+
+	for i, u := range c.Ulimits {
+		if u.Name == "" {
+			fail([]interface{}{"ulimits", i}, "name is required")
+		}
+
+		if u.SoftLimit > u.HardLimit {
+			fail([]interface{}{"ulimits", i, "softLimit"}, "must not be more than hardLimit (%d)", u.HardLimit)
+		}
+	}
+
+	for i, d := range c.DependsOn {
+		dep, ok := containers[d.ContainerName]
+		switch {
+		case !ok:
+			fail([]interface{}{"dependsOn", i, "containerName"}, "container %q isn't defined", d.ContainerName)
+		case d.ContainerName == c.Name:
+			fail([]interface{}{"dependsOn", i, "containerName"}, "a container can't depend on itself")
+		}
+
+		valid := false
+		for _, cond := range dependencyConditions {
+			if d.Condition == cond {
+				valid = true
+			}
+		}
+
+		if !valid {
+			fail([]interface{}{"dependsOn", i, "condition"}, "must be one of %s, got %q", strings.Join(dependencyConditions, ", "), d.Condition)
+		} else if ok && d.Condition == "HEALTHY" && dep.HealthCheck == nil {
+			fail([]interface{}{"dependsOn", i, "condition"}, "container %s doesn't have a health check", d.ContainerName)
+		}
+	}
+
+	return errs
+}
+
+// fieldPath formats a path like containers[0].ports[1]
+func fieldPath(path []interface{}) string {
+	var b strings.Builder
+	for _, p := range path {
+		switch v := p.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", v)
+		case string:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
+
+// nodeLine returns the line of the deepest node on the path that's in the document.  Fields are located at
+// their key, so errors are shown next to it.
+func nodeLine(n *yaml.Node, path []interface{}) int {
+	line := n.Line
+	for _, p := range path {
+		switch v := p.(type) {
+		case int:
+			if n.Kind != yaml.SequenceNode || v >= len(n.Content) {
+				return line
+			}
+			n = n.Content[v]
+			line = n.Line
+		case string:
+			if n.Kind != yaml.MappingNode {
+				return line
+			}
+
+			found := false
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == v {
+					line = n.Content[i].Line
+					n = n.Content[i+1]
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				return line
+			}
+		}
+	}
+	return line
+}
+
+// Encode writes the spec as YAML
+func (s *ServiceSpec) Encode(w io.Writer) error {
+	e := yaml.NewEncoder(w)
+	e.SetIndent(2)
+
+	if err := e.Encode(s); err != nil {
+		return err
+	}
+
+	return e.Close()
+}
+
+// StripErrorComments removes the error comments added by AnnotateErrors
+func StripErrorComments(doc []byte) []byte {
+	out := &bytes.Buffer{}
+	scanner := bufio.NewScanner(bytes.NewReader(doc))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(doc)+1)
+	for scanner.Scan() {
+		if strings.HasPrefix(strings.TrimSpace(scanner.Text()), errorComment) {
+			continue
+		}
+		out.WriteString(scanner.Text())
+		out.WriteString("\n")
+	}
+	return out.Bytes()
+}
+
+// AnnotateErrors adds a comment for each error above the line it's on, indented to match it.  Errors
+// without a line are added to the top of the document.
+func AnnotateErrors(doc []byte, errs []*SpecError) []byte {
+	lines := strings.Split(strings.TrimSuffix(string(doc), "\n"), "\n")
+
+	above := map[int][]string{}
+	for _, e := range errs {
+		line := e.Line
+		if line < 1 || line > len(lines) {
+			line = 0
+		}
+
+		msg := e.Message
+		if e.Field != "" {
+			msg = e.Field + ": " + msg
+		}
+		above[line] = append(above[line], msg)
+	}
+
+	out := &bytes.Buffer{}
+	for _, msg := range above[0] {
+		out.WriteString(errorComment + msg + "\n")
+	}
+
+	for i, l := range lines {
+		indent := l[:len(l)-len(strings.TrimLeft(l, " "))]
+		for _, msg := range above[i+1] {
+			out.WriteString(indent + errorComment + msg + "\n")
+		}
+		out.WriteString(l + "\n")
+	}
+
+	return out.Bytes()
+}
+
+// DiffServiceSpec returns the fields that changed between two specs.  Containers, volumes and capacity
+// providers are matched by name, other lists are compared as a whole.
+func DiffServiceSpec(old, new *ServiceSpec) ([]*FieldChange, error) {
+	o, err := flattenSpec(old)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := flattenSpec(new)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]bool{}
+	for k := range o {
+		keys[k] = true
+	}
+	for k := range n {
+		keys[k] = true
+	}
+
+	var fields []*FieldChange
+	for _, k := range sortedKeys(keys) {
+		ov, inOld := o[k]
+		nv, inNew := n[k]
+
+		switch {
+		case !inOld:
+			fields = append(fields, &FieldChange{Field: k, New: nv})
+		case !inNew:
+			fields = append(fields, &FieldChange{Field: k, Old: ov})
+		case !reflect.DeepEqual(ov, nv):
+			fields = append(fields, &FieldChange{Field: k, Old: ov, New: nv})
+		}
+	}
+
+	return fields, nil
+}
+
+// flattenSpec returns the leaf values of a spec by their path, like containers.web.image
+func flattenSpec(s *ServiceSpec) (map[string]interface{}, error) {
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{}
+	flatten("", doc, out)
+	return out, nil
+}
+
+func flatten(prefix string, v interface{}, out map[string]interface{}) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			flatten(join(k), child, out)
+		}
+	case []interface{}:
+		if named, ok := namedItems(t); ok && (strings.HasSuffix(prefix, "containers") || strings.HasSuffix(prefix, "volumes") || strings.HasSuffix(prefix, "capacityProviders")) {
+			for name, item := range named {
+				flatten(join(name), item, out)
+			}
+			return
+		}
+		out[prefix] = t
+	default:
+		out[prefix] = t
+	}
+}
+
+// namedItems returns the items of a list of mappings by their name, if they all have a unique name
+func namedItems(items []interface{}) (map[string]interface{}, bool) {
+	named := map[string]interface{}{}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		name, ok := m["name"].(string)
+		if !ok || name == "" || named[name] != nil {
+			return nil, false
+		}
+
+		rest := map[string]interface{}{}
+		for k, v := range m {
+			if k != "name" {
+				rest[k] = v
+			}
+		}
+		named[name] = rest
+	}
+	return named, true
+}
+
+// sortedMapKeys returns the keys of a map in order
+func sortedMapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
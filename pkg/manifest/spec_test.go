@@ -0,0 +1,174 @@
+package manifest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSpec = `desiredCount: 2
+volumes:
+  - name: data
+    efs:
+      fileSystemId: fs-123
+containers:
+  - name: app
+    image: nginx:1.25
+    essential: true
+    ports:
+      - containerPort: 8080
+    secrets:
+      DB_PASSWORD: db-password
+    mountPoints:
+      - sourceVolume: data
+        containerPath: /data
+  - name: sidecar
+    image: envoy
+    dependsOn:
+      - containerName: app
+        condition: START
+`
+
+func secretExists(name string) bool {
+	return name == "db-password"
+}
+
+func TestParseServiceSpec(t *testing.T) {
+	s, errs := ParseServiceSpec([]byte(testSpec), secretExists)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+
+	if s.DesiredCount != 2 || len(s.Containers) != 2 || s.Containers[0].Ports[0].ContainerPort != 8080 || s.Volumes[0].EFS.FileSystemID != "fs-123" {
+		t.Errorf("unexpected spec %+v", s)
+	}
+
+	s, errs = ParseServiceSpec([]byte("# nothing here\n"), secretExists)
+	if s != nil || errs != nil {
+		t.Errorf("expected nil spec and errors for an empty document, got %+v, %v", s, errs)
+	}
+}
+
+func TestParseServiceSpecErrors(t *testing.T) {
+	doc := strings.NewReplacer(
+		"containerPort: 8080", "containerPort: http",
+		"image: envoy", "image: envoy\n    imagee: typo",
+	).Replace(testSpec)
+
+	_, errs := ParseServiceSpec([]byte(doc), secretExists)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+
+	if errs[0].Line != 11 || !strings.Contains(errs[0].Message, "int64") {
+		t.Errorf("expected a type error on line 11, got %s", errs[0])
+	}
+
+	if errs[1].Line != 19 || !strings.Contains(errs[1].Message, "imagee") {
+		t.Errorf("expected an unknown field error on line 19, got %s", errs[1])
+	}
+
+	doc = strings.NewReplacer(
+		"containerPort: 8080", "containerPort: 70000",
+		"DB_PASSWORD: db-password", "DB_PASSWORD: missing",
+		"sourceVolume: data", "sourceVolume: logs",
+		"containerName: app", "containerName: web",
+	).Replace(testSpec)
+
+	_, errs = ParseServiceSpec([]byte(doc), secretExists)
+
+	got := map[int]string{}
+	for _, e := range errs {
+		got[e.Line] = e.Field
+	}
+
+	expected := map[int]string{
+		11: "containers[0].ports[0].containerPort",
+		13: "containers[0].secrets.DB_PASSWORD",
+		15: "containers[0].mountPoints[0].sourceVolume",
+		20: "containers[1].dependsOn[0].containerName",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected errors %v, got %v", expected, errs)
+	}
+}
+
+func TestServiceSpecValidate(t *testing.T) {
+	s := &ServiceSpec{
+		Containers: []*ContainerSpec{
+			{Name: "app", Image: "app", Ports: []*PortSpec{{ContainerPort: 80, Protocol: "sctp"}}},
+			{Name: "app", Image: "app", Ulimits: []*UlimitSpec{{Name: "nofile", SoftLimit: 2048, HardLimit: 1024}}},
+		},
+	}
+
+	var got []string
+	for _, e := range s.Validate(nil) {
+		got = append(got, e.Field)
+	}
+
+	expected := []string{
+		"containers[1].name",
+		"containers",
+		"containers[0].ports[0].protocol",
+		"containers[1].ulimits[0].softLimit",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected errors for %v, got %v", expected, got)
+	}
+}
+
+func TestAnnotateErrors(t *testing.T) {
+	doc := []byte("containers:\n  - name: app\n    image: nginx\n")
+	errs := []*SpecError{
+		{Line: 3, Field: "containers[0].image", Message: "bad image"},
+		{Message: "something else"},
+	}
+
+	annotated := AnnotateErrors(doc, errs)
+	expected := "# error: something else\ncontainers:\n  - name: app\n    # error: containers[0].image: bad image\n    image: nginx\n"
+	if string(annotated) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, annotated)
+	}
+
+	if stripped := StripErrorComments(annotated); !bytes.Equal(stripped, doc) {
+		t.Errorf("expected\n%s\ngot\n%s", doc, stripped)
+	}
+}
+
+func TestDiffServiceSpec(t *testing.T) {
+	old, _ := ParseServiceSpec([]byte(testSpec), secretExists)
+	new, _ := ParseServiceSpec([]byte(testSpec), secretExists)
+
+	new.DesiredCount = 3
+	new.Containers[0].Image = "nginx:1.26"
+	new.Containers[0].Env = map[string]string{"LOG_LEVEL": "info"}
+	new.Containers = append(new.Containers[:1], &ContainerSpec{Name: "worker", Image: "worker"})
+
+	fields, err := DiffServiceSpec(old, new)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.Field)
+	}
+
+	expected := []string{
+		"containers.app.env.LOG_LEVEL",
+		"containers.app.image",
+		"containers.sidecar.dependsOn",
+		"containers.sidecar.essential",
+		"containers.sidecar.image",
+		"containers.worker.essential",
+		"containers.worker.image",
+		"desiredCount",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected changed fields %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package session implements the client side of the data channel for container exec sessions.  The
+// channel is a websocket carrying the binary agent messages of the SSM session manager protocol, which
+// are acknowledged and ordered by sequence number.
+package session
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// message types
+const (
+	InputStreamData  = "input_stream_data"
+	OutputStreamData = "output_stream_data"
+	Acknowledge      = "acknowledge"
+	ChannelClosed    = "channel_closed"
+	StartPublication = "start_publication"
+	PausePublication = "pause_publication"
+)
+
+// PayloadType is the type of the payload of a stream data message
+type PayloadType uint32
+
+// payload types
+const (
+	PayloadOutput            PayloadType = 1
+	PayloadError             PayloadType = 2
+	PayloadSize              PayloadType = 3
+	PayloadParameter         PayloadType = 4
+	PayloadHandshakeRequest  PayloadType = 5
+	PayloadHandshakeResponse PayloadType = 6
+	PayloadHandshakeComplete PayloadType = 7
+	PayloadFlag              PayloadType = 10
+	PayloadStdErr            PayloadType = 11
+	PayloadExitCode          PayloadType = 12
+)
+
+const (
+	// schemaVersion is the version of the agent message format
+	schemaVersion = 1
+
+	// headerLength is the length of the message header, which doesn't include the payload length
+	headerLength = 116
+
+	messageTypeLength = 32
+)
+
+// Message is an agent message
+type Message struct {
+	MessageType    string
+	SchemaVersion  uint32
+	CreatedDate    time.Time
+	SequenceNumber int64
+	Flags          uint64
+	MessageID      uuid.UUID
+	PayloadType    PayloadType
+	Payload        []byte
+}
+
+// NewMessage returns a message of the given type with a new id
+func NewMessage(messageType string, seq int64, payloadType PayloadType, payload []byte) *Message {
+	return &Message{
+		MessageType:    messageType,
+		SchemaVersion:  schemaVersion,
+		CreatedDate:    time.Now(),
+		SequenceNumber: seq,
+		MessageID:      uuid.New(),
+		PayloadType:    payloadType,
+		Payload:        payload,
+	}
+}
+
+// MarshalBinary encodes the message.  All integers are big endian and the message type is padded with
+// spaces.
+func (m *Message) MarshalBinary() ([]byte, error) {
+	if len(m.MessageType) > messageTypeLength {
+		return nil, fmt.Errorf("message type %q is longer than %d bytes", m.MessageType, messageTypeLength)
+	}
+
+	buf := &bytes.Buffer{}
+	buf.Grow(headerLength + 4 + len(m.Payload))
+
+	digest := sha256.Sum256(m.Payload)
+
+	binary.Write(buf, binary.BigEndian, uint32(headerLength))
+	buf.WriteString(m.MessageType + strings.Repeat(" ", messageTypeLength-len(m.MessageType)))
+	binary.Write(buf, binary.BigEndian, m.SchemaVersion)
+	binary.Write(buf, binary.BigEndian, uint64(m.CreatedDate.UnixMilli()))
+	binary.Write(buf, binary.BigEndian, m.SequenceNumber)
+	binary.Write(buf, binary.BigEndian, m.Flags)
+	buf.Write(swapUUID(m.MessageID))
+	buf.Write(digest[:])
+	binary.Write(buf, binary.BigEndian, uint32(m.PayloadType))
+	binary.Write(buf, binary.BigEndian, uint32(len(m.Payload)))
+	buf.Write(m.Payload)
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a message and verifies the digest of its payload
+func (m *Message) UnmarshalBinary(data []byte) error {
+	if len(data) < headerLength+4 {
+		return fmt.Errorf("message is too short, %d bytes", len(data))
+	}
+
+	hl := binary.BigEndian.Uint32(data[0:4])
+	if hl < headerLength || int(hl)+4 > len(data) {
+		return fmt.Errorf("invalid message header length %d", hl)
+	}
+
+	m.MessageType = strings.TrimRight(string(bytes.TrimRight(data[4:36], "\x00")), " ")
+	m.SchemaVersion = binary.BigEndian.Uint32(data[36:40])
+	m.CreatedDate = time.UnixMilli(int64(binary.BigEndian.Uint64(data[40:48])))
+	m.SequenceNumber = int64(binary.BigEndian.Uint64(data[48:56]))
+	m.Flags = binary.BigEndian.Uint64(data[56:64])
+
+	id, err := uuid.FromBytes(swapUUIDBytes(data[64:80]))
+	if err != nil {
+		return err
+	}
+	m.MessageID = id
+
+	var digest [32]byte
+	copy(digest[:], data[80:112])
+	m.PayloadType = PayloadType(binary.BigEndian.Uint32(data[112:116]))
+
+	length := binary.BigEndian.Uint32(data[hl : hl+4])
+	payload := data[hl+4:]
+	if uint32(len(payload)) != length {
+		return fmt.Errorf("message payload is %d bytes, expected %d", len(payload), length)
+	}
+
+	if sha256.Sum256(payload) != digest {
+		return errors.New("message payload doesn't match its digest")
+	}
+	m.Payload = payload
+
+	return nil
+}
+
+// swapUUID returns the bytes of a message id, which the protocol sends with the least significant
+// half first
+func swapUUID(id uuid.UUID) []byte {
+	return swapUUIDBytes(id[:])
+}
+
+func swapUUIDBytes(b []byte) []byte {
+	out := make([]byte, 16)
+	copy(out[0:8], b[8:16])
+	copy(out[8:16], b[0:8])
+	return out
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMessageMarshalBinary(t *testing.T) {
+	msg := &Message{
+		MessageType:    InputStreamData,
+		SchemaVersion:  1,
+		CreatedDate:    time.UnixMilli(1700000000123),
+		SequenceNumber: 7,
+		Flags:          0,
+		MessageID:      uuid.MustParse("00112233-4455-6677-8899-aabbccddeeff"),
+		PayloadType:    PayloadOutput,
+		Payload:        []byte("ls -la\n"),
+	}
+
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(data) != headerLength+4+len(msg.Payload) {
+		t.Fatalf("expected %d bytes, got %d", headerLength+4+len(msg.Payload), len(data))
+	}
+
+	if mt := string(data[4:36]); mt != "input_stream_data               " {
+		t.Errorf("expected a space padded message type, got %q", mt)
+	}
+
+	// the least significant half of the message id is sent first
+	if id := data[64:80]; !bytes.Equal(id, []byte{0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77}) {
+		t.Errorf("unexpected message id bytes %x", id)
+	}
+
+	out := &Message{}
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if out.MessageType != msg.MessageType || out.SchemaVersion != msg.SchemaVersion || !out.CreatedDate.Equal(msg.CreatedDate) ||
+		out.SequenceNumber != msg.SequenceNumber || out.MessageID != msg.MessageID || out.PayloadType != msg.PayloadType ||
+		!bytes.Equal(out.Payload, msg.Payload) {
+		t.Errorf("expected %+v, got %+v", msg, out)
+	}
+}
+
+func TestMessageUnmarshalBinaryErrors(t *testing.T) {
+	data, err := NewMessage(OutputStreamData, 0, PayloadOutput, []byte("hello")).MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if err := (&Message{}).UnmarshalBinary(data[:100]); err == nil {
+		t.Error("expected error for a short message, got nil")
+	}
+
+	if err := (&Message{}).UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected error for a truncated payload, got nil")
+	}
+
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] = 'O'
+	if err := (&Message{}).UnmarshalBinary(tampered); err == nil {
+		t.Error("expected error for a payload that doesn't match its digest, got nil")
+	}
+
+	long := NewMessage("a_message_type_that_is_longer_than_32_bytes", 0, 0, nil)
+	if _, err := long.MarshalBinary(); err == nil {
+		t.Error("expected error for a long message type, got nil")
+	}
+}
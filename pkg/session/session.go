@@ -0,0 +1,420 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/websocket"
+)
+
+// clientVersion is the version of the session manager client whose protocol features are implemented,
+// the agent uses it to decide what it can send
+const clientVersion = "1.2.0.0"
+
+// action statuses in a handshake response
+const (
+	actionSuccess = 1
+	actionFailed  = 2
+)
+
+// inputChunkSize is the largest input sent in a single message
+const inputChunkSize = 1024
+
+// Session is an exec session to connect to
+type Session struct {
+	ID        string
+	StreamURL string
+	Token     string
+}
+
+// Conn is a connected session data channel
+type Conn struct {
+	ws      *websocket.Conn
+	session *Session
+
+	// mu serializes writes to the websocket and the input sequence number
+	mu  sync.Mutex
+	seq int64
+
+	// ready is closed when the handshake completes and input can be sent
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// size is the last terminal size, sent when the handshake completes
+	sizeMu sync.Mutex
+	size   *Size
+
+	// expected is the sequence number of the next output message, later messages are held in pending
+	expected int64
+	pending  map[int64]*Message
+
+	exitCode *int
+}
+
+// Size is the size of a terminal
+type Size struct {
+	Cols int `json:"cols"`
+	Rows int `json:"rows"`
+}
+
+type openDataChannelInput struct {
+	MessageSchemaVersion string
+	RequestId            string
+	TokenValue           string
+	ClientId             string
+	ClientVersion        string
+}
+
+type handshakeRequest struct {
+	AgentVersion           string
+	RequestedClientActions []struct {
+		ActionType       string
+		ActionParameters json.RawMessage
+	}
+}
+
+type processedClientAction struct {
+	ActionType   string
+	ActionStatus int
+	ActionResult json.RawMessage `json:",omitempty"`
+	Error        string          `json:",omitempty"`
+}
+
+type handshakeResponse struct {
+	ClientVersion          string
+	ProcessedClientActions []*processedClientAction
+	Errors                 []string
+}
+
+type acknowledgeContent struct {
+	AcknowledgedMessageType           string
+	AcknowledgedMessageId             string
+	AcknowledgedMessageSequenceNumber int64
+	IsSequentialMessage               bool
+}
+
+type channelClosed struct {
+	MessageId string
+	SessionId string
+	Output    string
+}
+
+// Dial connects to the data channel of the session and opens it with the session token
+func Dial(ctx context.Context, s *Session) (*Conn, error) {
+	config, err := websocket.NewConfig(s.StreamURL, "http://localhost")
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream url: %s", err)
+	}
+
+	log.Infof("connecting to session %s", s.ID)
+
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to session %s: %s", s.ID, err)
+	}
+
+	open, err := json.Marshal(&openDataChannelInput{
+		MessageSchemaVersion: "1.0",
+		RequestId:            uuid.New().String(),
+		TokenValue:           s.Token,
+		ClientId:             uuid.New().String(),
+		ClientVersion:        clientVersion,
+	})
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	if err := websocket.Message.Send(ws, string(open)); err != nil {
+		ws.Close()
+		return nil, fmt.Errorf("failed opening session %s: %s", s.ID, err)
+	}
+
+	return &Conn{
+		ws:      ws,
+		session: s,
+		ready:   make(chan struct{}),
+		pending: map[int64]*Message{},
+	}, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.ws.Close()
+}
+
+// Resize sets the size of the remote terminal, it's sent once the handshake completes
+func (c *Conn) Resize(size Size) error {
+	c.sizeMu.Lock()
+	c.size = &size
+	c.sizeMu.Unlock()
+
+	select {
+	case <-c.ready:
+		return c.sendSize(size)
+	default:
+		return nil
+	}
+}
+
+// Run copies stdin to the session and the output of the session to stdout and stderr, until the session
+// is closed or the context is cancelled.  It returns the exit code of the command when the agent sends it
+// and 0 otherwise.
+func (c *Conn) Run(ctx context.Context, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+		c.ws.Close()
+	}()
+
+	if stdin != nil {
+		go c.copyInput(ctx, stdin)
+	}
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(c.ws, &data); err != nil {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+
+			if c.exitCode != nil {
+				return *c.exitCode, nil
+			}
+
+			if errors.Is(err, io.EOF) {
+				return 0, fmt.Errorf("session %s closed unexpectedly", c.session.ID)
+			}
+
+			return 0, fmt.Errorf("failed reading from session %s: %s", c.session.ID, err)
+		}
+
+		msg := &Message{}
+		if err := msg.UnmarshalBinary(data); err != nil {
+			log.Warnf("ignoring invalid message: %s", err)
+			continue
+		}
+
+		log.Debugf("received %s message %d with payload type %d", msg.MessageType, msg.SequenceNumber, msg.PayloadType)
+
+		switch msg.MessageType {
+		case OutputStreamData:
+			// a failed acknowledgement is resent by the agent, or the connection is gone and the next
+			// read fails
+			if err := c.acknowledge(msg); err != nil {
+				log.Debugf("failed acknowledging message %d: %s", msg.SequenceNumber, err)
+			}
+
+			if err := c.receive(msg, stdout, stderr); err != nil {
+				return 0, err
+			}
+		case ChannelClosed:
+			closed := &channelClosed{}
+			if err := json.Unmarshal(msg.Payload, closed); err == nil && closed.Output != "" {
+				log.Infof("session %s closed: %s", c.session.ID, closed.Output)
+			}
+
+			if c.exitCode != nil {
+				return *c.exitCode, nil
+			}
+			return 0, nil
+		case Acknowledge, StartPublication, PausePublication:
+		default:
+			log.Debugf("ignoring %s message", msg.MessageType)
+		}
+	}
+}
+
+// receive handles output messages in sequence order, messages that arrive early are held until the
+// ones before them arrive and duplicates are dropped
+func (c *Conn) receive(msg *Message, stdout, stderr io.Writer) error {
+	if msg.SequenceNumber < c.expected {
+		log.Debugf("dropping duplicate message %d", msg.SequenceNumber)
+		return nil
+	}
+
+	c.pending[msg.SequenceNumber] = msg
+	for {
+		next, ok := c.pending[c.expected]
+		if !ok {
+			return nil
+		}
+		delete(c.pending, c.expected)
+		c.expected++
+
+		if err := c.handle(next, stdout, stderr); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles an output message by its payload type
+func (c *Conn) handle(msg *Message, stdout, stderr io.Writer) error {
+	switch msg.PayloadType {
+	case PayloadOutput:
+		if _, err := stdout.Write(msg.Payload); err != nil {
+			return err
+		}
+	case PayloadStdErr:
+		if _, err := stderr.Write(msg.Payload); err != nil {
+			return err
+		}
+	case PayloadHandshakeRequest:
+		return c.handshake(msg)
+	case PayloadHandshakeComplete:
+		log.Infof("session %s handshake complete", c.session.ID)
+		c.readyOnce.Do(func() { close(c.ready) })
+
+		c.sizeMu.Lock()
+		size := c.size
+		c.sizeMu.Unlock()
+
+		if size != nil {
+			return c.sendSize(*size)
+		}
+	case PayloadExitCode:
+		code, err := strconv.Atoi(strings.TrimSpace(string(msg.Payload)))
+		if err != nil {
+			log.Warnf("ignoring invalid exit code %q", msg.Payload)
+			return nil
+		}
+		c.exitCode = &code
+	default:
+		log.Debugf("ignoring output with payload type %d", msg.PayloadType)
+	}
+
+	return nil
+}
+
+// handshake answers the handshake request from the agent.  Only plain sessions are supported, an
+// agent that requires encryption will close the session.
+func (c *Conn) handshake(msg *Message) error {
+	req := &handshakeRequest{}
+	if err := json.Unmarshal(msg.Payload, req); err != nil {
+		return fmt.Errorf("invalid handshake request: %s", err)
+	}
+
+	log.Infof("session %s handshake with agent version %s", c.session.ID, req.AgentVersion)
+
+	res := &handshakeResponse{
+		ClientVersion:          clientVersion,
+		ProcessedClientActions: []*processedClientAction{},
+		Errors:                 []string{},
+	}
+
+	for _, a := range req.RequestedClientActions {
+		switch a.ActionType {
+		case "SessionType":
+			res.ProcessedClientActions = append(res.ProcessedClientActions, &processedClientAction{
+				ActionType:   a.ActionType,
+				ActionStatus: actionSuccess,
+			})
+		default:
+			e := fmt.Sprintf("%s isn't supported", a.ActionType)
+			res.ProcessedClientActions = append(res.ProcessedClientActions, &processedClientAction{
+				ActionType:   a.ActionType,
+				ActionStatus: actionFailed,
+				Error:        e,
+			})
+			res.Errors = append(res.Errors, e)
+		}
+	}
+
+	payload, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	return c.sendInput(PayloadHandshakeResponse, payload)
+}
+
+// copyInput sends stdin to the session once the handshake completes
+func (c *Conn) copyInput(ctx context.Context, stdin io.Reader) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-c.ready:
+	}
+
+	buf := make([]byte, inputChunkSize)
+	for {
+		n, err := stdin.Read(buf)
+		if n > 0 {
+			if err := c.sendInput(PayloadOutput, append([]byte{}, buf[:n]...)); err != nil {
+				log.Debugf("failed sending input: %s", err)
+				return
+			}
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				log.Debugf("failed reading input: %s", err)
+			}
+			return
+		}
+	}
+}
+
+func (c *Conn) sendSize(size Size) error {
+	payload, err := json.Marshal(size)
+	if err != nil {
+		return err
+	}
+	return c.sendInput(PayloadSize, payload)
+}
+
+// sendInput sends an input message with the next sequence number
+func (c *Conn) sendInput(payloadType PayloadType, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	msg := NewMessage(InputStreamData, c.seq, payloadType, payload)
+	if err := c.send(msg); err != nil {
+		return err
+	}
+	c.seq++
+
+	return nil
+}
+
+// acknowledge acknowledges an output message
+func (c *Conn) acknowledge(msg *Message) error {
+	payload, err := json.Marshal(&acknowledgeContent{
+		AcknowledgedMessageType:           msg.MessageType,
+		AcknowledgedMessageId:             msg.MessageID.String(),
+		AcknowledgedMessageSequenceNumber: msg.SequenceNumber,
+		IsSequentialMessage:               true,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.send(NewMessage(Acknowledge, 0, 0, payload))
+}
+
+// send writes a message to the websocket, callers must hold mu
+func (c *Conn) send(msg *Message) error {
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	if err := websocket.Message.Send(c.ws, data); err != nil {
+		return fmt.Errorf("failed sending %s message: %s", msg.MessageType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeAgent is the agent end of a session data channel
+type fakeAgent struct {
+	t     *testing.T
+	ws    *websocket.Conn
+	seq   int64
+	input chan *Message
+
+	mu   sync.Mutex
+	acks []int64
+}
+
+func (a *fakeAgent) send(messageType string, seq int64, payloadType PayloadType, payload []byte) {
+	data, err := NewMessage(messageType, seq, payloadType, payload).MarshalBinary()
+	if err != nil {
+		a.t.Errorf("failed marshalling message: %s", err)
+		return
+	}
+
+	if err := websocket.Message.Send(a.ws, data); err != nil {
+		a.t.Errorf("failed sending message: %s", err)
+	}
+}
+
+func (a *fakeAgent) output(payloadType PayloadType, payload string) {
+	a.send(OutputStreamData, a.seq, payloadType, []byte(payload))
+	a.seq++
+}
+
+// read reads messages from the client, acknowledgements are recorded and input is sent to the input channel
+func (a *fakeAgent) read() {
+	defer close(a.input)
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(a.ws, &data); err != nil {
+			return
+		}
+
+		msg := &Message{}
+		if err := msg.UnmarshalBinary(data); err != nil {
+			a.t.Errorf("client sent an invalid message: %s", err)
+			return
+		}
+
+		if msg.MessageType == Acknowledge {
+			ack := &acknowledgeContent{}
+			if err := json.Unmarshal(msg.Payload, ack); err != nil {
+				a.t.Errorf("invalid acknowledgement: %s", err)
+			}
+
+			a.mu.Lock()
+			a.acks = append(a.acks, ack.AcknowledgedMessageSequenceNumber)
+			a.mu.Unlock()
+			continue
+		}
+
+		a.input <- msg
+	}
+}
+
+func (a *fakeAgent) next(payloadType PayloadType) *Message {
+	select {
+	case msg, ok := <-a.input:
+		if !ok {
+			a.t.Fatalf("expected input with payload type %d, connection closed", payloadType)
+		}
+
+		if msg.MessageType != InputStreamData || msg.PayloadType != payloadType {
+			a.t.Fatalf("expected input with payload type %d, got %s with payload type %d", payloadType, msg.MessageType, msg.PayloadType)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		a.t.Fatalf("timed out waiting for input with payload type %d", payloadType)
+	}
+	return nil
+}
+
+func newFakeAgent(t *testing.T, token string, script func(a *fakeAgent)) *httptest.Server {
+	return httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		var open string
+		if err := websocket.Message.Receive(ws, &open); err != nil {
+			t.Errorf("failed receiving open data channel message: %s", err)
+			return
+		}
+
+		o := &openDataChannelInput{}
+		if err := json.Unmarshal([]byte(open), o); err != nil || o.TokenValue != token {
+			t.Errorf("unexpected open data channel message %s", open)
+			return
+		}
+
+		a := &fakeAgent{t: t, ws: ws, input: make(chan *Message, 16)}
+		go a.read()
+		script(a)
+	}))
+}
+
+func TestConnRun(t *testing.T) {
+	var acks []int64
+	server := newFakeAgent(t, "secret-token", func(a *fakeAgent) {
+		a.output(PayloadHandshakeRequest, `{"AgentVersion":"3.3.0.0","RequestedClientActions":[{"ActionType":"SessionType","ActionParameters":{"SessionType":"InteractiveCommands"}},{"ActionType":"KMSEncryption","ActionParameters":{"KMSKeyId":"key"}}]}`)
+
+		res := &handshakeResponse{}
+		if err := json.Unmarshal(a.next(PayloadHandshakeResponse).Payload, res); err != nil {
+			t.Errorf("invalid handshake response: %s", err)
+		}
+
+		if len(res.ProcessedClientActions) != 2 || res.ProcessedClientActions[0].ActionStatus != actionSuccess || res.ProcessedClientActions[1].ActionStatus != actionFailed {
+			t.Errorf("unexpected handshake response %+v", res)
+		}
+
+		a.output(PayloadHandshakeComplete, `{}`)
+
+		size := &Size{}
+		if err := json.Unmarshal(a.next(PayloadSize).Payload, size); err != nil || size.Cols != 120 || size.Rows != 40 {
+			t.Errorf("unexpected size %+v", size)
+		}
+
+		in := a.next(PayloadOutput)
+
+		// send the output out of order, with a duplicate
+		a.send(OutputStreamData, a.seq+1, PayloadOutput, []byte(" world\n"))
+		a.send(OutputStreamData, a.seq, PayloadOutput, []byte(strings.ToUpper(strings.TrimSpace(string(in.Payload)))))
+		a.send(OutputStreamData, a.seq, PayloadOutput, []byte("duplicate"))
+		a.seq += 2
+
+		a.output(PayloadStdErr, "warning\n")
+		a.output(PayloadExitCode, "3")
+
+		// wait for the acknowledgements before closing the channel
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			a.mu.Lock()
+			n := len(a.acks)
+			a.mu.Unlock()
+			if n >= 7 {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		a.mu.Lock()
+		acks = append(acks, a.acks...)
+		a.mu.Unlock()
+
+		a.send(ChannelClosed, 0, PayloadOutput, []byte(`{"Output":"Exiting session"}`))
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := Dial(ctx, &Session{ID: "s-123", StreamURL: "ws" + strings.TrimPrefix(server.URL, "http"), Token: "secret-token"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Resize(Size{Cols: 120, Rows: 40}); err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code, err := conn.Run(ctx, strings.NewReader("hello\n"), stdout, stderr)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if code != 3 {
+		t.Errorf("expected exit code 3, got %d", code)
+	}
+
+	if stdout.String() != "HELLO world\n" {
+		t.Errorf("expected output %q, got %q", "HELLO world\n", stdout.String())
+	}
+
+	if stderr.String() != "warning\n" {
+		t.Errorf("expected stderr %q, got %q", "warning\n", stderr.String())
+	}
+
+	if len(acks) != 7 {
+		t.Errorf("expected 7 acknowledgements, got %v", acks)
+	}
+}
+
+func TestConnRunClosed(t *testing.T) {
+	server := newFakeAgent(t, "token", func(a *fakeAgent) {
+		a.output(PayloadOutput, "partial")
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := Dial(ctx, &Session{ID: "s-123", StreamURL: "ws" + strings.TrimPrefix(server.URL, "http"), Token: "token"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+	defer conn.Close()
+
+	stdout := &bytes.Buffer{}
+	if _, err := conn.Run(ctx, nil, stdout, io.Discard); err == nil {
+		t.Error("expected error when the session closes without a channel closed message, got nil")
+	}
+
+	if stdout.String() != "partial" {
+		t.Errorf("expected output %q, got %q", "partial", stdout.String())
+	}
+}
+
+func TestConnRunCancelled(t *testing.T) {
+	done := make(chan struct{})
+	server := newFakeAgent(t, "token", func(a *fakeAgent) {
+		<-done
+	})
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conn, err := Dial(ctx, &Session{ID: "s-123", StreamURL: "ws" + strings.TrimPrefix(server.URL, "http"), Token: "token"})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+	defer conn.Close()
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := conn.Run(ctx, nil, io.Discard, io.Discard); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
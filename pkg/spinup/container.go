@@ -1,6 +1,7 @@
 package spinup
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -212,6 +213,7 @@ type ContainerService struct {
 		Port          int64
 		RegistryArn   string
 	}
+	Job            *Job `json:"-"`
 	Status         string
 	Tasks          []string
 	TaskDefinition struct {
@@ -228,8 +230,13 @@ type ContainerService struct {
 }
 
 // GetEndpoint returns the endpoint to get details about a container service
-func (c *ContainerService) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/containers/" + params["name"]
+func (s *ContainerService) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/containers/" + params["name"]
+}
+
+// SetJob sets the async job started by an update to the container service
+func (s *ContainerService) SetJob(job *Job) {
+	s.Job = job
 }
 
 // ContainerSize is the size for a container satisfying the Size interface
@@ -241,8 +248,13 @@ type ContainerSize struct {
 
 // ContainerSize returns ContainerSize
 func (c *Client) ContainerSize(id string) (*ContainerSize, error) {
+	return c.ContainerSizeCtx(context.Background(), id)
+}
+
+// ContainerSizeCtx is ContainerSize with a context to allow cancellation and deadlines
+func (c *Client) ContainerSizeCtx(ctx context.Context, id string) (*ContainerSize, error) {
 	size := &ContainerSize{}
-	if err := c.GetResource(map[string]string{"id": id}, size); err != nil {
+	if err := c.GetResourceCtx(ctx, map[string]string{"id": id}, size); err != nil {
 		return nil, err
 	}
 
@@ -268,8 +280,28 @@ func (c *Client) ContainerSize(id string) (*ContainerSize, error) {
 }
 
 // GetEndpoint returns the endpoint to get details about a container service task
-func (c *ContainerTask) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/containers/" + params["name"] + "/tasks/" + params["taskId"]
+func (s *ContainerTask) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/containers/" + params["name"] + "/tasks/" + params["taskId"]
+}
+
+// ContainerExecInput is the input to start an exec session in a container of a container service task
+type ContainerExecInput struct {
+	Container   string `json:"container"`
+	Command     string `json:"command"`
+	Interactive bool   `json:"interactive"`
+}
+
+// ContainerExecSession is an exec session started in a container, the stream url is the websocket for
+// the session data channel and the token opens it
+type ContainerExecSession struct {
+	SessionId  string
+	StreamUrl  string
+	TokenValue string
+}
+
+// GetEndpoint returns the endpoint to start an exec session in a container service task
+func (s *ContainerExecSession) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/containers/" + params["name"] + "/tasks/" + params["taskId"] + "/exec"
 }
 
 type ContainerServiceWrapperUpdateInput struct {
@@ -289,3 +321,37 @@ type CapacityProviderStrategyInput struct {
 	CapacityProvider string
 	Weight           int64
 }
+
+// ContainerServiceInput is the input to create a container service, or to update its container definitions
+type ContainerServiceInput struct {
+	Name          string                           `json:"name,omitempty"`
+	ForceRedeploy bool                             `json:"force_redeploy"`
+	Service       *ContainerServiceDefinitionInput `json:"service"`
+	Size          *FlexInt                         `json:"size_id"`
+}
+
+// ContainerServiceDefinitionInput is the service and container definitions for a container service
+type ContainerServiceDefinitionInput struct {
+	CapacityProviderStrategy []*ContainerCapacityProviderInput `json:"capacity_provider_strategy,omitempty"`
+	ContainerDefinitions     []*ContainerDefinition            `json:"container_definitions"`
+	DesiredCount             int64                             `json:"desired_count"`
+	PlatformVersion          string                            `json:"platform_version"`
+	Volumes                  []*ContainerVolume                `json:"volumes,omitempty"`
+}
+
+// ContainerCapacityProviderInput is an item in the capacity provider strategy for a container service
+type ContainerCapacityProviderInput struct {
+	Base             int64  `json:"base"`
+	CapacityProvider string `json:"capacity_provider"`
+	Weight           int64  `json:"weight"`
+}
+
+// CreateContainerServiceOutput is returned from the api when a container service is created in a space
+type CreateContainerServiceOutput struct {
+	ContainerService
+}
+
+// GetEndpoint returns the endpoint to create a container service in a space
+func (s *CreateContainerServiceOutput) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/containers"
+}
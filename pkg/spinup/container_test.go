@@ -0,0 +1,21 @@
+package spinup
+
+import "testing"
+
+func TestContainerTaskGetEndpoint(t *testing.T) {
+	resource := ContainerTask{}
+	expected := "http://localhost:8090/api/v3/spaces/myspace/containers/web/tasks/abc123"
+
+	if out := resource.GetEndpoint(testClient, map[string]string{"space": "myspace", "name": "web", "taskId": "abc123"}); out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+}
+
+func TestContainerExecSessionGetEndpoint(t *testing.T) {
+	resource := ContainerExecSession{}
+	expected := "http://localhost:8090/api/v3/spaces/myspace/containers/web/tasks/abc123/exec"
+
+	if out := resource.GetEndpoint(testClient, map[string]string{"space": "myspace", "name": "web", "taskId": "abc123"}); out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+}
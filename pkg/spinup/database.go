@@ -1,6 +1,10 @@
 package spinup
 
-import log "github.com/sirupsen/logrus"
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
 
 type DatabaseInfo struct {
 	Endpoint    string        `json:",omitempty"`
@@ -81,8 +85,8 @@ type DBInstanceEndpoint struct {
 }
 
 // GetEndpoint gets the URL for server info
-func (s *DatabaseInfo) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/databases/" + params["name"]
+func (s *DatabaseInfo) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/databases/" + params["name"]
 }
 
 // DatabaseSize is the size for a database satisfying the Size interface
@@ -92,8 +96,13 @@ type DatabaseSize struct {
 
 // DatabaseSize returns a DatabaseSize as a Size
 func (c *Client) DatabaseSize(id string) (*DatabaseSize, error) {
+	return c.DatabaseSizeCtx(context.Background(), id)
+}
+
+// DatabaseSizeCtx is DatabaseSize with a context to allow cancellation and deadlines
+func (c *Client) DatabaseSizeCtx(ctx context.Context, id string) (*DatabaseSize, error) {
 	size := &DatabaseSize{}
-	if err := c.GetResource(map[string]string{"id": id}, size); err != nil {
+	if err := c.GetResourceCtx(ctx, map[string]string{"id": id}, size); err != nil {
 		return nil, err
 	}
 
@@ -101,3 +110,19 @@ func (c *Client) DatabaseSize(id string) (*DatabaseSize, error) {
 
 	return size, nil
 }
+
+// DatabaseInput is the input to create a database, or to change its size
+type DatabaseInput struct {
+	Name string   `json:"name,omitempty"`
+	Size *FlexInt `json:"size_id"`
+}
+
+// CreateDatabaseOutput is returned from the api when a database is created in a space
+type CreateDatabaseOutput struct {
+	Name string
+}
+
+// GetEndpoint returns the endpoint to create a database in a space
+func (s *CreateDatabaseOutput) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/databases"
+}
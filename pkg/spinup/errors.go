@@ -0,0 +1,117 @@
+package spinup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// APIError is an error response returned from the Spinup API
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	Status     string
+	Message    string
+	Body       []byte
+}
+
+// Error returns the APIError as a string, including the server's message when one was returned
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s", e.Method, e.Endpoint, e.Status)
+	if e.Message != "" {
+		msg = msg + ": " + e.Message
+	}
+	return msg
+}
+
+// newAPIError reads the body of an error response and returns an APIError describing it
+func newAPIError(res *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+	}
+
+	if res.Request != nil {
+		apiErr.Method = res.Request.Method
+		apiErr.Endpoint = res.Request.URL.String()
+	}
+
+	if res.Body != nil {
+		defer res.Body.Close()
+		if body, err := ioutil.ReadAll(res.Body); err == nil {
+			apiErr.Body = body
+		}
+	}
+
+	apiErr.Message = parseErrorMessage(apiErr.Body)
+
+	return apiErr
+}
+
+// parseErrorMessage attempts to find the error message in a response body.  The api isn't
+// consistent about where it puts the message, so try the common keys and fall back to the
+// body itself if it's a short non-json string.
+func parseErrorMessage(body []byte) string {
+	b := strings.TrimSpace(string(body))
+	if b == "" {
+		return ""
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		if len(b) > 256 || strings.HasPrefix(b, "<") {
+			return ""
+		}
+		return b
+	}
+
+	for _, k := range []string{"error", "message", "msg", "errors"} {
+		switch v := payload[k].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case []interface{}:
+			msgs := make([]string, 0, len(v))
+			for _, m := range v {
+				msgs = append(msgs, fmt.Sprintf("%v", m))
+			}
+			if len(msgs) > 0 {
+				return strings.Join(msgs, ", ")
+			}
+		case map[string]interface{}:
+			if m, ok := v["message"].(string); ok && m != "" {
+				return m
+			}
+		}
+	}
+
+	return ""
+}
+
+// IsNotFound returns true if the error is an APIError with a 404 status
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsForbidden returns true if the error is an APIError with a 403 status
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+// IsUnauthorized returns true if the error is an APIError with a 401 status
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+func hasStatusCode(err error, code int) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == code
+	}
+	return false
+}
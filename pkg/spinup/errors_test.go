@@ -0,0 +1,95 @@
+package spinup
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseErrorMessage(t *testing.T) {
+	tests := map[string]string{
+		``:                                       "",
+		`Not Found`:                              "Not Found",
+		`{"error":"space not found"}`:            "space not found",
+		`{"message":"forbidden"}`:                "forbidden",
+		`{"errors":["bad name","bad size"]}`:     "bad name, bad size",
+		`{"error":{"message":"nested failure"}}`: "nested failure",
+		`{"foo":"bar"}`:                          "",
+		`<html><body>502</body></html>`:          "",
+	}
+
+	for body, expected := range tests {
+		if out := parseErrorMessage([]byte(body)); out != expected {
+			t.Errorf("expected '%s' for body '%s', got '%s'", expected, body, out)
+		}
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case MockInfoURI + "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"token is expired"}`))
+		case MockInfoURI + "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"not a member of space"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("Not Found"))
+		}
+	}))
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	err = client.GetResource(map[string]string{"id": "missing"}, &MockResourceInfo{})
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got %s", err)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+
+	if apiErr.Method != http.MethodGet {
+		t.Errorf("expected method %s, got %s", http.MethodGet, apiErr.Method)
+	}
+
+	if expected := ts.URL + MockInfoURI + "/missing"; apiErr.Endpoint != expected {
+		t.Errorf("expected endpoint %s, got %s", expected, apiErr.Endpoint)
+	}
+
+	if string(apiErr.Body) != "Not Found" || apiErr.Message != "Not Found" {
+		t.Errorf("expected body and message 'Not Found', got '%s' and '%s'", string(apiErr.Body), apiErr.Message)
+	}
+
+	err = client.GetResource(map[string]string{"id": "forbidden"}, &MockResourceInfo{})
+	if !IsForbidden(err) || IsNotFound(err) {
+		t.Errorf("expected forbidden error, got %s", err)
+	}
+
+	err = client.PutResource(map[string]string{"id": "unauthorized"}, []byte{}, &MockResourceInfo{})
+	if !IsUnauthorized(err) {
+		t.Errorf("expected unauthorized error, got %s", err)
+	}
+
+	if errors.As(err, &apiErr); apiErr.Message != "token is expired" {
+		t.Errorf("expected message 'token is expired', got '%s'", apiErr.Message)
+	}
+
+	wrapped := fmt.Errorf("wrapped: %w", err)
+	if !IsUnauthorized(wrapped) {
+		t.Errorf("expected wrapped unauthorized error, got %s", wrapped)
+	}
+
+	if IsNotFound(errors.New("boom")) {
+		t.Error("expected non-api error not to be not found")
+	}
+}
@@ -24,9 +24,14 @@ type Image struct {
 // Images is a list of server images
 type Images []*Image
 
+// GetEndpoint gets the endpoint URL for an image
+func (i *Image) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/images/" + params["name"]
+}
+
 // GetEndpoint gets the endpoint UR for an image list
-func (i *Images) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/images"
+func (i *Images) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/images"
 }
 
 type ImageVolumes map[string]*ImageVolume
@@ -0,0 +1,44 @@
+package spinup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Job is an asynchronous job started by the api when a request is accepted (202) rather than completed
+type Job struct {
+	ID       string `json:"id,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// JobTracker is implemented by ResourceTypes that want to know about the async job started by a PUT or POST
+type JobTracker interface {
+	SetJob(job *Job)
+}
+
+// parseJob finds the job id in an accepted response, either in the body or the Location header
+func parseJob(res *http.Response, body []byte) *Job {
+	job := &Job{
+		Location: res.Header.Get("Location"),
+	}
+
+	var payload map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	if err := d.Decode(&payload); err == nil {
+		for _, k := range []string{"job_id", "jobId", "task_id", "taskId", "flow_id"} {
+			if v, ok := payload[k]; ok && v != nil {
+				job.ID = fmt.Sprintf("%v", v)
+				break
+			}
+		}
+	}
+
+	if job.ID == "" && job.Location == "" {
+		return nil
+	}
+
+	return job
+}
@@ -0,0 +1,86 @@
+package spinup
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ContainerLogEvent is a log event from a container in a container service task
+type ContainerLogEvent struct {
+	EventId       string `json:"eventId"`
+	IngestionTime int64  `json:"ingestionTime"`
+	Message       string `json:"message"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+// Time returns the time of the log event
+func (e *ContainerLogEvent) Time() time.Time {
+	return time.UnixMilli(e.Timestamp)
+}
+
+// ContainerLogs is a page of log events from a container in a container service task
+type ContainerLogs struct {
+	Events            []*ContainerLogEvent `json:"events"`
+	NextBackwardToken string               `json:"nextBackwardToken"`
+	NextForwardToken  string               `json:"nextForwardToken"`
+}
+
+// GetEndpoint returns the endpoint to get the logs of a container in a container service task.  The
+// container param is required, start_time (in milliseconds) and next_token are optional.
+func (l *ContainerLogs) GetEndpoint(c *Client, params map[string]string) string {
+	q := url.Values{}
+	for _, p := range []string{"container", "start_time", "next_token"} {
+		if v, ok := params[p]; ok && v != "" {
+			q.Set(p, v)
+		}
+	}
+
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/containers/" + params["name"] + "/tasks/" + params["taskId"] + "/logs?" + q.Encode()
+}
+
+// ContainerLogEvents gets the log events of a container in a container service task, following the
+// pagination tokens until there are no more events.  It returns the events and the token to pass to
+// get the events logged after them.  An empty token starts at the given time.
+func (c *Client) ContainerLogEvents(params map[string]string, start time.Time, token string) ([]*ContainerLogEvent, string, error) {
+	return c.ContainerLogEventsCtx(context.Background(), params, start, token)
+}
+
+// ContainerLogEventsCtx is ContainerLogEvents with a context to allow cancellation and deadlines
+func (c *Client) ContainerLogEventsCtx(ctx context.Context, params map[string]string, start time.Time, token string) ([]*ContainerLogEvent, string, error) {
+	p := map[string]string{}
+	for k, v := range params {
+		p[k] = v
+	}
+
+	if token == "" && !start.IsZero() {
+		p["start_time"] = strconv.FormatInt(start.UnixMilli(), 10)
+	}
+
+	events := []*ContainerLogEvent{}
+	for {
+		p["next_token"] = token
+
+		page := &ContainerLogs{}
+		if err := c.GetResourceCtx(ctx, p, page); err != nil {
+			return nil, token, err
+		}
+
+		events = append(events, page.Events...)
+		delete(p, "start_time")
+
+		// pages can be empty before the end of the stream, which is reached when the forward
+		// token stays the same
+		if page.NextForwardToken == "" || page.NextForwardToken == token {
+			break
+		}
+		token = page.NextForwardToken
+	}
+
+	log.Debugf("got %d log events for %s/%s task %s container %s", len(events), params["space"], params["name"], params["taskId"], params["container"])
+
+	return events, token, nil
+}
@@ -0,0 +1,138 @@
+package spinup
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestContainerLogsGetEndpoint(t *testing.T) {
+	resource := ContainerLogs{}
+
+	expected := "http://localhost:8090/api/v3/spaces/myspace/containers/web/tasks/abc123/logs?container=app"
+	if out := resource.GetEndpoint(testClient, map[string]string{"space": "myspace", "name": "web", "taskId": "abc123", "container": "app"}); out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+
+	expected = "http://localhost:8090/api/v3/spaces/myspace/containers/web/tasks/abc123/logs?container=app&next_token=f%2F123"
+	if out := resource.GetEndpoint(testClient, map[string]string{"space": "myspace", "name": "web", "taskId": "abc123", "container": "app", "start_time": "", "next_token": "f/123"}); out != expected {
+		t.Errorf("expected %s, got %s", expected, out)
+	}
+}
+
+func TestContainerLogEvents(t *testing.T) {
+	// three pages of two events, an empty page and then the end of the stream
+	pages := map[string]*ContainerLogs{}
+	for i := 0; i < 5; i++ {
+		token := ""
+		if i > 0 {
+			token = "f/" + strconv.Itoa(i)
+		}
+
+		page := &ContainerLogs{NextForwardToken: "f/" + strconv.Itoa(i+1)}
+		if i < 3 {
+			for j := 0; j < 2; j++ {
+				n := i*2 + j
+				page.Events = append(page.Events, &ContainerLogEvent{
+					EventId:   strconv.Itoa(n),
+					Message:   "line " + strconv.Itoa(n),
+					Timestamp: int64(1700000000000 + n),
+				})
+			}
+		}
+
+		if i == 4 {
+			page.NextForwardToken = token
+		}
+
+		pages[token] = page
+	}
+
+	var startTimes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/spaces/myspace/containers/web/tasks/abc123/logs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		if c := r.URL.Query().Get("container"); c != "app" {
+			t.Errorf("expected container app, got %s", c)
+		}
+
+		startTimes = append(startTimes, r.URL.Query().Get("start_time"))
+
+		page, ok := pages[r.URL.Query().Get("next_token")]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		j, _ := json.Marshal(page)
+		w.Write(j)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		BaseURL:    server.URL,
+		HTTPClient: server.Client(),
+		SpaceURI:   SpaceURI,
+	}
+
+	params := map[string]string{"space": "myspace", "name": "web", "taskId": "abc123", "container": "app"}
+	start := time.UnixMilli(1700000000000)
+
+	events, token, err := client.ContainerLogEventsCtx(context.Background(), params, start, "")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(events))
+	}
+
+	for i, e := range events {
+		if e.EventId != strconv.Itoa(i) {
+			t.Errorf("expected event %d, got %s", i, e.EventId)
+		}
+	}
+
+	if token != "f/4" {
+		t.Errorf("expected token f/4, got %s", token)
+	}
+
+	if startTimes[0] != "1700000000000" {
+		t.Errorf("expected the first request to start at 1700000000000, got %q", startTimes[0])
+	}
+
+	for _, s := range startTimes[1:] {
+		if s != "" {
+			t.Errorf("expected no start time with a token, got %s", s)
+		}
+	}
+
+	if _, ok := params["next_token"]; ok {
+		t.Error("expected the passed params not to be modified")
+	}
+
+	// resuming at the end of the stream returns no events and the same token
+	startTimes = nil
+	events, token, err = client.ContainerLogEventsCtx(context.Background(), params, start, token)
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if len(events) != 0 || token != "f/4" {
+		t.Errorf("expected no events and token f/4, got %d events and token %s", len(events), token)
+	}
+
+	if len(startTimes) != 1 || startTimes[0] != "" {
+		t.Errorf("expected a single request without a start time, got %v", startTimes)
+	}
+
+	if e := (&ContainerLogEvent{Timestamp: 1700000000123}); !e.Time().Equal(time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC)) {
+		t.Errorf("unexpected event time %s", e.Time())
+	}
+}
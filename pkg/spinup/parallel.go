@@ -0,0 +1,66 @@
+package spinup
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the number of requests a client runs at once when fanning out
+var DefaultConcurrency = 8
+
+// Parallel calls fn for each index in [0, n) using a bounded pool of workers sized by the client's
+// Concurrency.  Callers should write results into a slice by index to preserve ordering.  The first
+// error returned by fn cancels the context passed to the remaining calls and is returned.
+func (c *Client) Parallel(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	workers := c.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+
+	if workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	indexes := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := fn(ctx, i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			break feed
+		case indexes <- i:
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	// the parent context was cancelled before all of the work was handed out
+	return ctx.Err()
+}
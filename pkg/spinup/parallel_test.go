@@ -0,0 +1,87 @@
+package spinup
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	c := &Client{Concurrency: 3}
+
+	var running, peak int32
+	out := make([]int, 20)
+	err := c.Parallel(context.Background(), len(out), func(ctx context.Context, i int) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+
+		// finish out of order
+		time.Sleep(time.Duration(len(out)-i) * time.Millisecond)
+		out[i] = i * i
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if peak > 3 {
+		t.Errorf("expected at most 3 concurrent calls, got %d", peak)
+	}
+
+	expected := make([]int, 20)
+	for i := range expected {
+		expected[i] = i * i
+	}
+
+	if !reflect.DeepEqual(expected, out) {
+		t.Errorf("expected %v, got %v", expected, out)
+	}
+}
+
+func TestParallelError(t *testing.T) {
+	c := &Client{Concurrency: 2}
+
+	boom := errors.New("boom")
+	var calls int32
+	err := c.Parallel(context.Background(), 100, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i == 1 {
+			return boom
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+		return nil
+	})
+
+	if err != boom {
+		t.Errorf("expected error %s, got %v", boom, err)
+	}
+
+	if calls >= 100 {
+		t.Errorf("expected the remaining calls to be cancelled, got %d calls", calls)
+	}
+}
+
+func TestParallelEmpty(t *testing.T) {
+	c := &Client{}
+	if err := c.Parallel(context.Background(), 0, func(ctx context.Context, i int) error {
+		t.Error("unexpected call")
+		return nil
+	}); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+}
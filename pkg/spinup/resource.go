@@ -1,6 +1,7 @@
 package spinup
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -35,16 +36,21 @@ type Resource struct {
 }
 
 // GetEndpoint returns the URL to get a resource
-func (r *Resource) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/resources/" + params["name"]
+func (r *Resource) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/resources/" + params["name"]
 }
 
 // Resources gets the resources from a space
 func (c *Client) Resources(space string) ([]*Resource, error) {
-	endpoint := BaseURL + SpaceURI + "/" + space
+	return c.ResourcesCtx(context.Background(), space)
+}
+
+// ResourcesCtx is Resources with a context to allow cancellation and deadlines
+func (c *Client) ResourcesCtx(ctx context.Context, space string) ([]*Resource, error) {
+	endpoint := c.BaseURL + c.SpaceURI + "/" + space
 	log.Infof("getting resources from endpoint: %s", endpoint)
 
-	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating get request for space %s: %s", space, err)
 	}
@@ -56,13 +62,13 @@ func (c *Client) Resources(space string) ([]*Resource, error) {
 		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
 	}
 
-	res, err := c.HTTPClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed getting space "+space)
 	}
 
 	if res.StatusCode >= 400 {
-		return nil, errors.New("error getting space details: " + res.Status)
+		return nil, newAPIError(res)
 	}
 
 	log.Infof("got success response from api %s", res.Status)
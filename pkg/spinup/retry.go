@@ -0,0 +1,172 @@
+package spinup
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RetryPolicy configures how the client retries requests that fail with transient errors.  Idempotent
+// GET requests are retried on network errors, 429 and 5xx responses.  PUT and POST requests are only
+// retried when their context has been marked with WithRetrySafe.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried after the first attempt
+	MaxRetries int
+	// MinWait is the base wait time before the first retry, it doubles with each retry
+	MinWait time.Duration
+	// MaxWait caps the backoff between retries
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy is a reasonable retry policy for talking to the Spinup API
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinWait:    500 * time.Millisecond,
+	MaxWait:    10 * time.Second,
+}
+
+type retrySafeKey struct{}
+
+// WithRetrySafe marks requests made with the returned context as safe to retry, even when
+// they use a non-idempotent method like PUT or POST
+func WithRetrySafe(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retrySafeKey{}, true)
+}
+
+// retryable returns true if the request may be retried
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	safe, _ := req.Context().Value(retrySafeKey{}).(bool)
+	return safe
+}
+
+// retryableStatus returns true if the response status represents a transient failure
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || (code >= 500 && code != http.StatusNotImplemented)
+}
+
+// do sends the request with the client's retry policy.  Responses with an error status are returned
+// to the caller after the retries are exhausted so the body can be parsed into an APIError.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	policy := c.Retry
+	if policy == nil || !retryable(req) {
+		return c.HTTPClient.Do(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		res, err := c.HTTPClient.Do(req)
+
+		if attempt >= policy.MaxRetries || ctx.Err() != nil {
+			return res, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			log.Warnf("request %s %s failed, retrying: %s", req.Method, req.URL, err)
+			wait = policy.backoff(attempt)
+		case retryableStatus(res.StatusCode):
+			log.Warnf("request %s %s returned %s, retrying", req.Method, req.URL, res.Status)
+
+			wait = policy.retryWait(attempt, res.Header.Get("Retry-After"))
+
+			// drain and close the body so the connection can be reused
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+		default:
+			return res, nil
+		}
+
+		log.Infof("waiting %s before retry %d of %d", wait, attempt+1, policy.MaxRetries)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// backoff returns the exponential backoff with jitter for the given attempt
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.MinWait
+	for i := 0; i < attempt && (p.MaxWait <= 0 || wait < p.MaxWait); i++ {
+		wait *= 2
+	}
+
+	if p.MaxWait > 0 && wait > p.MaxWait {
+		wait = p.MaxWait
+	}
+
+	if wait <= 0 {
+		return 0
+	}
+
+	// wait at least half of the backoff, plus a random jitter
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryWait returns the wait before retrying a response with a retryable status, the Retry-After header
+// if there is one or the backoff for the attempt.  The header is capped at MaxWait so a server asking for
+// a long wait, or a date far in the future, doesn't stall the command.
+func (p *RetryPolicy) retryWait(attempt int, header string) time.Duration {
+	wait, ok := retryAfter(header)
+	if !ok {
+		return p.backoff(attempt)
+	}
+
+	if p.MaxWait > 0 && wait > p.MaxWait {
+		log.Debugf("capping Retry-After %s at %s", wait, p.MaxWait)
+		wait = p.MaxWait
+	}
+
+	return wait
+}
+
+// retryAfter parses a Retry-After header, which is either a number of seconds or an http date
+func retryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return 0, false
+	}
+
+	wait := time.Until(t)
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait, true
+}
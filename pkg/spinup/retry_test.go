@@ -0,0 +1,220 @@
+package spinup
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newFlakyServer(failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	var count int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"1","name":"forgery"}`))
+	}))
+	return ts, &count
+}
+
+func TestRetryGet(t *testing.T) {
+	ts, count := newFlakyServer(2, http.StatusBadGateway, "")
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+	client.Retry = &RetryPolicy{MaxRetries: 3, MinWait: time.Millisecond, MaxWait: 5 * time.Millisecond}
+
+	output := MockResourceInfo{}
+	if err := client.GetResource(map[string]string{"id": "1"}, &output); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if output.Name != "forgery" {
+		t.Errorf("expected name forgery, got %s", output.Name)
+	}
+
+	if c := atomic.LoadInt32(count); c != 3 {
+		t.Errorf("expected 3 attempts, got %d", c)
+	}
+}
+
+func TestRetryExhausted(t *testing.T) {
+	ts, count := newFlakyServer(10, http.StatusTooManyRequests, "0")
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+	client.Retry = &RetryPolicy{MaxRetries: 2, MinWait: time.Hour, MaxWait: time.Hour}
+
+	// the Retry-After header of 0 takes precedence over the hour long backoff
+	err = client.GetResource(map[string]string{"id": "1"}, &MockResourceInfo{})
+	if !hasStatusCode(err, http.StatusTooManyRequests) {
+		t.Errorf("expected too many requests error, got %v", err)
+	}
+
+	if c := atomic.LoadInt32(count); c != 3 {
+		t.Errorf("expected 3 attempts, got %d", c)
+	}
+}
+
+func TestRetryNoPolicy(t *testing.T) {
+	ts, count := newFlakyServer(1, http.StatusServiceUnavailable, "")
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if err := client.GetResource(map[string]string{"id": "1"}, &MockResourceInfo{}); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if c := atomic.LoadInt32(count); c != 1 {
+		t.Errorf("expected 1 attempt, got %d", c)
+	}
+}
+
+func TestRetryPut(t *testing.T) {
+	ts, count := newFlakyServer(1, http.StatusBadGateway, "")
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+	client.Retry = &RetryPolicy{MaxRetries: 3, MinWait: time.Millisecond, MaxWait: 5 * time.Millisecond}
+
+	if err := client.PutResource(map[string]string{"id": "1"}, []byte(`{}`), &MockResourceInfo{}); err == nil {
+		t.Error("expected error for unsafe put, got nil")
+	}
+
+	if c := atomic.LoadInt32(count); c != 1 {
+		t.Errorf("expected 1 attempt, got %d", c)
+	}
+
+	ctx := WithRetrySafe(context.Background())
+	if err := client.PutResourceCtx(ctx, map[string]string{"id": "1"}, []byte(`{}`), &MockResourceInfo{}); err != nil {
+		t.Errorf("expected nil error for safe put, got %s", err)
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	ts, _ := newFlakyServer(10, http.StatusBadGateway, "")
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+	client.Retry = &RetryPolicy{MaxRetries: 3, MinWait: time.Hour, MaxWait: time.Hour}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := client.GetResourceCtx(ctx, map[string]string{"id": "1"}, &MockResourceInfo{}); err == nil {
+		t.Error("expected error, got nil")
+	}
+
+	if time.Since(start) > 5*time.Second {
+		t.Error("expected retry wait to be cancelled with the context")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := &RetryPolicy{MinWait: 100 * time.Millisecond, MaxWait: time.Second}
+	for attempt := 0; attempt < 10; attempt++ {
+		max := p.MinWait << uint(attempt)
+		if max > p.MaxWait {
+			max = p.MaxWait
+		}
+
+		if b := p.backoff(attempt); b < max/2 || b > max {
+			t.Errorf("expected backoff for attempt %d between %s and %s, got %s", attempt, max/2, max, b)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("5"); !ok || d != 5*time.Second {
+		t.Errorf("expected 5s, got %s (%t)", d, ok)
+	}
+
+	if _, ok := retryAfter(""); ok {
+		t.Error("expected empty header not to parse")
+	}
+
+	if _, ok := retryAfter("soon"); ok {
+		t.Error("expected invalid header not to parse")
+	}
+
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(future); !ok || d <= 0 || d > time.Minute {
+		t.Errorf("expected about a minute, got %s (%t)", d, ok)
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	p := &RetryPolicy{MinWait: 100 * time.Millisecond, MaxWait: time.Second}
+
+	if w := p.retryWait(0, "0"); w != 0 {
+		t.Errorf("expected no wait, got %s", w)
+	}
+
+	if w := p.retryWait(0, "3600"); w != time.Second {
+		t.Errorf("expected Retry-After to be capped at 1s, got %s", w)
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if w := p.retryWait(0, future); w != time.Second {
+		t.Errorf("expected Retry-After date to be capped at 1s, got %s", w)
+	}
+
+	if w := p.retryWait(2, "soon"); w < 200*time.Millisecond || w > 400*time.Millisecond {
+		t.Errorf("expected backoff between 200ms and 400ms for an invalid header, got %s", w)
+	}
+
+	// without a maximum the header is honored
+	p.MaxWait = 0
+	if w := p.retryWait(0, "3600"); w != time.Hour {
+		t.Errorf("expected 1h, got %s", w)
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	ts, count := newFlakyServer(2, http.StatusServiceUnavailable, "3600")
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+	client.Retry = &RetryPolicy{MaxRetries: 3, MinWait: time.Millisecond, MaxWait: 5 * time.Millisecond}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	output := MockResourceInfo{}
+	if err := client.GetResourceCtx(ctx, map[string]string{"id": "1"}, &output); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if c := atomic.LoadInt32(count); c != 3 {
+		t.Errorf("expected 3 attempts, got %d", c)
+	}
+}
@@ -1,5 +1,7 @@
 package spinup
 
+import "context"
+
 type Secret struct {
 	ARN              string
 	Name             string
@@ -19,12 +21,59 @@ type SecretInput struct {
 	Description string `json:"description,omitempty"`
 }
 
+// SecretUpdateInput is the input to update the value and/or description of a secret
+type SecretUpdateInput struct {
+	Value       string `json:"value,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// CreateSecretOutput is returned from the api when a secret is created in a space
+type CreateSecretOutput struct {
+	ARN       string
+	Name      string
+	VersionId string
+}
+
 // GetEndpoint returns the endpoint to get details about a secret
-func (s *Secret) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/secrets/" + params["secretname"]
+func (s *Secret) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/secrets/" + params["secretname"]
 }
 
 // GetEndpoint returns the endpoint to get a list of secrets in a space
-func (s *Secrets) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/secrets"
+func (s *Secrets) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/secrets"
+}
+
+// GetEndpoint returns the endpoint to create a secret in a space
+func (s *CreateSecretOutput) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/secrets"
+}
+
+// SecretValue is the current value of a secret, only returned to members of the space
+type SecretValue struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	VersionId string `json:"version_id,omitempty"`
+}
+
+// GetEndpoint returns the endpoint to get the value of a secret
+func (s *SecretValue) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/secrets/" + params["secretname"] + "/value"
+}
+
+// Sensitive marks the secret value so its responses aren't logged
+func (s *SecretValue) Sensitive() {}
+
+// SecretValue returns the value of a secret in a space
+func (c *Client) SecretValue(space, name string) (*SecretValue, error) {
+	return c.SecretValueCtx(context.Background(), space, name)
+}
+
+// SecretValueCtx is SecretValue with a context to allow cancellation and deadlines
+func (c *Client) SecretValueCtx(ctx context.Context, space, name string) (*SecretValue, error) {
+	value := &SecretValue{}
+	if err := c.GetResourceCtx(ctx, map[string]string{"space": space, "secretname": name}, value); err != nil {
+		return nil, err
+	}
+	return value, nil
 }
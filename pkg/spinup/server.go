@@ -1,6 +1,7 @@
 package spinup
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -72,24 +73,29 @@ type ServerSize struct {
 }
 
 // GetEndpoint gets the URL for server info
-func (s *ServerInfo) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/resources/" + params["name"] + "/info"
+func (s *ServerInfo) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/resources/" + params["name"] + "/info"
 }
 
 // GetEndpoint gets the URL for server disks
-func (s *Disks) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/servers/" + params["name"] + "/disks"
+func (s *Disks) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/servers/" + params["name"] + "/disks"
 }
 
 // GetEndpoint gets the URL for server snapshots
-func (s *Snapshots) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/servers/" + params["name"] + "/snapshots"
+func (s *Snapshots) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/servers/" + params["name"] + "/snapshots"
 }
 
 // ServerSize returns a ServerSize as a Size
 func (c *Client) ServerSize(id string) (*ServerSize, error) {
+	return c.ServerSizeCtx(context.Background(), id)
+}
+
+// ServerSizeCtx is ServerSize with a context to allow cancellation and deadlines
+func (c *Client) ServerSizeCtx(ctx context.Context, id string) (*ServerSize, error) {
 	size := &ServerSize{}
-	if err := c.GetResource(map[string]string{"id": id}, size); err != nil {
+	if err := c.GetResourceCtx(ctx, map[string]string{"id": id}, size); err != nil {
 		return nil, err
 	}
 
@@ -1,9 +1,10 @@
 package spinup
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
+	"net/http"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -26,18 +27,28 @@ type Size interface {
 	GetPrice() string
 }
 
+// Size gets the size with the given id
 func (c *Client) Size(id string) (Size, error) {
-	endpoint := BaseURL + SizeURI + "/" + id
+	return c.SizeCtx(context.Background(), id)
+}
+
+// SizeCtx is Size with a context to allow cancellation and deadlines
+func (c *Client) SizeCtx(ctx context.Context, id string) (Size, error) {
+	endpoint := c.BaseURL + c.SizeURI + "/" + id
 	log.Infof("getting resource from endpoint: %s", endpoint)
 
-	res, err := c.HTTPClient.Get(endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating get request for size "+id)
+	}
+
+	res, err := c.do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed getting size "+id)
 	}
 
 	if res.StatusCode >= 400 {
-		msg := fmt.Sprintf("error getting size (ID: %s): %s", id, res.Status)
-		return nil, errors.New(msg)
+		return nil, newAPIError(res)
 	}
 
 	log.Infof("got success response from api %s", res.Status)
@@ -61,8 +72,8 @@ func (c *Client) Size(id string) (Size, error) {
 	return &size, nil
 }
 
-func (s *BaseSize) GetEndpoint(params map[string]string) string {
-	return BaseURL + SizeURI + "/" + params["id"]
+func (s *BaseSize) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SizeURI + "/" + params["id"]
 }
 
 func (s *BaseSize) GetName() string {
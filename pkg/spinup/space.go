@@ -57,21 +57,21 @@ type CostValue struct {
 type SpaceCosts []*SpaceCost
 
 // GetEndpoint returns the endpoint to get the list of spaces
-func (s *Spaces) GetEndpoint(_ map[string]string) string {
-	return BaseURL + SpaceURI
+func (s *Spaces) GetEndpoint(c *Client, _ map[string]string) string {
+	return c.BaseURL + c.SpaceURI
 }
 
 // GetEndpoint returns the endpoint to get details about a space
-func (s *Space) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["id"]
+func (s *Space) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["id"]
 }
 
 // GetEndpoint returns the endpoint to get details about a space
-func (s *GetSpace) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["id"]
+func (s *GetSpace) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["id"]
 }
 
 // GetEndpoint returns the endpoint to get cost of a space
-func (s *SpaceCosts) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["id"] + "/cost"
+func (s *SpaceCosts) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["id"] + "/cost"
 }
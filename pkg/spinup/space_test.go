@@ -11,11 +11,17 @@ import (
 	"testing"
 )
 
+var testClient = &Client{
+	BaseURL:  "http://localhost:8090",
+	SizeURI:  SizeURI,
+	SpaceURI: SpaceURI,
+}
+
 func TestSpacesGetEndpoint(t *testing.T) {
 	resource := Spaces{}
 	expected := "http://localhost:8090/api/v3/spaces"
 
-	if out := resource.GetEndpoint(map[string]string{}); out != expected {
+	if out := resource.GetEndpoint(testClient, map[string]string{}); out != expected {
 		t.Errorf("expected %s, got %s", expected, out)
 	}
 }
@@ -24,7 +30,7 @@ func TestSpaceGetEndpoint(t *testing.T) {
 	resource := Space{}
 	expected := "http://localhost:8090/api/v3/spaces/123"
 
-	if out := resource.GetEndpoint(map[string]string{"id": "123"}); out != expected {
+	if out := resource.GetEndpoint(testClient, map[string]string{"id": "123"}); out != expected {
 		t.Errorf("expected %s, got %s", expected, out)
 	}
 }
@@ -33,7 +39,7 @@ func TestGetSpaceGetEndpoint(t *testing.T) {
 	resource := GetSpace{}
 	expected := "http://localhost:8090/api/v3/spaces/123"
 
-	if out := resource.GetEndpoint(map[string]string{"id": "123"}); out != expected {
+	if out := resource.GetEndpoint(testClient, map[string]string{"id": "123"}); out != expected {
 		t.Errorf("expected %s, got %s", expected, out)
 	}
 }
@@ -42,7 +48,7 @@ func TestSpaceCostGetEndpoint(t *testing.T) {
 	resource := SpaceCosts{}
 	expected := "http://localhost:8090/api/v3/spaces/123/cost"
 
-	if out := resource.GetEndpoint(map[string]string{"id": "123"}); out != expected {
+	if out := resource.GetEndpoint(testClient, map[string]string{"id": "123"}); out != expected {
 		t.Errorf("expected %s, got %s", expected, out)
 	}
 }
@@ -2,6 +2,7 @@ package spinup
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -10,13 +11,13 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
 var (
-	BaseURL      = "http://localhost:8090"
 	DatabaseURI  = "/api/v3/databases"
 	ContainerURI = "/api/v3/containers"
 	ResourceURI  = "/api/v3/resources"
@@ -32,11 +33,20 @@ type FlexInt int
 // FlexBool is a bool... or a stirng... or an int... or...
 type FlexBool bool
 
-// Client is the spinup client
+// Client is the spinup client.  The base URL and URI prefixes are carried on the
+// client so that multiple clients pointed at different Spinup instances can coexist.
 type Client struct {
-	AuthToken  string
-	CSRFToken  string
-	HTTPClient *http.Client
+	AuthToken   string
+	BaseURL     string
+	Concurrency int
+	CSRFToken   string
+	HTTPClient  *http.Client
+	Retry       *RetryPolicy
+	SizeURI     string
+	SpaceURI    string
+
+	// mu guards the CSRFToken, which may be set by concurrent requests
+	mu sync.Mutex
 }
 
 // NameValue is the ubuquitous Name/Value struct
@@ -51,35 +61,51 @@ type NameValueFrom struct {
 	ValueFrom string
 }
 
-// ResourceType is an interface for deteriming URLs
+// ResourceType is an interface for deteriming URLs.  Endpoints are resolved relative
+// to the client issuing the request.
 type ResourceType interface {
-	GetEndpoint(params map[string]string) string
+	GetEndpoint(c *Client, params map[string]string) string
 }
 
+// SensitiveResource is a resource with secret content, like the value of a secret.  Responses for sensitive
+// resources are never logged, even at debug level.
+type SensitiveResource interface {
+	ResourceType
+	Sensitive()
+}
+
+// New returns a new spinup client for the given spinup url
 func New(spinupUrl string, client *http.Client, token string) (*Client, error) {
 	u, err := url.Parse(spinupUrl)
 	if err != nil {
 		return nil, err
 	}
 
-	BaseURL = u.String()
 	return &Client{
-		AuthToken: token,
-		// BaseURL:    u,
-		HTTPClient: client,
+		AuthToken:   token,
+		BaseURL:     u.String(),
+		Concurrency: DefaultConcurrency,
+		HTTPClient:  client,
+		SizeURI:     SizeURI,
+		SpaceURI:    SpaceURI,
 	}, nil
 }
 
 // GetResource gets details about a resource and unmarshals them them into the passed
-// ResourceType.  It first gets the resource endpoint by calling r.GetEndpoint(id) which
+// ResourceType.  It first gets the resource endpoint by calling r.GetEndpoint(c, params) which
 // is a function on the passed ResourceType interface.
 func (c *Client) GetResource(params map[string]string, r ResourceType) error {
+	return c.GetResourceCtx(context.Background(), params, r)
+}
+
+// GetResourceCtx is GetResource with a context to allow cancellation and deadlines
+func (c *Client) GetResourceCtx(ctx context.Context, params map[string]string, r ResourceType) error {
 	defer timeTrack(time.Now(), "GetResource()")
 
-	endpoint := r.GetEndpoint(params)
+	endpoint := r.GetEndpoint(c, params)
 	log.Infof("getting resource from endpoint: %s", endpoint)
 
-	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return fmt.Errorf("failed creating get resource request with params %+v: %s", params, err)
 	}
@@ -91,18 +117,20 @@ func (c *Client) GetResource(params map[string]string, r ResourceType) error {
 		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
 	}
 
-	res, err := c.HTTPClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed getting resource with params %+v: %s", params, err)
 	}
 
 	if res.StatusCode >= 400 {
-		return fmt.Errorf("error getting resource details: %s", res.Status)
+		return newAPIError(res)
 	}
 
 	log.Infof("got success response from api %s", res.Status)
 
-	if log.GetLevel() == log.DebugLevel {
+	_, sensitive := r.(SensitiveResource)
+
+	if log.GetLevel() == log.DebugLevel && !sensitive {
 		dump, err := httputil.DumpResponse(res, true)
 		if err != nil {
 			log.Fatal(err)
@@ -121,7 +149,9 @@ func (c *Client) GetResource(params map[string]string, r ResourceType) error {
 			}
 
 			log.Debugf("XSRF-TOKEN cookie %+v", decodedValue)
+			c.mu.Lock()
 			c.CSRFToken = decodedValue
+			c.mu.Unlock()
 		}
 	}
 
@@ -131,28 +161,39 @@ func (c *Client) GetResource(params map[string]string, r ResourceType) error {
 	}
 	defer res.Body.Close()
 
-	log.Debugf("read response body: %s", string(body))
+	if sensitive {
+		log.Debugf("read sensitive response body (%d bytes), not logging it", len(body))
+	} else {
+		log.Debugf("read response body: %s", string(body))
+	}
 
 	err = json.Unmarshal(body, r)
 	if err != nil {
 		return fmt.Errorf("failed unmarshalling resource body from json: %s", err)
 	}
 
-	log.Debugf("decoded output: %+v", r)
+	if !sensitive {
+		log.Debugf("decoded output: %+v", r)
+	}
 
 	return nil
 }
 
 // PutResources updates a resource
 func (c *Client) PutResource(params map[string]string, input []byte, r ResourceType) error {
+	return c.PutResourceCtx(context.Background(), params, input, r)
+}
+
+// PutResourceCtx is PutResource with a context to allow cancellation and deadlines
+func (c *Client) PutResourceCtx(ctx context.Context, params map[string]string, input []byte, r ResourceType) error {
 	defer timeTrack(time.Now(), "PutResource()")
 
-	endpoint := r.GetEndpoint(params)
+	endpoint := r.GetEndpoint(c, params)
 	log.Infof("putting resource to endpoint: %s", endpoint)
 
-	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewBuffer(input))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewBuffer(input))
 	if err != nil {
-		return fmt.Errorf("failed creating update request with params %+v, %s: %s", params, string(input), err)
+		return fmt.Errorf("failed creating update request with params %+v: %s", params, err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -162,38 +203,35 @@ func (c *Client) PutResource(params map[string]string, input []byte, r ResourceT
 		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
 	}
 
-	res, err := c.HTTPClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
-		return fmt.Errorf("failed updating resource with params %+v, %s: %s", params, string(input), err)
+		return fmt.Errorf("failed updating resource with params %+v: %s", params, err)
 	}
 
 	if res.StatusCode >= 400 {
-		return fmt.Errorf("error updating resource: %s", res.Status)
+		return newAPIError(res)
 	}
 
 	log.Infof("got success response from api %s", res.Status)
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("failed reading resource body: %s", err)
-	}
-	defer res.Body.Close()
-
-	log.Debugf("got response body: %s", string(body))
-
-	return nil
+	return decodeResponse(res, r)
 }
 
 // PostResource creates a resource
 func (c *Client) PostResource(params map[string]string, input []byte, r ResourceType) error {
+	return c.PostResourceCtx(context.Background(), params, input, r)
+}
+
+// PostResourceCtx is PostResource with a context to allow cancellation and deadlines
+func (c *Client) PostResourceCtx(ctx context.Context, params map[string]string, input []byte, r ResourceType) error {
 	defer timeTrack(time.Now(), "PostResource()")
 
-	endpoint := r.GetEndpoint(params)
+	endpoint := r.GetEndpoint(c, params)
 	log.Infof("posting resource to endpoint: %s", endpoint)
 
-	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(input))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(input))
 	if err != nil {
-		return fmt.Errorf("failed creating create request with params %+v, %s: %s", params, string(input), err)
+		return fmt.Errorf("failed creating create request with params %+v: %s", params, err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -203,17 +241,62 @@ func (c *Client) PostResource(params map[string]string, input []byte, r Resource
 		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
 	}
 
-	res, err := c.HTTPClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
-		return fmt.Errorf("failed creating resource with params %+v, %s: %s", params, string(input), err)
+		return fmt.Errorf("failed creating resource with params %+v: %s", params, err)
 	}
 
 	if res.StatusCode >= 400 {
-		return fmt.Errorf("error creating resource: %s", res.Status)
+		return newAPIError(res)
 	}
 
 	log.Infof("got success response from api %s", res.Status)
 
+	return decodeResponse(res, r)
+}
+
+// DeleteResource deletes a resource, decoding the response (if any) into the passed ResourceType
+func (c *Client) DeleteResource(params map[string]string, r ResourceType) error {
+	return c.DeleteResourceCtx(context.Background(), params, r)
+}
+
+// DeleteResourceCtx is DeleteResource with a context to allow cancellation and deadlines
+func (c *Client) DeleteResourceCtx(ctx context.Context, params map[string]string, r ResourceType) error {
+	defer timeTrack(time.Now(), "DeleteResource()")
+
+	endpoint := r.GetEndpoint(c, params)
+	log.Infof("deleting resource at endpoint: %s", endpoint)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed creating delete request with params %+v: %s", params, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.AuthToken != "" {
+		log.Debugf("setting authorization bearer header")
+		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	}
+
+	res, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed deleting resource with params %+v: %s", params, err)
+	}
+
+	if res.StatusCode >= 400 {
+		return newAPIError(res)
+	}
+
+	log.Infof("got success response from api %s", res.Status)
+
+	return decodeResponse(res, r)
+}
+
+// decodeResponse decodes the body of a successful PUT or POST response into the passed ResourceType.
+// A 204 or an empty body leaves the ResourceType untouched, and a 202 notifies a JobTracker about the
+// asynchronous job that was started.
+func decodeResponse(res *http.Response, r ResourceType) error {
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return fmt.Errorf("failed reading resource body: %s", err)
@@ -222,6 +305,31 @@ func (c *Client) PostResource(params map[string]string, input []byte, r Resource
 
 	log.Debugf("got response body: %s", string(body))
 
+	if res.StatusCode == http.StatusAccepted {
+		if t, ok := r.(JobTracker); ok {
+			if job := parseJob(res, body); job != nil {
+				log.Infof("request accepted as async job %+v", job)
+				t.SetJob(job)
+			}
+		}
+	}
+
+	if res.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(body)) == 0 {
+		log.Debug("no content in response, not decoding")
+		return nil
+	}
+
+	if err := json.Unmarshal(body, r); err != nil {
+		// an accepted request may only return the job details
+		if res.StatusCode == http.StatusAccepted {
+			log.Debugf("unable to decode accepted response into resource: %s", err)
+			return nil
+		}
+		return fmt.Errorf("failed unmarshalling resource body from json: %s", err)
+	}
+
+	log.Debugf("decoded output: %+v", r)
+
 	return nil
 }
 
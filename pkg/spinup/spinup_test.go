@@ -1,6 +1,8 @@
 package spinup
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -11,8 +13,10 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 )
 
 func TestURIVars(t *testing.T) {
@@ -93,8 +97,8 @@ var (
 )
 
 // GetEndpoint returns the url for a mock resource
-func (m *MockResourceInfo) GetEndpoint(params map[string]string) string {
-	return BaseURL + MockInfoURI + "/" + params["id"]
+func (m *MockResourceInfo) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + MockInfoURI + "/" + params["id"]
 }
 func MockResourceGetHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -163,6 +167,41 @@ func TestGetResource(t *testing.T) {
 	}
 }
 
+func TestGetResourceCtx(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := client.GetResourceCtx(ctx, map[string]string{"id": "1"}, &MockResourceInfo{}); err == nil {
+		t.Error("expected error for exceeded deadline, got nil")
+	}
+
+	cctx, ccancel := context.WithCancel(context.Background())
+	ccancel()
+
+	if err := client.GetResourceCtx(cctx, map[string]string{"id": "1"}, &MockResourceInfo{}); err == nil {
+		t.Error("expected error for canceled context, got nil")
+	}
+
+	if _, err := client.ResourcesCtx(cctx, "1"); err == nil {
+		t.Error("expected error for canceled context, got nil")
+	}
+}
+
 func MockResourcePutHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		w.WriteHeader(http.StatusBadRequest)
@@ -212,6 +251,10 @@ func TestPutResource(t *testing.T) {
 		if err := client.PutResource(map[string]string{"id": id}, input, &output); err != nil {
 			t.Errorf("expected nil error, got %s", err)
 		}
+
+		if !reflect.DeepEqual(expected, output) {
+			t.Errorf("expected '%+v', got '%+v'", expected, output)
+		}
 	}
 
 	if err := client.GetResource(map[string]string{"id": "missing"}, &MockResourceInfo{}); err == nil {
@@ -219,12 +262,154 @@ func TestPutResource(t *testing.T) {
 	}
 }
 
+type MockAsyncResourceInfo struct {
+	MockResourceInfo
+	Job *Job `json:"-"`
+}
+
+func (m *MockAsyncResourceInfo) SetJob(job *Job) {
+	m.Job = job
+}
+
+func MockResourcePostHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte{})
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.String(), MockInfoURI+"/") {
+	case "created":
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"10","name":"created"}`))
+	case "accepted":
+		w.Header().Set("Location", "/api/v3/jobs/123")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"job_id":123}`))
+	case "nocontent":
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("not json"))
+	}
+}
+
+func TestPostResource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(MockResourcePostHandler))
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	created := MockAsyncResourceInfo{}
+	if err := client.PostResource(map[string]string{"id": "created"}, []byte(`{}`), &created); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if expected := (MockResourceInfo{ID: "10", Name: "created"}); !reflect.DeepEqual(expected, created.MockResourceInfo) {
+		t.Errorf("expected '%+v', got '%+v'", expected, created.MockResourceInfo)
+	}
+
+	if created.Job != nil {
+		t.Errorf("expected nil job for created resource, got %+v", created.Job)
+	}
+
+	accepted := MockAsyncResourceInfo{}
+	if err := client.PostResource(map[string]string{"id": "accepted"}, []byte(`{}`), &accepted); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if expected := (&Job{ID: "123", Location: "/api/v3/jobs/123"}); !reflect.DeepEqual(expected, accepted.Job) {
+		t.Errorf("expected job '%+v', got '%+v'", expected, accepted.Job)
+	}
+
+	nocontent := MockAsyncResourceInfo{MockResourceInfo: MockResourceInfo{ID: "1", Name: "unchanged"}}
+	if err := client.PostResource(map[string]string{"id": "nocontent"}, []byte(`{}`), &nocontent); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if nocontent.Name != "unchanged" {
+		t.Errorf("expected resource to be unchanged, got '%+v'", nocontent)
+	}
+
+	if err := client.PostResource(map[string]string{"id": "broken"}, []byte(`{}`), &MockAsyncResourceInfo{}); err == nil {
+		t.Error("expected error for invalid json, got nil")
+	}
+}
+
+func MockResourceDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte{})
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.String(), MockInfoURI+"/")
+	switch id {
+	case "accepted":
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"task_id":"abc"}`))
+		return
+	case "notempty":
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error":"bucket is not empty"}`))
+		return
+	}
+
+	if _, ok := testMockInfos[id]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Not Found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestDeleteResource(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(MockResourceDeleteHandler))
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	for id := range testMockInfos {
+		if err := client.DeleteResource(map[string]string{"id": id}, &MockResourceInfo{}); err != nil {
+			t.Errorf("expected nil error, got %s", err)
+		}
+	}
+
+	accepted := MockAsyncResourceInfo{}
+	if err := client.DeleteResource(map[string]string{"id": "accepted"}, &accepted); err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if accepted.Job == nil || accepted.Job.ID != "abc" {
+		t.Errorf("expected job with id abc, got %+v", accepted.Job)
+	}
+
+	if err := client.DeleteResource(map[string]string{"id": "missing"}, &MockResourceInfo{}); !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err := client.DeleteResource(map[string]string{"id": "notempty"}, &MockResourceInfo{}); !hasStatusCode(err, http.StatusConflict) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
 func TestNew(t *testing.T) {
+	spinupUrl := "https://spinup.example.com"
 	expected := &Client{
-		HTTPClient: http.DefaultClient,
-		AuthToken:  "token",
+		AuthToken:   "token",
+		BaseURL:     spinupUrl,
+		Concurrency: 8,
+		HTTPClient:  http.DefaultClient,
+		SizeURI:     "/api/v3/sizes",
+		SpaceURI:    "/api/v3/spaces",
 	}
-	spinupUrl := "https://spinup.example.com"
 
 	output, err := New(spinupUrl, expected.HTTPClient, "token")
 	if err != nil {
@@ -239,16 +424,55 @@ func TestNew(t *testing.T) {
 		t.Errorf("expected '%+v', got '%+v'", expected, output)
 	}
 
-	if BaseURL != spinupUrl {
-		t.Errorf("expected BaseURL to be set to '%s', got '%s'", spinupUrl, BaseURL)
-	}
-
 	// TODO find a URL that throws an error
 	// if _, err := New("⌘", expected.HTTPClient); err == nil {
 	// 	t.Error("expected error, got nil")
 	// }
 }
 
+func TestMultipleClients(t *testing.T) {
+	ts1 := httptest.NewServer(http.HandlerFunc(MockResourceGetHandler))
+	defer ts1.Close()
+
+	ts2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("Not Found"))
+	}))
+	defer ts2.Close()
+
+	client1, err := New(ts1.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	client2, err := New(ts2.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Errorf("expected nil error, got %s", err)
+	}
+
+	if client1.BaseURL != ts1.URL {
+		t.Errorf("expected first client BaseURL to be '%s', got '%s'", ts1.URL, client1.BaseURL)
+	}
+
+	if client2.BaseURL != ts2.URL {
+		t.Errorf("expected second client BaseURL to be '%s', got '%s'", ts2.URL, client2.BaseURL)
+	}
+
+	expected := testMockInfos["1"]
+	output := MockResourceInfo{}
+	if err := client1.GetResource(map[string]string{"id": "1"}, &output); err != nil {
+		t.Errorf("expected nil error from first client, got %s", err)
+	}
+
+	if !reflect.DeepEqual(expected, output) {
+		t.Errorf("expected '%+v', got '%+v'", expected, output)
+	}
+
+	if err := client2.GetResource(map[string]string{"id": "1"}, &MockResourceInfo{}); err == nil {
+		t.Error("expected error from second client, got nil")
+	}
+}
+
 func TestFlexIntUnmarshallJSON(t *testing.T) {
 	expectedInts := map[int]FlexInt{}
 	for i := 0; i <= 100; i += 1 {
@@ -391,3 +615,45 @@ func TestNameValueFromString(t *testing.T) {
 		}
 	}
 }
+
+func TestGetResourceSensitive(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != SpaceURI+"/myspace/secrets/db-password/value" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"name":"db-password","value":"hunter2","version_id":"v1"}`))
+	}))
+	defer ts.Close()
+
+	client, err := New(ts.URL, http.DefaultClient, "token")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	level, out := log.GetLevel(), log.StandardLogger().Out
+	log.SetLevel(log.DebugLevel)
+	log.SetOutput(buf)
+	defer func() {
+		log.SetLevel(level)
+		log.SetOutput(out)
+	}()
+
+	value, err := client.SecretValueCtx(context.Background(), "myspace", "db-password")
+	if err != nil {
+		t.Fatalf("expected nil error, got %s", err)
+	}
+
+	if value.Value != "hunter2" || value.VersionId != "v1" {
+		t.Errorf("unexpected secret value %+v", value)
+	}
+
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("expected the secret value not to be logged, got %s", buf.String())
+	}
+
+	if !strings.Contains(buf.String(), "not logging it") {
+		t.Errorf("expected the sensitive response to be noted in the debug log, got %s", buf.String())
+	}
+}
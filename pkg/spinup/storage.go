@@ -1,6 +1,10 @@
 package spinup
 
-import log "github.com/sirupsen/logrus"
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
 
 // S3StorageInfo is the info about a S3 storage bucket
 type S3StorageInfo struct {
@@ -27,8 +31,8 @@ type S3StorageUserAccessKey struct {
 }
 
 // GetEndpoint returns the url for a storage resource
-func (s *S3StorageInfo) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/storage/" + params["name"]
+func (s *S3StorageInfo) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/storage/" + params["name"]
 }
 
 // S3StorageSize is the size for a container satisfying the Size interface
@@ -38,8 +42,13 @@ type S3StorageSize struct {
 
 // S3StorageSize returns S3StorageSize as a Size
 func (c *Client) S3StorageSize(id string) (*S3StorageSize, error) {
+	return c.S3StorageSizeCtx(context.Background(), id)
+}
+
+// S3StorageSizeCtx is S3StorageSize with a context to allow cancellation and deadlines
+func (c *Client) S3StorageSizeCtx(ctx context.Context, id string) (*S3StorageSize, error) {
 	size := &S3StorageSize{}
-	if err := c.GetResource(map[string]string{"id": id}, size); err != nil {
+	if err := c.GetResourceCtx(ctx, map[string]string{"id": id}, size); err != nil {
 		return nil, err
 	}
 
@@ -49,11 +58,47 @@ func (c *Client) S3StorageSize(id string) (*S3StorageSize, error) {
 }
 
 // GetEndpoint returns the URL for the list of users of a storage resource
-func (s *S3StorageUsers) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/storage/" + params["name"] + "/users"
+func (s *S3StorageUsers) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/storage/" + params["name"] + "/users"
 }
 
 // GetEndpoint returns the URL for the details about a user of a storage resource
-func (s *S3StorageUser) GetEndpoint(params map[string]string) string {
-	return BaseURL + SpaceURI + "/" + params["space"] + "/storage/" + params["name"] + "/users/" + params["username"]
+func (s *S3StorageUser) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/storage/" + params["name"] + "/users/" + params["username"]
+}
+
+// S3StorageInput is the input to create a S3 storage bucket
+type S3StorageInput struct {
+	Name string   `json:"name"`
+	Size *FlexInt `json:"size_id"`
+}
+
+// CreateS3StorageOutput is returned from the api when a S3 storage bucket is created in a space
+type CreateS3StorageOutput struct {
+	Name string
+}
+
+// GetEndpoint returns the endpoint to create a S3 storage bucket in a space
+func (s *CreateS3StorageOutput) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/storage"
+}
+
+// S3StorageUserInput is the input to create a user of a storage resource
+type S3StorageUserInput struct {
+	Username string `json:"username"`
+}
+
+// CreateS3StorageUserOutput is returned from the api when a storage user is created, the secret access
+// key is only returned once
+type CreateS3StorageUserOutput struct {
+	UserName  string
+	AccessKey *struct {
+		AccessKeyId     string
+		SecretAccessKey string
+	}
+}
+
+// GetEndpoint returns the endpoint to create a user of a storage resource
+func (s *CreateS3StorageUserOutput) GetEndpoint(c *Client, params map[string]string) string {
+	return c.BaseURL + c.SpaceURI + "/" + params["space"] + "/storage/" + params["name"] + "/users"
 }